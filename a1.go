@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// a1Range is a parsed A1-notation range such as "A2:E" or "B3:D10".
+// Columns are 0-based indices (A is 0); rows are 1-based as in the sheet.
+// A zero endRow means the range is open-ended.
+type a1Range struct {
+	startCol, startRow int
+	endCol, endRow     int
+}
+
+// parseA1Range parses a range of the form "A2:E", "A2:E10" or "A:E".
+// A start cell without a row number starts at row 1.
+func parseA1Range(r string) (a1Range, error) {
+	parts := strings.Split(r, ":")
+	if len(parts) != 2 {
+		return a1Range{}, fmt.Errorf("range %q is not of the form START:END", r)
+	}
+
+	startCol, startRow, err := parseA1Cell(parts[0])
+	if err != nil {
+		return a1Range{}, fmt.Errorf("bad start of range %q: %v", r, err)
+	}
+	if startRow == 0 {
+		startRow = 1
+	}
+
+	endCol, endRow, err := parseA1Cell(parts[1])
+	if err != nil {
+		return a1Range{}, fmt.Errorf("bad end of range %q: %v", r, err)
+	}
+
+	if endCol < startCol || (endRow != 0 && endRow < startRow) {
+		return a1Range{}, fmt.Errorf("range %q ends before it starts", r)
+	}
+
+	return a1Range{
+		startCol: startCol,
+		startRow: startRow,
+		endCol:   endCol,
+		endRow:   endRow,
+	}, nil
+}
+
+func (r a1Range) String() string {
+	end := columnLetters(r.endCol)
+	if r.endRow != 0 {
+		end += strconv.Itoa(r.endRow)
+	}
+	return fmt.Sprintf("%s%d:%s", columnLetters(r.startCol), r.startRow, end)
+}
+
+// ensureRangeCovers widens the range r, if needed, so that it includes col,
+// a 0-based column index counted from the start of the range. A range that
+// can't be parsed is returned as is.
+func ensureRangeCovers(r string, col int) string {
+	rng, err := parseA1Range(r)
+	if err != nil || rng.startCol+col <= rng.endCol {
+		return r
+	}
+	rng.endCol = rng.startCol + col
+	return rng.String()
+}
+
+// parseA1Cell splits a cell reference like "E12" into its 0-based column
+// index and row number. The row is 0 if the reference has none.
+func parseA1Cell(c string) (col, row int, err error) {
+	i := strings.IndexFunc(c, func(r rune) bool { return r >= '0' && r <= '9' })
+	letters, digits := c, ""
+	if i >= 0 {
+		letters, digits = c[:i], c[i:]
+	}
+
+	col, err = columnIndex(letters)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if digits != "" {
+		row, err = strconv.Atoi(digits)
+		if err != nil || row < 1 {
+			return 0, 0, fmt.Errorf("bad row number in %q", c)
+		}
+	}
+
+	return col, row, nil
+}
+
+// columnIndex converts column letters ("A", "Z", "AA") to a 0-based index.
+func columnIndex(letters string) (int, error) {
+	if letters == "" {
+		return 0, fmt.Errorf("missing column letters")
+	}
+
+	idx := 0
+	for _, r := range strings.ToUpper(letters) {
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("bad column %q", letters)
+		}
+		idx = idx*26 + int(r-'A'+1)
+	}
+	return idx - 1, nil
+}
+
+// optionalColumn is like columnIndex, but returns -1 for empty letters.
+func optionalColumn(letters string) (int, error) {
+	if letters == "" {
+		return -1, nil
+	}
+	return columnIndex(letters)
+}
+
+// parseColumnList parses a comma-separated list of column letters, such as
+// "D,E,F", into their 0-based indices.
+func parseColumnList(spec string) ([]int, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	var cols []int
+	for _, letters := range strings.Split(spec, ",") {
+		col, err := columnIndex(strings.TrimSpace(letters))
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// columnLetters converts a 0-based column index to its letters.
+func columnLetters(idx int) string {
+	var b []byte
+	for idx++; idx > 0; idx = (idx - 1) / 26 {
+		b = append([]byte{byte('A' + (idx-1)%26)}, b...)
+	}
+	return string(b)
+}
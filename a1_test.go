@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseA1Range(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    a1Range
+		wantErr bool
+	}{
+		{in: "A2:E", want: a1Range{startCol: 0, startRow: 2, endCol: 4}},
+		{in: "A2:E10", want: a1Range{startCol: 0, startRow: 2, endCol: 4, endRow: 10}},
+		{in: "A:E", want: a1Range{startCol: 0, startRow: 1, endCol: 4}},
+		{in: "AA3:AB", want: a1Range{startCol: 26, startRow: 3, endCol: 27}},
+		{in: "b2:c", want: a1Range{startCol: 1, startRow: 2, endCol: 2}},
+		{in: "A2", wantErr: true},
+		{in: "E2:A", wantErr: true},
+		{in: "A10:E2", wantErr: true},
+		{in: "A0:E", wantErr: true},
+		{in: "2:E", wantErr: true},
+	} {
+		got, err := parseA1Range(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseA1Range(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && got != tc.want {
+			t.Errorf("parseA1Range(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestEnsureRangeCovers(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		col  int
+		want string
+	}{
+		{in: "A2:E", col: 3, want: "A2:E"},
+		{in: "A2:E", col: 4, want: "A2:E"},
+		{in: "A2:E", col: 6, want: "A2:G"},
+		{in: "B2:C10", col: 3, want: "B2:E10"},
+		{in: "not a range", col: 3, want: "not a range"},
+	} {
+		if got := ensureRangeCovers(tc.in, tc.col); got != tc.want {
+			t.Errorf("ensureRangeCovers(%q, %d) = %q, want %q", tc.in, tc.col, got, tc.want)
+		}
+	}
+}
+
+func TestColumnLetters(t *testing.T) {
+	for idx, letters := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnLetters(idx); got != letters {
+			t.Errorf("columnLetters(%d) = %q, want %q", idx, got, letters)
+		}
+		if got, err := columnIndex(letters); err != nil || got != idx {
+			t.Errorf("columnIndex(%q) = %d, %v, want %d", letters, got, err, idx)
+		}
+	}
+	for _, bad := range []string{"", "A1", "É", "-"} {
+		if _, err := columnIndex(bad); err == nil {
+			t.Errorf("columnIndex(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParseColumnList(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{in: ""},
+		{in: "D", want: []int{3}},
+		{in: "D, e ,AA", want: []int{3, 4, 26}},
+		{in: "D,,E", wantErr: true},
+		{in: "D,4", wantErr: true},
+	} {
+		got, err := parseColumnList(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseColumnList(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseColumnList(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	if got, err := optionalColumn(""); err != nil || got != -1 {
+		t.Errorf("optionalColumn(\"\") = %d, %v, want -1", got, err)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"time"
+)
+
+// The strategies of --account_strategy, by which accountRouter picks the
+// account that posts each row.
+const (
+	// accountsPerRowColumn posts each row from the account named in its
+	// --account_column, or the first account if that's empty.
+	accountsPerRowColumn = "per-row-column"
+	// accountsRoundRobin posts the rows from each account in turn.
+	accountsRoundRobin = "round-robin"
+	// accountsRandom posts each row from an account picked at random.
+	accountsRandom = "random"
+)
+
+// account is one of the Twitter accounts of --accounts_file. They all post
+// through the app of --twitter_consumer_key.
+type account struct {
+	Name         string `json:"name"`
+	AccessToken  string `json:"access_token"`
+	AccessSecret string `json:"access_secret"`
+}
+
+// loadAccounts reads --accounts_file, a JSON list of accounts.
+func loadAccounts(path string) ([]account, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("there are no accounts")
+	}
+	seen := make(map[string]bool)
+	for i, a := range accounts {
+		if a.Name == "" || a.AccessToken == "" || a.AccessSecret == "" {
+			return nil, fmt.Errorf("account %d needs a name, access_token and access_secret", i)
+		}
+		if seen[a.Name] {
+			return nil, fmt.Errorf("there are two accounts named %q", a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return accounts, nil
+}
+
+// accountRouter is a Poster that spreads posts across several accounts.
+// Before each row, choose picks the account that posts it, along with any
+// media and replies, as --account_strategy says. Each call is then made
+// from the account named by its context, as set by withAccount, or the
+// first account if it names none. The router forwards the optional
+// interfaces that all of the accounts' Posters support.
+type accountRouter struct {
+	strategy string
+	names    []string
+	posters  map[string]Poster
+	rng      *rand.Rand // for accountsRandom.
+	next     int        // the index of the next account, for accountsRoundRobin.
+}
+
+type accountKey struct{}
+
+// withAccount returns a context whose calls to an accountRouter are made
+// from the named account.
+func withAccount(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, accountKey{}, name)
+}
+
+// poster returns the Poster of the account named by ctx.
+func (a *accountRouter) poster(ctx context.Context) Poster {
+	if name, ok := ctx.Value(accountKey{}).(string); ok {
+		if p, ok := a.posters[name]; ok {
+			return p
+		}
+	}
+	return a.posters[a.names[0]]
+}
+
+// newAccountRouter returns a router across the Twitter accounts, which
+// post with the app of tc. seed seeds the random strategy.
+func newAccountRouter(strategy string, accounts []account, tc *twitterConfig, seed int64) (*accountRouter, error) {
+	switch strategy {
+	case accountsPerRowColumn, accountsRoundRobin, accountsRandom:
+	default:
+		return nil, fmt.Errorf("unknown --account_strategy %q", strategy)
+	}
+
+	a := &accountRouter{
+		strategy: strategy,
+		posters:  make(map[string]Poster),
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+	for _, acc := range accounts {
+		atc := *tc
+		atc.accessToken, atc.accessSecret = acc.AccessToken, acc.AccessSecret
+		a.names = append(a.names, acc.Name)
+		a.posters[acc.Name] = newTwitterPoster(&atc)
+	}
+	return a, nil
+}
+
+// choose returns the name of the account to post the next row, given the
+// name in its --account_column, which is only used by
+// accountsPerRowColumn. It's not safe for concurrent use.
+func (a *accountRouter) choose(name string) (string, error) {
+	switch a.strategy {
+	case accountsPerRowColumn:
+		if name == "" {
+			return a.names[0], nil
+		}
+		if _, ok := a.posters[name]; !ok {
+			return "", fmt.Errorf("there is no account named %q", name)
+		}
+		return name, nil
+	case accountsRoundRobin:
+		name = a.names[a.next]
+		a.next = (a.next + 1) % len(a.names)
+		return name, nil
+	default:
+		return a.names[a.rng.Intn(len(a.names))], nil
+	}
+}
+
+func (a *accountRouter) wrapped() []Poster {
+	var posters []Poster
+	for _, name := range a.names {
+		posters = append(posters, a.posters[name])
+	}
+	return posters
+}
+
+func (a *accountRouter) Post(ctx context.Context, p *post) (string, error) {
+	return a.poster(ctx).Post(ctx, p)
+}
+
+func (a *accountRouter) UploadMedia(ctx context.Context, data []byte) (string, error) {
+	u, ok := a.poster(ctx).(mediaUploader)
+	if !ok {
+		return "", errors.New("the backend does not support media")
+	}
+	return u.UploadMedia(ctx, data)
+}
+
+func (a *accountRouter) UploadVideo(ctx context.Context, data []byte, mimeType string) (string, error) {
+	v, ok := a.poster(ctx).(videoUploader)
+	if !ok {
+		return a.UploadMedia(ctx, data)
+	}
+	return v.UploadVideo(ctx, data, mimeType)
+}
+
+func (a *accountRouter) Repost(ctx context.Context, id string) (string, error) {
+	rp, ok := a.poster(ctx).(reposter)
+	if !ok {
+		return "", errors.New("the backend can't repost")
+	}
+	return rp.Repost(ctx, id)
+}
+
+func (a *accountRouter) LatestPostID(ctx context.Context, screenName string) (string, error) {
+	tr, ok := a.poster(ctx).(timelineReader)
+	if !ok {
+		return "", errors.New("the backend can't look up an account's posts")
+	}
+	return tr.LatestPostID(ctx, screenName)
+}
+
+// RateLimit returns the rate limit of whichever account has the fewest
+// requests left, as that's the one that would run out first.
+func (a *accountRouter) RateLimit() (int, time.Time, bool) {
+	var remaining int
+	var reset time.Time
+	var known bool
+	for _, name := range a.names {
+		rl, ok := a.posters[name].(rateLimitReporter)
+		if !ok {
+			continue
+		}
+		if n, t, ok := rl.RateLimit(); ok && (!known || n < remaining) {
+			remaining, reset, known = n, t, true
+		}
+	}
+	return remaining, reset, known
+}
+
+// Verify checks the credentials of every account.
+func (a *accountRouter) Verify(ctx context.Context) error {
+	for _, name := range a.names {
+		if v, ok := a.posters[name].(verifier); ok {
+			if err := v.Verify(ctx); err != nil {
+				return fmt.Errorf("account %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestRouter returns a router across fullPosters named by names.
+func newTestRouter(strategy string, names ...string) (*accountRouter, map[string]*fullPoster) {
+	a := &accountRouter{strategy: strategy, names: names, posters: make(map[string]Poster), rng: rand.New(rand.NewSource(1))}
+	fakes := make(map[string]*fullPoster)
+	for i, name := range names {
+		fakes[name] = &fullPoster{name: name, remaining: 10 * (i + 1)}
+		a.posters[name] = fakes[name]
+	}
+	return a, fakes
+}
+
+func TestLoadAccounts(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "accounts",
+			content: `[{"name": "a", "access_token": "t1", "access_secret": "s1"}, {"name": "b", "access_token": "t2", "access_secret": "s2"}]`,
+			want:    []string{"a", "b"},
+		},
+		{name: "none", content: `[]`, wantErr: true},
+		{name: "not JSON", content: `a,t1,s1`, wantErr: true},
+		{name: "missing secret", content: `[{"name": "a", "access_token": "t1"}]`, wantErr: true},
+		{
+			name:    "same name twice",
+			content: `[{"name": "a", "access_token": "t1", "access_secret": "s1"}, {"name": "a", "access_token": "t2", "access_secret": "s2"}]`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "accounts.json")
+			if err := ioutil.WriteFile(path, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			accounts, err := loadAccounts(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("loadAccounts() = %v, want error: %t", err, tc.wantErr)
+			}
+			var names []string
+			for _, a := range accounts {
+				names = append(names, a.Name)
+			}
+			if !reflect.DeepEqual(names, tc.want) {
+				t.Errorf("loadAccounts() = accounts %q, want %q", names, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccountRouterChoose(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		strategy string
+		columns  []string // each row's --account_column.
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "round robin",
+			strategy: accountsRoundRobin,
+			columns:  []string{"", "", "", "", ""},
+			want:     []string{"a", "b", "c", "a", "b"},
+		},
+		{
+			name:     "per-row column",
+			strategy: accountsPerRowColumn,
+			columns:  []string{"c", "", "b"},
+			want:     []string{"c", "a", "b"},
+		},
+		{
+			name:     "unknown account",
+			strategy: accountsPerRowColumn,
+			columns:  []string{"z"},
+			wantErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, _ := newTestRouter(tc.strategy, "a", "b", "c")
+			var got []string
+			for _, col := range tc.columns {
+				name, err := a.choose(col)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("choose(%q) = %v, want error: %t", col, err, tc.wantErr)
+				}
+				if err == nil {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("chose %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccountRouterRandomIsSeeded(t *testing.T) {
+	picks := func() []string {
+		a, _ := newTestRouter(accountsRandom, "a", "b", "c")
+		var names []string
+		for i := 0; i < 20; i++ {
+			name, err := a.choose("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	first, second := picks(), picks()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("the same seed picked %q, then %q", first, second)
+	}
+	seen := make(map[string]bool)
+	for _, name := range first {
+		seen[name] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("picked only %q in 20 rows", first)
+	}
+}
+
+func TestNewAccountRouterRejectsUnknownStrategy(t *testing.T) {
+	if _, err := newAccountRouter("busiest", nil, &twitterConfig{}, 0); err == nil {
+		t.Error("newAccountRouter() accepted an unknown strategy")
+	}
+}
+
+// Each call is made from the account named by its context, whichever was
+// chosen last, so that concurrent rows don't cross accounts.
+func TestAccountRouterRoutesByContext(t *testing.T) {
+	a, fakes := newTestRouter(accountsRoundRobin, "a", "b")
+	ctxA, ctxB := withAccount(context.Background(), "a"), withAccount(context.Background(), "b")
+
+	// Choosing the next account doesn't change where ctxA's calls go.
+	a.choose("")
+	a.choose("")
+	a.Post(ctxA, &post{status: "from a"})
+	a.Post(ctxB, &post{status: "from b"})
+	a.Post(context.Background(), &post{status: "default"})
+	a.UploadMedia(ctxB, nil)
+	a.UploadVideo(ctxA, nil, "video/mp4")
+	a.Repost(ctxB, "1")
+	a.LatestPostID(ctxA, "me")
+
+	if got, want := fakes["a"].statuses(), []string{"from a", "default"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("account a posted %q, want %q", got, want)
+	}
+	if got, want := fakes["b"].statuses(), []string{"from b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("account b posted %q, want %q", got, want)
+	}
+	if got, want := fakes["a"].calls, []string{"video", "latest me"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("account a was called for %q, want %q", got, want)
+	}
+	if got, want := fakes["b"].calls, []string{"media", "repost 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("account b was called for %q, want %q", got, want)
+	}
+}
+
+func TestAccountRouterRateLimitAndVerify(t *testing.T) {
+	a, fakes := newTestRouter(accountsRoundRobin, "a", "b", "c")
+	fakes["b"].remaining = 3
+	if n, _, ok := a.RateLimit(); !ok || n != 3 {
+		t.Errorf("RateLimit() = %d, %t, want the fewest remaining, 3", n, ok)
+	}
+
+	if err := a.Verify(context.Background()); err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	for name, f := range fakes {
+		if !reflect.DeepEqual(f.calls, []string{"verify"}) {
+			t.Errorf("account %s was called for %q, want it verified", name, f.calls)
+		}
+	}
+}
+
+// The router supports an optional interface only if every account's Poster
+// does.
+func TestAccountRouterForwardsOnlyShared(t *testing.T) {
+	a, _ := newTestRouter(accountsRoundRobin, "a", "b")
+	if _, ok := optional[mediaUploader](a); !ok {
+		t.Error("doesn't support media, though every account does")
+	}
+
+	a.posters["b"] = &fakePoster{}
+	if _, ok := optional[mediaUploader](a); ok {
+		t.Error("supports media, though account b doesn't")
+	}
+	if _, ok := optional[timelineReader](a); ok {
+		t.Error("can read timelines, though account b can't")
+	}
+	if _, ok := optional[reposter](a); ok {
+		t.Error("can repost, though account b can't")
+	}
+}
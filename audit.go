@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// AuditEntry is a single line of the audit log, recording one post.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Row    int       `json:"row"`
+	ID     string    `json:"id"`
+	SHA256 string    `json:"sha256"`
+	// Title is the start of the post's first line, and URL its web URL,
+	// for --recap.
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// maxAuditTitle is the most runes of a post kept as its AuditEntry.Title.
+const maxAuditTitle = 80
+
+// auditLog appends an AuditEntry per post to a file. Existing lines are
+// never rewritten.
+type auditLog struct {
+	f  *os.File
+	bc *backendConfig // for the URLs of posts.
+}
+
+func openAuditLog(path string, bc *backendConfig) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{f: f, bc: bc}, nil
+}
+
+// readAuditLog reads the entries of the audit log at path, oldest first.
+func readAuditLog(path string) ([]AuditEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []AuditEntry
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// record appends an entry for the post of status from row num with the given ID.
+func (a *auditLog) record(num int, id, status string) error {
+	line, err := json.Marshal(&AuditEntry{
+		Time:   time.Now().UTC(),
+		Row:    num,
+		ID:     id,
+		SHA256: statusHash(status),
+		Title:  truncate(strings.TrimSpace(strings.SplitN(status, "\n", 2)[0]), maxAuditTitle, runeLength),
+		URL:    postURL(a.bc, id),
+	})
+	if err != nil {
+		return err
+	}
+
+	// A single write keeps the line whole, even with concurrent writers.
+	_, err = a.f.Write(append(line, '\n'))
+	return err
+}
+
+func (a *auditLog) Close() error {
+	return a.f.Close()
+}
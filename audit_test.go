@@ -0,0 +1,48 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLogRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	a, err := openAuditLog(path, &backendConfig{name: backendTwitter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		num        int
+		id, status string
+	}{
+		{num: 2, id: "10", status: "  First post\nwith a second line"},
+		{num: 3, id: "11", status: "Second post"},
+	} {
+		if err := a.record(e.num, e.id, e.status); err != nil {
+			t.Fatalf("record() failed: %v", err)
+		}
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readAuditLog(path)
+	if err != nil {
+		t.Fatalf("readAuditLog() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("read %d entries, want 2", len(entries))
+	}
+	for i, want := range []AuditEntry{
+		{Row: 2, ID: "10", Title: "First post", URL: "https://twitter.com/i/web/status/10"},
+		{Row: 3, ID: "11", Title: "Second post", URL: "https://twitter.com/i/web/status/11"},
+	} {
+		got := entries[i]
+		if got.Row != want.Row || got.ID != want.ID || got.Title != want.Title || got.URL != want.URL {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want)
+		}
+		if got.SHA256 == "" || got.Time.IsZero() {
+			t.Errorf("entry %d = %+v, want a hash and time", i, got)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+)
+
+// The ways of authorizing Sheets access.
+const (
+	// authOAuth authorizes as a user, with the client secret file and a
+	// token cached from an interactive or device flow.
+	authOAuth = "oauth"
+	// authServiceAccount authorizes as the service account in
+	// --service_account_file.
+	authServiceAccount = "service_account"
+	// authADC uses Application Default Credentials, such as those of a
+	// GCP instance.
+	authADC = "adc"
+)
+
+// sheetsAuthStrategy returns which way of authorizing Sheets access the
+// flags select.
+func sheetsAuthStrategy(sc *sheetsConfig) (string, error) {
+	switch {
+	case sc.useADC && sc.serviceAccountFile != "":
+		return "", fmt.Errorf("%w: --use_adc and --service_account_file are mutually exclusive", ErrConfig)
+	case sc.useADC:
+		return authADC, nil
+	case sc.serviceAccountFile != "":
+		return authServiceAccount, nil
+	default:
+		return authOAuth, nil
+	}
+}
+
+// newSheetsClient returns an HTTP client authorized for Sheets access by
+// the selected strategy.
+func newSheetsClient(ctx context.Context, sc *sheetsConfig) (*http.Client, error) {
+	strategy, err := sheetsAuthStrategy(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strategy {
+	case authADC:
+		creds, err := google.FindDefaultCredentials(ctx, authScopes(sc)...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: Application Default Credentials are not available: %w", ErrAuth, err)
+		}
+		return oauth2.NewClient(ctx, creds.TokenSource), nil
+	case authServiceAccount:
+		data, err := ioutil.ReadFile(sc.serviceAccountFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read service account file: %w", ErrAuth, err)
+		}
+		config, err := google.JWTConfigFromJSON(data, authScopes(sc)...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to create config from service account file at %q: %w", ErrAuth, sc.serviceAccountFile, err)
+		}
+		return config.Client(ctx), nil
+	default:
+		return oauthClient(ctx, sc)
+	}
+}
+
+// explainSheetsError returns guidance on fixing err, if it's a Sheets API
+// error with a known cause, or "" otherwise.
+func explainSheetsError(err error) string {
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) {
+		return ""
+	}
+
+	switch gErr.Code {
+	case http.StatusForbidden:
+		return "Sheets denied access to the spreadsheet. With --service_account_file, share the spreadsheet " +
+			"with the service account's email (client_email in the key file). Otherwise, check that the " +
+			"account you authorized can edit the spreadsheet and granted the " + permScope + " scope; " +
+			"to authorize again, delete the cached token in ~/.credentials."
+	case http.StatusNotFound:
+		return "Sheets found no such spreadsheet or sheet. Check --sheet_id, which is the long ID in the " +
+			"spreadsheet's URL, and --sheet_name, which is the name of the tab."
+	case http.StatusBadRequest:
+		return "Sheets rejected the request. Check that --read_range and the column flags are valid A1 notation " +
+			"and that --sheet_name names an existing tab."
+	default:
+		return ""
+	}
+}
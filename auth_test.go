@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestSheetsAuthStrategy(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		sc      sheetsConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: authOAuth},
+		{name: "ADC", sc: sheetsConfig{useADC: true}, want: authADC},
+		{name: "service account", sc: sheetsConfig{serviceAccountFile: "sa.json"}, want: authServiceAccount},
+		{name: "both", sc: sheetsConfig{useADC: true, serviceAccountFile: "sa.json"}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sheetsAuthStrategy(&tc.sc)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("sheetsAuthStrategy() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrConfig) {
+				t.Errorf("sheetsAuthStrategy() = %v, want an ErrConfig", err)
+			}
+			if got != tc.want {
+				t.Errorf("sheetsAuthStrategy() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExplainSheetsError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "forbidden", err: &googleapi.Error{Code: http.StatusForbidden}, want: "share the spreadsheet"},
+		{name: "wrapped not found", err: fmt.Errorf("%w: %w", ErrSheetRead, &googleapi.Error{Code: http.StatusNotFound}), want: "--sheet_id"},
+		{name: "bad request", err: &googleapi.Error{Code: http.StatusBadRequest}, want: "A1 notation"},
+		{name: "server error", err: &googleapi.Error{Code: http.StatusInternalServerError}},
+		{name: "not an API error", err: errors.New("connection refused")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := explainSheetsError(tc.err)
+			if tc.want == "" {
+				if got != "" {
+					t.Errorf("explainSheetsError() = %q, want none", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tc.want) {
+				t.Errorf("explainSheetsError() = %q, want it to mention %q", got, tc.want)
+			}
+		})
+	}
+}
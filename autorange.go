@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// detectRange returns the range of the configured sheet that --auto_range
+// reads, from its grid properties.
+func detectRange(srv *sheets.Service, sc *sheetsConfig) (string, error) {
+	resp, err := srv.Spreadsheets.Get(sc.id).Fields("sheets.properties").Do()
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to get the properties of spreadsheet %q: %w", ErrSheetRead, sc.id, err)
+	}
+
+	for _, s := range resp.Sheets {
+		if s.Properties != nil && s.Properties.Title == sc.name {
+			return gridRange(s.Properties.GridProperties, sc.startRow)
+		}
+	}
+	return "", fmt.Errorf("%w: spreadsheet %q has no sheet named %q", ErrConfig, sc.id, sc.name)
+}
+
+// gridRange returns a range covering every column of a grid, from startRow
+// on. The end row is left open, since reading leaves out trailing empty
+// rows anyway. A grid with no cells from startRow on has no data.
+func gridRange(gp *sheets.GridProperties, startRow int) (string, error) {
+	if gp == nil || gp.ColumnCount < 1 || gp.RowCount < int64(startRow) {
+		return "", ErrNoData
+	}
+	return a1Range{
+		startCol: 0,
+		startRow: startRow,
+		endCol:   int(gp.ColumnCount) - 1,
+	}.String(), nil
+}
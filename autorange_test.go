@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+func TestGridRange(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		gp       *sheets.GridProperties
+		startRow int
+		want     string
+		wantErr  error
+	}{
+		{name: "default grid", gp: &sheets.GridProperties{RowCount: 1000, ColumnCount: 26}, startRow: 2, want: "A2:Z"},
+		{name: "wide grid", gp: &sheets.GridProperties{RowCount: 10, ColumnCount: 28}, startRow: 1, want: "A1:AB"},
+		{name: "last row", gp: &sheets.GridProperties{RowCount: 2, ColumnCount: 1}, startRow: 2, want: "A2:A"},
+		{name: "too few rows", gp: &sheets.GridProperties{RowCount: 1, ColumnCount: 5}, startRow: 2, wantErr: ErrNoData},
+		{name: "no columns", gp: &sheets.GridProperties{RowCount: 10}, startRow: 2, wantErr: ErrNoData},
+		{name: "no properties", startRow: 2, wantErr: ErrNoData},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := gridRange(tc.gp, tc.startRow)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("gridRange() = %v, want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("gridRange() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
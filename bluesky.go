@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Bluesky limits posts to 300 graphemes. Statuses are truncated by rune,
+// and a string never has more graphemes than runes, so this is safe.
+const maxBlueskyPostSize = 300
+
+const blueskyPostType = "app.bsky.feed.post"
+
+// blueskyPoster posts to Bluesky through the AT Protocol XRPC API.
+type blueskyPoster struct {
+	bc     *blueskyConfig
+	client *http.Client
+
+	// The session is created on the first post, and refreshed when its
+	// access token expires.
+	did, accessJwt, refreshJwt string
+}
+
+// errSessionExpired is returned by an XRPC call whose access token was
+// rejected, as it is once it expires.
+var errSessionExpired = errors.New("the Bluesky session has expired")
+
+func newBlueskyPoster(bc *blueskyConfig, client *http.Client) *blueskyPoster {
+	return &blueskyPoster{bc: bc, client: client}
+}
+
+// blueskyRecord is an app.bsky.feed.post record.
+type blueskyRecord struct {
+	Type      string `json:"$type"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// newBlueskyRecord builds the post record for status, created at t.
+func newBlueskyRecord(status string, t time.Time) *blueskyRecord {
+	return &blueskyRecord{
+		Type:      blueskyPostType,
+		Text:      status,
+		CreatedAt: t.UTC().Format(time.RFC3339),
+	}
+}
+
+func (b *blueskyPoster) Post(ctx context.Context, p *post) (string, error) {
+	if b.accessJwt == "" {
+		if err := b.createSession(ctx); err != nil {
+			return "", fmt.Errorf("%w: failed to log in to Bluesky as %q: %w", ErrAuth, b.bc.handle, err)
+		}
+	}
+
+	req := struct {
+		Repo       string         `json:"repo"`
+		Collection string         `json:"collection"`
+		Record     *blueskyRecord `json:"record"`
+	}{
+		Repo:       b.did,
+		Collection: blueskyPostType,
+		Record:     newBlueskyRecord(p.status, time.Now()),
+	}
+	var resp struct {
+		URI string `json:"uri"`
+	}
+	err := b.call(ctx, "com.atproto.repo.createRecord", b.accessJwt, req, &resp)
+	if errors.Is(err, errSessionExpired) {
+		// An access token only lasts a few hours, which a long-running
+		// process, as with --every, outlives.
+		if err := b.refreshSession(ctx); err != nil {
+			return "", fmt.Errorf("%w: failed to renew the Bluesky session of %q: %w", ErrAuth, b.bc.handle, err)
+		}
+		err = b.call(ctx, "com.atproto.repo.createRecord", b.accessJwt, req, &resp)
+	}
+	if err != nil {
+		return "", err
+	}
+	return resp.URI, nil
+}
+
+// Verify logs in, which is the only way to check an app password.
+func (b *blueskyPoster) Verify(ctx context.Context) error {
+	return b.createSession(ctx)
+}
+
+// createSession logs in with the handle and app password.
+func (b *blueskyPoster) createSession(ctx context.Context) error {
+	req := map[string]string{
+		"identifier": b.bc.handle,
+		"password":   b.bc.appPassword,
+	}
+	var resp blueskySession
+	if err := b.call(ctx, "com.atproto.server.createSession", "", req, &resp); err != nil {
+		return err
+	}
+	b.did, b.accessJwt, b.refreshJwt = resp.DID, resp.AccessJwt, resp.RefreshJwt
+	return nil
+}
+
+// refreshSession renews the session with its refresh token, or, if that
+// has expired too, logs in again.
+func (b *blueskyPoster) refreshSession(ctx context.Context) error {
+	var resp blueskySession
+	if err := b.call(ctx, "com.atproto.server.refreshSession", b.refreshJwt, nil, &resp); err != nil {
+		return b.createSession(ctx)
+	}
+	b.did, b.accessJwt, b.refreshJwt = resp.DID, resp.AccessJwt, resp.RefreshJwt
+	return nil
+}
+
+// blueskySession is the response of createSession and refreshSession.
+type blueskySession struct {
+	DID        string `json:"did"`
+	AccessJwt  string `json:"accessJwt"`
+	RefreshJwt string `json:"refreshJwt"`
+}
+
+// call invokes the XRPC procedure method with the JSON body in, if it's
+// not nil, decoding the response into out. The call is authorized with
+// token, if it's set.
+func (b *blueskyPoster) call(ctx context.Context, method, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.bc.pds+"/xrpc/"+method, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		var xErr struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(msg, &xErr)
+		if token != "" && (resp.StatusCode == http.StatusUnauthorized || xErr.Error == "ExpiredToken") {
+			return fmt.Errorf("%w: %s returned %s: %s", errSessionExpired, method, resp.Status, bytes.TrimSpace(msg))
+		}
+		return fmt.Errorf("%s returned %s: %s", method, resp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakePDS is a Bluesky server that accepts a single account, recording the
+// records created. Its access tokens are "jwt" followed by the number of
+// sessions and refreshes so far; expire makes the current one expire.
+type fakePDS struct {
+	sessions, refreshes int
+	access              string
+	records             []blueskyRecord
+}
+
+func (p *fakePDS) expire() { p.access = "" }
+
+// newAccess issues a new access token, along with the refresh token.
+func (p *fakePDS) newAccess(w http.ResponseWriter) {
+	p.access = fmt.Sprintf("jwt%d", p.sessions+p.refreshes)
+	json.NewEncoder(w).Encode(map[string]string{"did": "did:plc:me", "accessJwt": p.access, "refreshJwt": "refresh"})
+}
+
+func (p *fakePDS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/xrpc/com.atproto.server.createSession":
+		var in map[string]string
+		json.NewDecoder(req.Body).Decode(&in)
+		if in["identifier"] != "me.bsky.social" || in["password"] != "app-password" {
+			http.Error(w, `{"error": "AuthenticationRequired"}`, http.StatusUnauthorized)
+			return
+		}
+		p.sessions++
+		p.newAccess(w)
+	case "/xrpc/com.atproto.server.refreshSession":
+		if req.Header.Get("Authorization") != "Bearer refresh" {
+			http.Error(w, `{"error": "InvalidToken"}`, http.StatusBadRequest)
+			return
+		}
+		p.refreshes++
+		p.newAccess(w)
+	case "/xrpc/com.atproto.repo.createRecord":
+		switch req.Header.Get("Authorization") {
+		case "Bearer " + p.access:
+		case "":
+			http.Error(w, `{"error": "AuthenticationRequired"}`, http.StatusUnauthorized)
+			return
+		default:
+			http.Error(w, `{"error": "ExpiredToken"}`, http.StatusBadRequest)
+			return
+		}
+		var in struct {
+			Repo, Collection string
+			Record           blueskyRecord
+		}
+		json.NewDecoder(req.Body).Decode(&in)
+		if in.Repo != "did:plc:me" || in.Collection != blueskyPostType {
+			http.Error(w, "bad record", http.StatusBadRequest)
+			return
+		}
+		p.records = append(p.records, in.Record)
+		json.NewEncoder(w).Encode(map[string]string{"uri": "at://did:plc:me/app.bsky.feed.post/1"})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestBlueskyPosterPost(t *testing.T) {
+	pds := &fakePDS{}
+	srv := httptest.NewServer(pds)
+	defer srv.Close()
+
+	b := newBlueskyPoster(&blueskyConfig{handle: "me.bsky.social", appPassword: "app-password", pds: srv.URL}, srv.Client())
+	for _, status := range []string{"one", "two"} {
+		id, err := b.Post(context.Background(), &post{status: status})
+		if err != nil {
+			t.Fatalf("Post(%q) = %v", status, err)
+		}
+		if want := "at://did:plc:me/app.bsky.feed.post/1"; id != want {
+			t.Errorf("Post(%q) = %q, want %q", status, id, want)
+		}
+	}
+
+	if pds.sessions != 1 {
+		t.Errorf("logged in %d times, want once", pds.sessions)
+	}
+	if len(pds.records) != 2 || pds.records[0].Text != "one" || pds.records[1].Text != "two" {
+		t.Errorf("records = %+v, want one and two", pds.records)
+	}
+}
+
+func TestBlueskyPosterRefreshesSession(t *testing.T) {
+	pds := &fakePDS{}
+	srv := httptest.NewServer(pds)
+	defer srv.Close()
+
+	b := newBlueskyPoster(&blueskyConfig{handle: "me.bsky.social", appPassword: "app-password", pds: srv.URL}, srv.Client())
+	if _, err := b.Post(context.Background(), &post{status: "one"}); err != nil {
+		t.Fatalf("Post() = %v", err)
+	}
+	pds.expire()
+	if _, err := b.Post(context.Background(), &post{status: "two"}); err != nil {
+		t.Fatalf("Post() after the session expired = %v", err)
+	}
+
+	if pds.sessions != 1 || pds.refreshes != 1 {
+		t.Errorf("logged in %d times and refreshed %d, want once each", pds.sessions, pds.refreshes)
+	}
+	if len(pds.records) != 2 || pds.records[1].Text != "two" {
+		t.Errorf("records = %+v, want one and two", pds.records)
+	}
+}
+
+func TestBlueskyPosterBadPassword(t *testing.T) {
+	srv := httptest.NewServer(&fakePDS{})
+	defer srv.Close()
+
+	b := newBlueskyPoster(&blueskyConfig{handle: "me.bsky.social", appPassword: "wrong", pds: srv.URL}, srv.Client())
+	if _, err := b.Post(context.Background(), &post{status: "hi"}); !errors.Is(err, ErrAuth) {
+		t.Errorf("Post() = %v, want an ErrAuth", err)
+	}
+	if err := b.Verify(context.Background()); err == nil {
+		t.Error("Verify() = nil, want an error")
+	}
+}
+
+func TestNewBlueskyRecord(t *testing.T) {
+	at := time.Date(2024, 6, 3, 14, 5, 0, 0, time.FixedZone("EST", -5*3600))
+	got := newBlueskyRecord("hello", at)
+	want := &blueskyRecord{Type: blueskyPostType, Text: "hello", CreatedAt: "2024-06-03T19:05:00Z"}
+	if *got != *want {
+		t.Errorf("newBlueskyRecord() = %+v, want %+v", got, want)
+	}
+}
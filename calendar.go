@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	calendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// calendarScope is the extra scope needed to add --plan_out's posts to
+// --calendar_id.
+const calendarScope = "https://www.googleapis.com/auth/calendar.events"
+
+// authScopes returns the scopes to authorize, which include calendarScope
+// only with --calendar_id.
+func authScopes(sc *sheetsConfig) []string {
+	sheetsScope := permScope
+	if sc.deviceFlow {
+		sheetsScope = driveFileScope
+	}
+	if sc.calendarID != "" {
+		return []string{sheetsScope, calendarScope}
+	}
+	return []string{sheetsScope}
+}
+
+// planEventID returns the ID of the calendar event for e. It's derived
+// from the entry, so that adding the same plan again finds its events
+// already there. Event IDs may only use the characters a-v and 0-9, which
+// hex digits are among.
+func planEventID(e planEntry) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s", e.Row, e.Status, e.At.UTC().Format(time.RFC3339))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addPlanToCalendar creates an event in --calendar_id for each of the
+// plan's scheduled posts, at the time it's scheduled for, titled with its
+// status. Events created before, for the same posts, are left alone.
+func (r *runner) addPlanToCalendar(ctx context.Context, p *plan) error {
+	added := 0
+	for _, e := range p.Entries {
+		if e.At == nil {
+			log.Printf("warning: row %d: not adding the post to the calendar, as it isn't scheduled", e.Row)
+			continue
+		}
+
+		at := &calendar.EventDateTime{DateTime: e.At.Format(time.RFC3339)}
+		desc := e.Status
+		if len(e.Media) > 0 {
+			desc += "\n\n" + strings.Join(e.Media, "\n")
+		}
+		ev := &calendar.Event{
+			Id:          planEventID(e),
+			Summary:     e.Status,
+			Description: desc + "\n\nRow " + strconv.Itoa(e.Row),
+			Start:       at,
+			End:         at,
+		}
+		_, err := r.calendar.Events.Insert(r.sc.calendarID, ev).Context(ctx).Do()
+		var gErr *googleapi.Error
+		if errors.As(err, &gErr) && gErr.Code == http.StatusConflict {
+			log.Printf("row %d: the post is already in the calendar", e.Row)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: failed to add the post to calendar %q: %w", e.Row, r.sc.calendarID, err)
+		}
+		added++
+	}
+	log.Printf("added %d posts to calendar %q", added, r.sc.calendarID)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestAuthScopes(t *testing.T) {
+	if got, want := authScopes(&sheetsConfig{}), []string{permScope}; !reflect.DeepEqual(got, want) {
+		t.Errorf("authScopes() = %q, want %q", got, want)
+	}
+	if got, want := authScopes(&sheetsConfig{calendarID: "primary"}), []string{permScope, calendarScope}; !reflect.DeepEqual(got, want) {
+		t.Errorf("authScopes() with --calendar_id = %q, want %q", got, want)
+	}
+	if got, want := authScopes(&sheetsConfig{deviceFlow: true}), []string{driveFileScope}; !reflect.DeepEqual(got, want) {
+		t.Errorf("authScopes() with --device_flow = %q, want %q", got, want)
+	}
+}
+
+func TestPlanEventID(t *testing.T) {
+	at := time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)
+	base := planEntry{Row: 2, Status: "hello", At: &at}
+	id := planEventID(base)
+
+	// Google Calendar event IDs may only use a-v and 0-9.
+	if !regexp.MustCompile(`^[a-v0-9]{5,}$`).MatchString(id) {
+		t.Errorf("planEventID() = %q, not a valid event ID", id)
+	}
+
+	sameInstant := at.In(time.FixedZone("EST", -5*3600))
+	later := at.Add(time.Minute)
+	for _, tc := range []struct {
+		name     string
+		e        planEntry
+		wantSame bool
+	}{
+		{name: "same entry", e: planEntry{Row: 2, Status: "hello", At: &at}, wantSame: true},
+		{name: "same instant in another zone", e: planEntry{Row: 2, Status: "hello", At: &sameInstant}, wantSame: true},
+		{name: "media ignored", e: planEntry{Row: 2, Status: "hello", Media: []string{"a.png"}, At: &at}, wantSame: true},
+		{name: "other row", e: planEntry{Row: 3, Status: "hello", At: &at}},
+		{name: "other status", e: planEntry{Row: 2, Status: "hi", At: &at}},
+		{name: "other time", e: planEntry{Row: 2, Status: "hello", At: &later}},
+	} {
+		if got := planEventID(tc.e) == id; got != tc.wantSame {
+			t.Errorf("%s: same ID = %t, want %t", tc.name, got, tc.wantSame)
+		}
+	}
+}
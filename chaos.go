@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// errSimulated is the error of a post failed by a chaosPoster. Like a real
+// transient failure, it's retried, but every attempt of a failed post
+// fails, so that it's then given up on.
+var errSimulated = &simulatedError{}
+
+type simulatedError struct{}
+
+func (*simulatedError) Error() string   { return "simulated failure" }
+func (*simulatedError) Timeout() bool   { return true }
+func (*simulatedError) Temporary() bool { return true }
+
+// chaosPoster wraps a Poster, failing a fraction rate of posts, at random,
+// without calling it. It's for testing how runs handle failures, with
+// --simulate_failure_rate, so it forwards the optional interfaces of the
+// wrapped Poster, which are then used as they would be without it.
+type chaosPoster struct {
+	Poster
+	rate float64
+	rng  *rand.Rand
+}
+
+func newChaosPoster(p Poster, rate float64, seed int64) *chaosPoster {
+	return &chaosPoster{Poster: p, rate: rate, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (c *chaosPoster) wrapped() []Poster {
+	return []Poster{c.Poster}
+}
+
+// Post decides once whether the post fails, rather than for each attempt,
+// so that retries don't lower the rate of failed posts below rate.
+func (c *chaosPoster) Post(ctx context.Context, p *post) (string, error) {
+	fail := c.rng.Float64() < c.rate
+	var id string
+	err := retry(func(err error) bool { return errors.Is(err, errSimulated) }, func() error {
+		if fail {
+			return errSimulated
+		}
+		var err error
+		id, err = c.Poster.Post(ctx, p)
+		return err
+	})
+	return id, err
+}
+
+// UploadMedia forwards to the wrapped Poster, so that wrapping it doesn't
+// hide its support for media.
+func (c *chaosPoster) UploadMedia(ctx context.Context, data []byte) (string, error) {
+	u, ok := c.Poster.(mediaUploader)
+	if !ok {
+		return "", errors.New("the backend does not support media")
+	}
+	return u.UploadMedia(ctx, data)
+}
+
+// UploadVideo forwards to the wrapped Poster, uploading the video as media
+// if it doesn't upload videos differently.
+func (c *chaosPoster) UploadVideo(ctx context.Context, data []byte, mimeType string) (string, error) {
+	if v, ok := c.Poster.(videoUploader); ok {
+		return v.UploadVideo(ctx, data, mimeType)
+	}
+	return c.UploadMedia(ctx, data)
+}
+
+func (c *chaosPoster) Repost(ctx context.Context, id string) (string, error) {
+	rp, ok := c.Poster.(reposter)
+	if !ok {
+		return "", errors.New("the backend can't repost")
+	}
+	return rp.Repost(ctx, id)
+}
+
+func (c *chaosPoster) LatestPostID(ctx context.Context, screenName string) (string, error) {
+	tr, ok := c.Poster.(timelineReader)
+	if !ok {
+		return "", errors.New("the backend can't look up an account's posts")
+	}
+	return tr.LatestPostID(ctx, screenName)
+}
+
+func (c *chaosPoster) RateLimit() (int, time.Time, bool) {
+	if rl, ok := c.Poster.(rateLimitReporter); ok {
+		return rl.RateLimit()
+	}
+	return 0, time.Time{}, false
+}
+
+func (c *chaosPoster) Verify(ctx context.Context) error {
+	v, ok := c.Poster.(verifier)
+	if !ok {
+		return errors.New("the backend can't check its credentials")
+	}
+	return v.Verify(ctx)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChaosPosterPost(t *testing.T) {
+	fastRetries(t)
+	for _, tc := range []struct {
+		name      string
+		rate      float64
+		wantPosts int
+		wantErr   bool
+	}{
+		{name: "never fails", rate: 0, wantPosts: 1},
+		{name: "always fails", rate: 1, wantPosts: 0, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &fakePoster{}
+			_, err := newChaosPoster(p, tc.rate, 1).Post(context.Background(), &post{status: "hi"})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Post() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err != nil && !errors.Is(err, errSimulated) {
+				t.Errorf("Post() = %v, want %v", err, errSimulated)
+			}
+			if len(p.posts) != tc.wantPosts {
+				t.Errorf("posted %d times, want %d", len(p.posts), tc.wantPosts)
+			}
+		})
+	}
+}
+
+// The rate is that of failed posts, however many times each is retried.
+func TestChaosPosterFailureRate(t *testing.T) {
+	fastRetries(t)
+	p := &fakePoster{}
+	c := newChaosPoster(p, 0.2, 1)
+	failed := 0
+	for i := 0; i < 500; i++ {
+		if _, err := c.Post(context.Background(), &post{status: "hi"}); err != nil {
+			failed++
+		}
+	}
+	if failed < 75 || failed > 125 {
+		t.Errorf("%d of 500 posts failed, want about 100", failed)
+	}
+	if len(p.posts) != 500-failed {
+		t.Errorf("posted %d times, want %d", len(p.posts), 500-failed)
+	}
+}
+
+func TestChaosPosterForwards(t *testing.T) {
+	ctx := context.Background()
+	p := &fullPoster{name: "inner", remaining: 7}
+	c := newChaosPoster(p, 0, 1)
+
+	if _, ok := optional[mediaUploader](c); !ok {
+		t.Error("doesn't support media")
+	}
+	if _, ok := optional[videoUploader](c); !ok {
+		t.Error("doesn't support videos")
+	}
+	if _, ok := optional[reposter](c); !ok {
+		t.Error("can't repost")
+	}
+	if _, ok := optional[timelineReader](c); !ok {
+		t.Error("can't read timelines")
+	}
+	if _, ok := optional[rateLimitReporter](c); !ok {
+		t.Error("doesn't report its rate limit")
+	}
+	if _, ok := optional[verifier](c); !ok {
+		t.Error("can't verify")
+	}
+
+	c.UploadMedia(ctx, nil)
+	c.UploadVideo(ctx, nil, "video/mp4")
+	c.Repost(ctx, "1")
+	c.LatestPostID(ctx, "me")
+	c.Verify(ctx)
+	if want := []string{"media", "video", "repost 1", "latest me", "verify"}; !reflect.DeepEqual(p.calls, want) {
+		t.Errorf("forwarded %q, want %q", p.calls, want)
+	}
+	if n, _, ok := c.RateLimit(); !ok || n != 7 {
+		t.Errorf("RateLimit() = %d, %t, want 7, true", n, ok)
+	}
+}
+
+func TestChaosPosterWithoutOptionalInterfaces(t *testing.T) {
+	ctx := context.Background()
+	c := newChaosPoster(&fakePoster{}, 0, 1)
+	if _, ok := optional[mediaUploader](c); ok {
+		t.Error("supports media, wrapping a Poster that doesn't")
+	}
+	if _, ok := optional[timelineReader](c); ok {
+		t.Error("can read timelines, wrapping a Poster that can't")
+	}
+	if _, ok := optional[verifier](c); ok {
+		t.Error("can verify, wrapping a Poster that can't")
+	}
+	if _, err := c.UploadMedia(ctx, nil); err == nil {
+		t.Error("UploadMedia() succeeded for a Poster without media")
+	}
+	if _, err := c.Repost(ctx, "1"); err == nil {
+		t.Error("Repost() succeeded for a Poster that can't repost")
+	}
+	if _, _, ok := c.RateLimit(); ok {
+		t.Error("RateLimit() is known for a Poster that doesn't report it")
+	}
+}
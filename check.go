@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// credentialCheck checks one set of credentials, named what.
+type credentialCheck struct {
+	what  string
+	check func() error
+}
+
+// checkCredentials checks that the Sheets credentials can read the
+// spreadsheet's metadata and that the backend accepts its credentials,
+// printing OK or FAIL for each.
+func checkCredentials(ctx context.Context, sc *sheetsConfig, bc *backendConfig) error {
+	return runChecks(os.Stdout, []credentialCheck{
+		{what: "Sheets", check: func() error {
+			srv, err := newSheetsService(ctx, sc)
+			if err != nil {
+				return err
+			}
+			return checkSheets(ctx, srv, sc.id)
+		}},
+		{what: bc.name, check: func() error {
+			poster, err := newPoster(bc)
+			if err != nil {
+				return err
+			}
+			return verifyPoster(ctx, poster)
+		}},
+	})
+}
+
+// runChecks runs each of checks, printing OK or FAIL for it to w. It
+// returns an error wrapping ErrAuth if any failed.
+func runChecks(w io.Writer, checks []credentialCheck) error {
+	ok := true
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			ok = false
+			fmt.Fprintf(w, "%s: FAIL (%v)\n", c.what, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s: OK\n", c.what)
+	}
+	if !ok {
+		return fmt.Errorf("%w: credential check failed", ErrAuth)
+	}
+	return nil
+}
+
+// checkSheets checks that srv can read the metadata of the spreadsheet id.
+func checkSheets(ctx context.Context, srv *sheets.Service, id string) error {
+	_, err := srv.Spreadsheets.Get(id).Fields("properties.title").Context(ctx).Do()
+	return err
+}
+
+// verifyPoster checks that the backend accepts poster's credentials,
+// without posting.
+func verifyPoster(ctx context.Context, poster Poster) error {
+	v, ok := optional[verifier](poster)
+	if !ok {
+		return errors.New("the backend can't check its credentials")
+	}
+	return v.Verify(ctx)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckCredentials(t *testing.T) {
+	ctx := context.Background()
+	_, srv := newFakeSheet(t)
+	twitter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, `{"errors": [{"code": 89, "message": "Invalid or expired token."}]}`, http.StatusUnauthorized)
+	}))
+	defer twitter.Close()
+
+	for _, tc := range []struct {
+		name     string
+		poster   Poster
+		sheetID  string
+		wantErr  error
+		wantLogs []string
+	}{
+		{
+			name:     "both verify",
+			poster:   &fullPoster{},
+			sheetID:  "sheet-id",
+			wantLogs: []string{"Sheets: OK", "twitter: OK"},
+		},
+		{
+			name:     "Twitter fails",
+			poster:   newTwitterPoster(&twitterConfig{apiBase: twitter.URL}),
+			sheetID:  "sheet-id",
+			wantErr:  ErrAuth,
+			wantLogs: []string{"Sheets: OK", "twitter: FAIL"},
+		},
+		{
+			name:     "Sheets fails",
+			poster:   &fullPoster{},
+			sheetID:  "other-id",
+			wantErr:  ErrAuth,
+			wantLogs: []string{"Sheets: FAIL", "twitter: OK"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			err := runChecks(&b, []credentialCheck{
+				{what: "Sheets", check: func() error { return checkSheets(ctx, srv, tc.sheetID) }},
+				{what: backendTwitter, check: func() error { return verifyPoster(ctx, tc.poster) }},
+			})
+			if !errors.Is(err, tc.wantErr) || (err != nil) != (tc.wantErr != nil) {
+				t.Errorf("runChecks() = %v, want %v", err, tc.wantErr)
+			}
+			for _, s := range tc.wantLogs {
+				if !strings.Contains(b.String(), s) {
+					t.Errorf("printed %q, want %q", b.String(), s)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyPosterWithoutVerifier(t *testing.T) {
+	if err := verifyPoster(context.Background(), &fakePoster{}); err == nil {
+		t.Error("verifyPoster() = nil for a Poster that can't verify")
+	}
+}
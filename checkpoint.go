@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpoint records progress through an append-only sheet across runs.
+type checkpoint struct {
+	// LastRow is the number of the last row processed.
+	LastRow int `json:"last_row"`
+	// Skipped holds the numbers of the rows up to LastRow that were passed
+	// over rather than posted, such as those denied by moderation, which
+	// the next run tries again.
+	Skipped []int `json:"skipped,omitempty"`
+	// LastRun is when the last successful run started, for
+	// --modified_column.
+	LastRun time.Time `json:"last_run,omitempty"`
+	// LastDay is the date, as YYYY-MM-DD, of the last successful run, for
+	// --daily.
+	LastDay string `json:"last_day,omitempty"`
+	// BudgetDay is the date, as YYYY-MM-DD, that BudgetUsed is for.
+	BudgetDay string `json:"budget_day,omitempty"`
+	// BudgetUsed is how many characters of --daily_char_budget were posted
+	// on BudgetDay.
+	BudgetUsed int `json:"budget_used,omitempty"`
+}
+
+// dayFormat is the layout of checkpoint.LastDay.
+const dayFormat = "2006-01-02"
+
+// loadCheckpoint reads the checkpoint at path. A missing file is an empty
+// checkpoint, as is a corrupt one, with a warning.
+func loadCheckpoint(path string) *checkpoint {
+	cp := &checkpoint{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp
+	}
+	if err != nil {
+		log.Printf("warning: failed to read checkpoint %q, starting from the beginning: %v", path, err)
+		return cp
+	}
+
+	if err := json.Unmarshal(data, cp); err != nil {
+		log.Printf("warning: corrupt checkpoint %q, starting from the beginning: %v", path, err)
+		return &checkpoint{}
+	}
+	return cp
+}
+
+// saveCheckpoint writes cp to path atomically.
+func saveCheckpoint(path string, cp *checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to path, replacing the file atomically so that
+// a crash can't leave it corrupt.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// pending returns the rows numbered after the checkpoint's, and those it
+// passed over.
+func (cp *checkpoint) pending(rows []row) []row {
+	skipped := make(map[int]bool)
+	for _, num := range cp.Skipped {
+		skipped[num] = true
+	}
+	var after []row
+	for _, r := range rows {
+		if r.num > cp.LastRow || skipped[r.num] {
+			after = append(after, r)
+		}
+	}
+	return after
+}
+
+// advance moves the checkpoint over the leading rows that were processed
+// or passed over, stopping at the first that was neither. The rows passed
+// over are recorded as skipped, along with those skipped before that the
+// checkpoint didn't reach again.
+func (cp *checkpoint) advance(rows []row, processed []row, passedOver []int) {
+	done := make(map[int]bool)
+	for _, r := range processed {
+		done[r.num] = true
+	}
+	over := make(map[int]bool)
+	for _, num := range passedOver {
+		over[num] = true
+	}
+
+	var skipped []int
+	i := 0
+	for ; i < len(rows); i++ {
+		num := rows[i].num
+		if over[num] {
+			skipped = append(skipped, num)
+		} else if !done[num] {
+			break
+		}
+		if num > cp.LastRow {
+			cp.LastRow = num
+		}
+	}
+	for _, r := range rows[i:] {
+		if r.num <= cp.LastRow && !done[r.num] {
+			skipped = append(skipped, r.num)
+		}
+	}
+	cp.Skipped = skipped
+}
+
+// charBudget is what's left of --daily_char_budget for the current day.
+type charBudget struct {
+	limit, used int
+}
+
+// budgetFor returns the budget of the day today, in dayFormat, carrying on
+// from what the checkpoint recorded if it's for the same day.
+func (cp *checkpoint) budgetFor(today string, limit int) *charBudget {
+	b := &charBudget{limit: limit}
+	if cp.BudgetDay == today {
+		b.used = cp.BudgetUsed
+	}
+	return b
+}
+
+// fits reports whether n more characters can be posted today.
+func (b *charBudget) fits(n int) bool {
+	return b.used+n <= b.limit
+}
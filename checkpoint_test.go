@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCharBudget(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cp       checkpoint
+		today    string
+		limit    int
+		n        int
+		wantUsed int
+		wantFits bool
+	}{
+		{name: "fresh day", today: "2024-06-03", limit: 100, n: 100, wantFits: true},
+		{name: "over the limit", today: "2024-06-03", limit: 100, n: 101, wantFits: false},
+		{
+			name:  "same day carries on",
+			cp:    checkpoint{BudgetDay: "2024-06-03", BudgetUsed: 60},
+			today: "2024-06-03", limit: 100, n: 41,
+			wantUsed: 60, wantFits: false,
+		},
+		{
+			name:  "same day with room",
+			cp:    checkpoint{BudgetDay: "2024-06-03", BudgetUsed: 60},
+			today: "2024-06-03", limit: 100, n: 40,
+			wantUsed: 60, wantFits: true,
+		},
+		{
+			name:  "new day starts over",
+			cp:    checkpoint{BudgetDay: "2024-06-02", BudgetUsed: 100},
+			today: "2024-06-03", limit: 100, n: 100,
+			wantUsed: 0, wantFits: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := tc.cp.budgetFor(tc.today, tc.limit)
+			if b.used != tc.wantUsed {
+				t.Errorf("used = %d, want %d", b.used, tc.wantUsed)
+			}
+			if got := b.fits(tc.n); got != tc.wantFits {
+				t.Errorf("fits(%d) = %t, want %t", tc.n, got, tc.wantFits)
+			}
+		})
+	}
+}
+
+func TestLoadCheckpointCorrupt(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := ioutil.WriteFile(path, []byte(`{"last_row": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cp := loadCheckpoint(path); !reflect.DeepEqual(cp, &checkpoint{}) {
+		t.Errorf("loadCheckpoint() = %+v, want an empty checkpoint", cp)
+	}
+	if !strings.Contains(logs.String(), "corrupt checkpoint") {
+		t.Errorf("logged %q, want a warning about the corrupt checkpoint", logs.String())
+	}
+}
+
+func TestCheckpointAdvance(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cp   checkpoint
+		// rows are the numbers of the rows of the run, which are those
+		// after the checkpoint's and those it skipped.
+		rows        []int
+		processed   []int
+		passedOver  []int
+		wantLast    int
+		wantSkipped []int
+	}{
+		{name: "all processed", rows: []int{2, 3, 4}, processed: []int{2, 3, 4}, wantLast: 4},
+		{name: "stops at the first unprocessed", rows: []int{2, 3, 4}, processed: []int{2, 4}, wantLast: 2},
+		{name: "passes over skipped rows", rows: []int{2, 3, 4}, processed: []int{2, 4}, passedOver: []int{3}, wantLast: 4, wantSkipped: []int{3}},
+		{
+			name:      "drops skips that were processed",
+			cp:        checkpoint{LastRow: 4, Skipped: []int{3}},
+			rows:      []int{3, 5},
+			processed: []int{3, 5},
+			wantLast:  5,
+		},
+		{
+			name:        "keeps skips it doesn't reach",
+			cp:          checkpoint{LastRow: 4, Skipped: []int{2, 3}},
+			rows:        []int{2, 3, 5},
+			processed:   []int{2},
+			wantLast:    4,
+			wantSkipped: []int{3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var rows, processed []row
+			for _, num := range tc.rows {
+				rows = append(rows, row{num: num})
+			}
+			for _, num := range tc.processed {
+				processed = append(processed, row{num: num})
+			}
+			cp := tc.cp
+			cp.advance(rows, processed, tc.passedOver)
+			if cp.LastRow != tc.wantLast || !reflect.DeepEqual(cp.Skipped, tc.wantSkipped) {
+				t.Errorf("advance() = row %d, skipped %v, want row %d, skipped %v", cp.LastRow, cp.Skipped, tc.wantLast, tc.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestRunAdvancesCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	rc := testRunConfig()
+	rc.checkpointFile = path
+	p := &fakePoster{}
+	r := newTestRunner(p, rc)
+	r.source = staticSource{{"one"}, {"two"}}
+
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("run() = %v", err)
+	}
+	if cp := loadCheckpoint(path); cp.LastRow != 2 {
+		t.Errorf("checkpoint's row = %d after a successful run, want 2", cp.LastRow)
+	}
+
+	r.source = staticSource{{"one"}, {"two"}, {"three"}}
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("second run() = %v", err)
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(p.statuses(), want) {
+		t.Errorf("posted %q, want %q", p.statuses(), want)
+	}
+}
+
+// A row denied by moderation doesn't move the checkpoint past it for good,
+// so it's tried again on the next run.
+func TestRunCheckpointRetriesPassedOver(t *testing.T) {
+	deny := "two"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		json.NewDecoder(req.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]bool{"allow": body["status"] != deny})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	rc := testRunConfig()
+	rc.checkpointFile = path
+	rc.moderationURL = srv.URL
+	p := &fakePoster{}
+	r := newTestRunner(p, rc)
+	r.source = staticSource{{"one"}, {"two"}, {"three"}}
+
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("run() = %v", err)
+	}
+	if cp := loadCheckpoint(path); cp.LastRow != 3 || !reflect.DeepEqual(cp.Skipped, []int{2}) {
+		t.Errorf("checkpoint = row %d, skipped %v, want row 3, skipped [2]", cp.LastRow, cp.Skipped)
+	}
+
+	deny = ""
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("second run() = %v", err)
+	}
+	if want := []string{"one", "three", "two"}; !reflect.DeepEqual(p.statuses(), want) {
+		t.Errorf("posted %q, want %q", p.statuses(), want)
+	}
+	if cp := loadCheckpoint(path); cp.LastRow != 3 || len(cp.Skipped) > 0 {
+		t.Errorf("checkpoint = row %d, skipped %v, want row 3 and none skipped", cp.LastRow, cp.Skipped)
+	}
+}
+
+// --daily posts once per calendar day in --timezone, whatever the day is
+// in UTC.
+func TestRunDaily(t *testing.T) {
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	rc := testRunConfig()
+	rc.checkpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+	rc.daily = true
+	rc.location = nyc
+	p := &fakePoster{}
+	r := newTestRunner(p, rc)
+
+	// The sheet gains a row before each run after the first.
+	source := staticSource{{"one"}, {"two"}, {"three"}, {"four"}, {"five"}, {"six"}}
+	for i, tc := range []struct {
+		now  time.Time
+		want []string
+	}{
+		{now: time.Date(2024, 6, 10, 9, 0, 0, 0, nyc), want: []string{"one"}},
+		{now: time.Date(2024, 6, 10, 21, 0, 0, 0, nyc), want: []string{"one"}},
+		// The next day in UTC, but not in New York.
+		{now: time.Date(2024, 6, 11, 3, 59, 0, 0, time.UTC), want: []string{"one"}},
+		{now: time.Date(2024, 6, 11, 0, 1, 0, 0, nyc), want: []string{"one", "two", "three", "four"}},
+		{now: time.Date(2024, 6, 11, 23, 59, 0, 0, nyc), want: []string{"one", "two", "three", "four"}},
+		{now: time.Date(2024, 6, 12, 0, 0, 0, 0, nyc), want: []string{"one", "two", "three", "four", "five", "six"}},
+	} {
+		r.source = source[:i+1]
+		r.now = func() time.Time { return tc.now }
+		if err := r.run(context.Background()); err != nil {
+			t.Fatalf("run() at %v = %v", tc.now, err)
+		}
+		if !reflect.DeepEqual(p.statuses(), tc.want) {
+			t.Errorf("after a run at %v, posted %q, want %q", tc.now, p.statuses(), tc.want)
+		}
+	}
+}
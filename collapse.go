@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// collapsed is a run of consecutive identical statuses.
+type collapsed struct {
+	status string
+	// first is the index of the run's first status, and count how many
+	// statuses it has.
+	first, count int
+}
+
+// collapseRuns groups consecutive identical statuses into runs.
+func collapseRuns(statuses []string) []collapsed {
+	var runs []collapsed
+	for i, s := range statuses {
+		if n := len(runs); n > 0 && runs[n-1].status == s {
+			runs[n-1].count++
+			continue
+		}
+		runs = append(runs, collapsed{status: s, first: i, count: 1})
+	}
+	return runs
+}
+
+// collapseDuplicates replaces each run of consecutive rows with identical
+// statuses by its first row, whose status gets a "(xN)" suffix with the
+// run's length. It returns those rows, and the rest of each run by the
+// number of its first row, to be marked complete along with it.
+func (r *runner) collapseDuplicates(rows []row) ([]row, map[int][]row) {
+	statuses := make([]string, len(rows))
+	for i, rw := range rows {
+		status, err := renderStatus(rw, r.rc)
+		if rw.edited != "" {
+			status, err = rw.edited, nil
+		}
+		if err != nil || strings.TrimSpace(status) == "" {
+			// A row that fails to render, or renders empty, is left
+			// alone, to be handled when it's tweeted.
+			status = fmt.Sprintf("\x00row %d", rw.num)
+		}
+		statuses[i] = status
+	}
+
+	var kept []row
+	rest := make(map[int][]row)
+	for _, run := range collapseRuns(statuses) {
+		first := rows[run.first]
+		if run.count > 1 {
+			log.Printf("row %d: collapsing %d identical rows into one post", first.num, run.count)
+			first.edited = fmt.Sprintf("%s (x%d)", run.status, run.count)
+			rest[first.num] = rows[run.first+1 : run.first+run.count]
+		}
+		kept = append(kept, first)
+	}
+	return kept, rest
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollapseRuns(t *testing.T) {
+	for _, tc := range []struct {
+		in   []string
+		want []collapsed
+	}{
+		{in: nil, want: nil},
+		{in: []string{"a"}, want: []collapsed{{status: "a", first: 0, count: 1}}},
+		{in: []string{"a", "a", "b", "a"}, want: []collapsed{
+			{status: "a", first: 0, count: 2},
+			{status: "b", first: 2, count: 1},
+			{status: "a", first: 3, count: 1},
+		}},
+		{in: []string{"x", "x", "x"}, want: []collapsed{{status: "x", first: 0, count: 3}}},
+	} {
+		if got := collapseRuns(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("collapseRuns(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCollapseDuplicates(t *testing.T) {
+	r := newTestRunner(&fakePoster{}, testRunConfig())
+	rows := testRows("same", "same", "other", "", "", "same")
+	rows[2].edited = "same"
+
+	kept, rest := r.collapseDuplicates(rows)
+	if got, want := rowNums(kept), []int{2, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("kept rows = %v, want %v", got, want)
+	}
+	if got, want := kept[0].edited, "same (x3)"; got != want {
+		t.Errorf("collapsed status = %q, want %q", got, want)
+	}
+	if got, want := rowNums(rest[2]), []int{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows collapsed into row 2 = %v, want %v", got, want)
+	}
+	// Empty rows are left alone, to be handled when they're tweeted.
+	if kept[1].edited != "" || kept[2].edited != "" {
+		t.Errorf("empty rows were collapsed: %q, %q", kept[1].edited, kept[2].edited)
+	}
+	if len(rest) != 1 {
+		t.Errorf("rest = %v, want only row 2's", rest)
+	}
+}
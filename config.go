@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// configKeyEnv is the environment variable that may hold the key of an
+// encrypted config file, instead of --config_key_file.
+const configKeyEnv = "HITLIST_CONFIG_KEY"
+
+// nonceSize is the size of the nonce that precedes an encrypted config.
+const nonceSize = 24
+
+// errWrongKey is returned for an encrypted config that can't be opened.
+var errWrongKey = errors.New("wrong key, or the config is corrupt")
+
+// readConfig reads the config file at path, decrypting it with key unless
+// key is empty.
+func readConfig(path, key string) ([]byte, error) {
+	if key == "" {
+		return ioutil.ReadFile(path)
+	}
+	return decryptConfig(path, key)
+}
+
+// decryptConfig reads and decrypts the config file at path, which holds a
+// NaCl secretbox preceded by its nonce. The key is 32 bytes, encoded as hex
+// or base64.
+func decryptConfig(path, key string) ([]byte, error) {
+	k, err := parseConfigKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < nonceSize+secretbox.Overhead {
+		return nil, errWrongKey
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], data)
+	plain, ok := secretbox.Open(nil, data[nonceSize:], &nonce, k)
+	if !ok {
+		return nil, errWrongKey
+	}
+	return plain, nil
+}
+
+// parseConfigKey decodes a 32-byte key from hex or base64.
+func parseConfigKey(key string) (*[32]byte, error) {
+	key = strings.TrimSpace(key)
+	b, err := hex.DecodeString(key)
+	if err != nil {
+		b, err = base64.StdEncoding.DecodeString(key)
+	}
+	if err != nil || len(b) != 32 {
+		return nil, errors.New("the key must be 32 bytes, encoded as hex or base64")
+	}
+
+	var k [32]byte
+	copy(k[:], b)
+	return &k, nil
+}
+
+// applyConfig sets flags from data, a JSON object mapping flag names to
+// their values. Flags set on the command line take precedence.
+func applyConfig(fs *flag.FlagSet, data []byte) error {
+	// Numbers are kept as written, so that large ints aren't formatted
+	// as floats.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values map[string]interface{}
+	if err := dec.Decode(&values); err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for name, v := range values {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag %q", name)
+		}
+		if set[name] {
+			continue
+		}
+		if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("bad value for %q: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+var testConfigKey = [32]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32}
+
+func TestParseConfigKey(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{name: "hex", in: hex.EncodeToString(testConfigKey[:])},
+		{name: "base64", in: base64.StdEncoding.EncodeToString(testConfigKey[:])},
+		{name: "surrounding space", in: " " + hex.EncodeToString(testConfigKey[:]) + "\n"},
+		{name: "short", in: hex.EncodeToString(testConfigKey[:16]), wantErr: true},
+		{name: "garbage", in: "not a key", wantErr: true},
+		{name: "empty", in: "", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := parseConfigKey(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseConfigKey() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err == nil && *k != testConfigKey {
+				t.Errorf("parseConfigKey() = %x, want %x", *k, testConfigKey)
+			}
+		})
+	}
+}
+
+func TestDecryptConfig(t *testing.T) {
+	dir := t.TempDir()
+	key := hex.EncodeToString(testConfigKey[:])
+	plain := []byte(`{"source": "a:Sheet1!A2:E"}`)
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], "a nonce of twenty-four b")
+	sealed := secretbox.Seal(nonce[:], plain, &nonce, &testConfigKey)
+	path := filepath.Join(dir, "config.enc")
+	if err := ioutil.WriteFile(path, sealed, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readConfig(path, key)
+	if err != nil {
+		t.Fatalf("readConfig() = %v", err)
+	}
+	if string(got) != string(plain) {
+		t.Errorf("readConfig() = %q, want %q", got, plain)
+	}
+
+	wrong := strings.Repeat("ff", 32)
+	if _, err := readConfig(path, wrong); err != errWrongKey {
+		t.Errorf("readConfig() with the wrong key = %v, want %v", err, errWrongKey)
+	}
+}
+
+func TestDecryptConfigShort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.enc")
+	if err := ioutil.WriteFile(path, []byte("too short"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptConfig(path, hex.EncodeToString(testConfigKey[:])); err != errWrongKey {
+		t.Errorf("decryptConfig() = %v, want %v", err, errWrongKey)
+	}
+}
+
+func TestReadConfigPlain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readConfig(path, "")
+	if err != nil || string(got) != "{}" {
+		t.Errorf("readConfig() = %q, %v, want %q", got, err, "{}")
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		config    string
+		wantName  string
+		wantCount int64
+		wantErr   bool
+	}{
+		{name: "sets flags", config: `{"name": "Queue", "count": 3}`, wantName: "Queue", wantCount: 3},
+		{name: "command line wins", args: []string{"-name", "Other"}, config: `{"name": "Queue"}`, wantName: "Other", wantCount: 1},
+		{name: "large int", config: `{"count": 12345678901234}`, wantName: "Sheet1", wantCount: 12345678901234},
+		{name: "unknown flag", config: `{"nope": 1}`, wantErr: true},
+		{name: "bad value", config: `{"count": "many"}`, wantErr: true},
+		{name: "not an object", config: `[1]`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			name := fs.String("name", "Sheet1", "")
+			count := fs.Int64("count", 1, "")
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyConfig(fs, []byte(tc.config))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("applyConfig() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err == nil && (*name != tc.wantName || *count != tc.wantCount) {
+				t.Errorf("flags = %q, %d, want %q, %d", *name, *count, tc.wantName, tc.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	keyring "github.com/zalando/go-keyring"
+)
+
+// merge fills in the empty fields of tc from other.
+func (tc *twitterConfig) merge(other *twitterConfig) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&tc.consumerKey, other.consumerKey)
+	fill(&tc.consumerSecret, other.consumerSecret)
+	fill(&tc.accessToken, other.accessToken)
+	fill(&tc.accessSecret, other.accessSecret)
+}
+
+// twitterConfigFromEnv reads the Twitter credentials from the environment.
+func twitterConfigFromEnv() *twitterConfig {
+	return &twitterConfig{
+		consumerKey:    os.Getenv("TWITTER_CONSUMER_KEY"),
+		consumerSecret: os.Getenv("TWITTER_CONSUMER_SECRET"),
+		accessToken:    os.Getenv("TWITTER_ACCESS_TOKEN"),
+		accessSecret:   os.Getenv("TWITTER_ACCESS_SECRET"),
+	}
+}
+
+// loadTwitterSecretFile reads all four Twitter credentials from the JSON
+// file at path, whose keys are "consumer_key", "consumer_secret",
+// "access_token" and "access_secret". It's an error for any to be missing.
+func loadTwitterSecretFile(path string) (*twitterConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f struct {
+		ConsumerKey    string `json:"consumer_key"`
+		ConsumerSecret string `json:"consumer_secret"`
+		AccessToken    string `json:"access_token"`
+		AccessSecret   string `json:"access_secret"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for key, v := range map[string]string{
+		"consumer_key":    f.ConsumerKey,
+		"consumer_secret": f.ConsumerSecret,
+		"access_token":    f.AccessToken,
+		"access_secret":   f.AccessSecret,
+	} {
+		if v == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, errors.New("missing " + strings.Join(missing, ", "))
+	}
+
+	return &twitterConfig{
+		consumerKey:    f.ConsumerKey,
+		consumerSecret: f.ConsumerSecret,
+		accessToken:    f.AccessToken,
+		accessSecret:   f.AccessSecret,
+	}, nil
+}
+
+// twitterConfigFromKeyring reads the Twitter credentials from the system
+// keyring, where each is stored under service with its flag's name as the
+// user. Missing entries are left empty.
+func twitterConfigFromKeyring(service string) (*twitterConfig, error) {
+	tc := &twitterConfig{}
+	for user, dst := range map[string]*string{
+		"twitter_consumer_key":    &tc.consumerKey,
+		"twitter_consumer_secret": &tc.consumerSecret,
+		"twitter_access_token":    &tc.accessToken,
+		"twitter_access_secret":   &tc.accessSecret,
+	} {
+		v, err := keyring.Get(service, user)
+		if err == keyring.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		*dst = v
+	}
+	return tc, nil
+}
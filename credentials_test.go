@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	keyring "github.com/zalando/go-keyring"
+)
+
+func TestTwitterConfigMerge(t *testing.T) {
+	tc := &twitterConfig{consumerKey: "flag-key", accessSecret: "flag-secret"}
+	tc.merge(&twitterConfig{consumerKey: "env-key", consumerSecret: "env-consumer-secret", accessToken: "env-token"})
+
+	want := twitterConfig{consumerKey: "flag-key", consumerSecret: "env-consumer-secret", accessToken: "env-token", accessSecret: "flag-secret"}
+	if *tc != want {
+		t.Errorf("merge() = %+v, want %+v", *tc, want)
+	}
+}
+
+func TestTwitterConfigFromEnv(t *testing.T) {
+	t.Setenv("TWITTER_CONSUMER_KEY", "ck")
+	t.Setenv("TWITTER_CONSUMER_SECRET", "cs")
+	t.Setenv("TWITTER_ACCESS_TOKEN", "at")
+	t.Setenv("TWITTER_ACCESS_SECRET", "")
+
+	want := twitterConfig{consumerKey: "ck", consumerSecret: "cs", accessToken: "at"}
+	if got := twitterConfigFromEnv(); *got != want {
+		t.Errorf("twitterConfigFromEnv() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestLoadTwitterSecretFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		want    twitterConfig
+		wantErr string
+	}{
+		{
+			name: "complete",
+			data: `{"consumer_key": "ck", "consumer_secret": "cs", "access_token": "at", "access_secret": "as"}`,
+			want: twitterConfig{consumerKey: "ck", consumerSecret: "cs", accessToken: "at", accessSecret: "as"},
+		},
+		{
+			name:    "missing",
+			data:    `{"consumer_key": "ck", "access_token": "at"}`,
+			wantErr: "missing access_secret, consumer_secret",
+		},
+		{name: "not JSON", data: `consumer_key=ck`, wantErr: "invalid"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secrets.json")
+			if err := ioutil.WriteFile(path, []byte(tc.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadTwitterSecretFile(path)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("loadTwitterSecretFile() = %v, want an error mentioning %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadTwitterSecretFile() = %v", err)
+			}
+			if *got != tc.want {
+				t.Errorf("loadTwitterSecretFile() = %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTwitterConfigFromKeyring(t *testing.T) {
+	keyring.MockInit()
+	for user, v := range map[string]string{
+		"twitter_consumer_key":  "ck",
+		"twitter_access_secret": "as",
+	} {
+		if err := keyring.Set("hitlist-test", user, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := twitterConfigFromKeyring("hitlist-test")
+	if err != nil {
+		t.Fatalf("twitterConfigFromKeyring() = %v", err)
+	}
+	if want := (twitterConfig{consumerKey: "ck", accessSecret: "as"}); *got != want {
+		t.Errorf("twitterConfigFromKeyring() = %+v, want %+v", *got, want)
+	}
+}
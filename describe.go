@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// rowAltTexts maps the URL in each of the row's media columns to the alt
+// text in the corresponding --alt_columns column, if any. Media without
+// alt text from a column, including that of --media_url, gets --alt_text's,
+// if it's set.
+func rowAltTexts(rw row, rc *runConfig) map[string]string {
+	alts := make(map[string]string)
+	for i, col := range rc.mediaColumns {
+		if i >= len(rc.altColumns) {
+			break
+		}
+		if u, alt := rw.cell(col), rw.cell(rc.altColumns[i]); u != "" && alt != "" {
+			alts[u] = alt
+		}
+	}
+
+	if rc.altTextTemplate == "" {
+		return alts
+	}
+	alt := strings.TrimSpace(renderTemplate(rc.altTextTemplate, rw.values))
+	if alt == "" {
+		return alts
+	}
+	for _, u := range rowMedia(rw, rc) {
+		if alts[u] == "" {
+			alts[u] = alt
+		}
+	}
+	return alts
+}
+
+// describeMedia posts, for each image attached to p that has alt text, a
+// reply with the alt text, for readers who can't see the image. Each reply
+// follows the last, so the descriptions read in order below the post, whose
+// ID is id. A description that fails to post is only a warning, as the
+// post itself has been made.
+func (r *runner) describeMedia(ctx context.Context, rw row, id string, p *post) {
+	alts := rowAltTexts(rw, r.rc)
+
+	var descs []string
+	for _, u := range p.mediaURLs {
+		if alt := alts[u]; alt != "" && mediaKind(u) == mediaImage {
+			descs = append(descs, alt)
+		}
+	}
+
+	length := lengthFunc(r.bc)
+	for i, alt := range descs {
+		status := "Image description: " + alt
+		if len(descs) > 1 {
+			status = fmt.Sprintf("Image %d of %d: %s", i+1, len(descs), alt)
+		}
+
+		reply := &post{status: truncate(status, statusLimit(r.bc), length), replyTo: id}
+		replyID, err := r.poster.Post(ctx, reply)
+		if err != nil {
+			log.Printf("warning: row %d: failed to post the description of image %d: %v", rw.num, i+1, err)
+			return
+		}
+		id = replyID
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRowAltTexts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		values   []interface{}
+		alt      string
+		mediaURL string
+		want     map[string]string
+	}{
+		{
+			name:   "from columns",
+			values: []interface{}{"s", "a.png", "an A", "b.png", ""},
+			want:   map[string]string{"a.png": "an A"},
+		},
+		{
+			name:   "template fills the rest",
+			values: []interface{}{"s", "a.png", "an A", "b.png", ""},
+			alt:    "about {0}",
+			want:   map[string]string{"a.png": "an A", "b.png": "about s"},
+		},
+		{
+			name:     "template covers --media_url",
+			values:   []interface{}{"s", "", "", "", ""},
+			alt:      "about {0}",
+			mediaURL: "https://example.com/{0}.png",
+			want:     map[string]string{"https://example.com/s.png": "about s"},
+		},
+		{
+			name:   "blank template",
+			values: []interface{}{"", "a.png", "", "", ""},
+			alt:    "{0}",
+			want:   map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.mediaColumns = []int{1, 3}
+			rc.altColumns = []int{2, 4}
+			rc.altTextTemplate = tc.alt
+			rc.mediaURLTemplate = tc.mediaURL
+			got := rowAltTexts(row{num: 2, values: tc.values}, rc)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rowAltTexts() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDescribeMedia(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		values    []interface{}
+		wantPosts []*post
+	}{
+		{
+			name:   "one image",
+			values: []interface{}{"s", "a.png", "an A", "", ""},
+			wantPosts: []*post{
+				{status: "Image description: an A", replyTo: "root"},
+			},
+		},
+		{
+			name:   "chained",
+			values: []interface{}{"s", "a.png", "an A", "b.jpg", "a B"},
+			wantPosts: []*post{
+				{status: "Image 1 of 2: an A", replyTo: "root"},
+				{status: "Image 2 of 2: a B", replyTo: "1"},
+			},
+		},
+		{
+			name:   "videos aren't described",
+			values: []interface{}{"s", "v.mp4", "a video", "", ""},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.mediaColumns = []int{1, 3}
+			rc.altColumns = []int{2, 4}
+			poster := &fakePoster{}
+			r := newTestRunner(poster, rc)
+			rw := row{num: 2, values: tc.values}
+
+			r.describeMedia(context.Background(), rw, "root", &post{mediaURLs: rowMedia(rw, rc)})
+			if !reflect.DeepEqual(poster.posts, tc.wantPosts) {
+				t.Errorf("posts = %+v, want %+v", poster.posts, tc.wantPosts)
+			}
+		})
+	}
+}
+
+func TestDescribeMediaFailure(t *testing.T) {
+	rc := testRunConfig()
+	rc.mediaColumns = []int{1}
+	rc.altColumns = []int{2}
+	r := newTestRunner(&fakePoster{err: errors.New("down")}, rc)
+	rw := row{num: 2, values: []interface{}{"s", "a.png", "an A"}}
+
+	// A failed description is only a warning.
+	r.describeMedia(context.Background(), rw, "root", &post{mediaURLs: rowMedia(rw, rc)})
+}
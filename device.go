@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// googleDeviceAuthURL is Google's OAuth2 device authorization endpoint.
+const googleDeviceAuthURL = "https://oauth2.googleapis.com/device/code"
+
+// defaultDeviceExpiry is how long to wait for the device to be authorized
+// if the device authorization response doesn't say.
+const defaultDeviceExpiry = 30 * time.Minute
+
+// deviceScopes are the only scopes Google allows with the device flow.
+// Sheets' own scope isn't among them, so the flow authorizes
+// driveFileScope instead.
+var deviceScopes = map[string]bool{
+	"openid":  true,
+	"email":   true,
+	"profile": true,
+	"https://www.googleapis.com/auth/userinfo.email":   true,
+	"https://www.googleapis.com/auth/userinfo.profile": true,
+	"https://www.googleapis.com/auth/drive.appdata":    true,
+	"https://www.googleapis.com/auth/drive.file":       true,
+	"https://www.googleapis.com/auth/youtube":          true,
+	"https://www.googleapis.com/auth/youtube.readonly": true,
+}
+
+// checkDeviceScopes returns an error wrapping ErrConfig if Google doesn't
+// allow any of scopes with the device flow.
+func checkDeviceScopes(scopes []string) error {
+	for _, s := range scopes {
+		if !deviceScopes[s] {
+			return fmt.Errorf("%w: Google doesn't allow the scope %q with --device_flow; use --service_account_file, or authorize in a browser instead", ErrConfig, s)
+		}
+	}
+	return nil
+}
+
+// deviceCode is a device authorization response (RFC 8628, section 3.2).
+type deviceCode struct {
+	DeviceCode string `json:"device_code"`
+	UserCode   string `json:"user_code"`
+	// Google calls the verification URI verification_url.
+	VerificationURI string `json:"verification_uri"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// getTokenFromDevice gets a token with the OAuth2 device authorization
+// grant, for machines where a browser can't be opened: the user enters a
+// short code on another device while this one polls for the token.
+func getTokenFromDevice(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	if err := checkDeviceScopes(config.Scopes); err != nil {
+		return nil, err
+	}
+	dc, err := requestDeviceCode(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request a device code: %v", err)
+	}
+
+	verificationURI := dc.VerificationURI
+	if verificationURI == "" {
+		verificationURI = dc.VerificationURL
+	}
+	log.Printf("On any device, go to %s and enter the code: %s\n", verificationURI, dc.UserCode)
+
+	expiry := time.Duration(dc.ExpiresIn) * time.Second
+	if expiry <= 0 {
+		expiry = defaultDeviceExpiry
+	}
+	ctx, cancel := context.WithTimeout(ctx, expiry)
+	defer cancel()
+	return pollDeviceToken(ctx, config, dc)
+}
+
+func requestDeviceCode(ctx context.Context, config *oauth2.Config) (*deviceCode, error) {
+	v := url.Values{
+		"client_id": {config.ClientID},
+		"scope":     {strings.Join(config.Scopes, " ")},
+	}
+
+	dc := &deviceCode{}
+	if status, err := postForm(ctx, googleDeviceAuthURL, v, dc); err != nil {
+		return nil, err
+	} else if status != http.StatusOK {
+		return nil, fmt.Errorf("the device authorization endpoint returned %d", status)
+	}
+
+	if dc.Interval <= 0 {
+		dc.Interval = 5
+	}
+	return dc, nil
+}
+
+// pollDeviceToken polls the token endpoint every dc.Interval seconds until
+// the user authorizes this device, denies it, or ctx is done.
+func pollDeviceToken(ctx context.Context, config *oauth2.Config, dc *deviceCode) (*oauth2.Token, error) {
+	v := url.Values{
+		"client_id":     {config.ClientID},
+		"client_secret": {config.ClientSecret},
+		"device_code":   {dc.DeviceCode},
+		"grant_type":    {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+
+	interval := time.Duration(dc.Interval) * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("timed out waiting for the device to be authorized")
+		case <-time.After(interval):
+		}
+
+		var resp struct {
+			AccessToken  string `json:"access_token"`
+			TokenType    string `json:"token_type"`
+			RefreshToken string `json:"refresh_token"`
+			ExpiresIn    int    `json:"expires_in"`
+			Error        string `json:"error"`
+		}
+		if _, err := postForm(ctx, config.Endpoint.TokenURL, v, &resp); err != nil {
+			return nil, err
+		}
+
+		switch resp.Error {
+		case "":
+			return &oauth2.Token{
+				AccessToken:  resp.AccessToken,
+				TokenType:    resp.TokenType,
+				RefreshToken: resp.RefreshToken,
+				Expiry:       time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+			}, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, fmt.Errorf("device authorization failed: %s", resp.Error)
+		}
+	}
+}
+
+// postForm posts the form v to u, decoding the JSON response into out
+// whatever its status, which it returns.
+func postForm(ctx context.Context, u string, v url.Values, out interface{}) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(v.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("bad response from %s (%s): %v", u, resp.Status, err)
+	}
+	return resp.StatusCode, nil
+}
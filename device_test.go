@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestCheckDeviceScopes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "allowed", scopes: []string{"openid", driveFileScope}},
+		{name: "sheets", scopes: []string{permScope}, wantErr: true},
+		{name: "sheets and calendar", scopes: []string{permScope, calendarScope}, wantErr: true},
+		{name: "one not allowed", scopes: []string{"email", calendarScope}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkDeviceScopes(tc.scopes)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("checkDeviceScopes(%q) = %v, want error: %t", tc.scopes, err, tc.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrConfig) {
+				t.Errorf("checkDeviceScopes(%q) = %v, want an ErrConfig", tc.scopes, err)
+			}
+		})
+	}
+}
+
+func TestPollDeviceToken(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if got := r.FormValue("device_code"); got != "dev" {
+			t.Errorf("device_code = %q, want %q", got, "dev")
+		}
+		if polls == 1 {
+			w.WriteHeader(http.StatusPreconditionRequired)
+			fmt.Fprint(w, `{"error":"authorization_pending"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"tok","token_type":"Bearer","refresh_token":"ref","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	config := &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{TokenURL: srv.URL}}
+	tok, err := pollDeviceToken(context.Background(), config, &deviceCode{DeviceCode: "dev"})
+	if err != nil {
+		t.Fatalf("pollDeviceToken() = %v", err)
+	}
+	if tok.AccessToken != "tok" || tok.RefreshToken != "ref" {
+		t.Errorf("pollDeviceToken() = %+v, want the access token %q and refresh token %q", tok, "tok", "ref")
+	}
+	if polls != 2 {
+		t.Errorf("polled %d times, want 2", polls)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// packDigest packs statuses, in order, into groups that each fit within
+// max when joined by sep, returning the indices of each group's statuses.
+// A status too long to fit even alone gets a group of its own.
+func packDigest(statuses []string, sep string, max int, length func(string) int) [][]int {
+	var groups [][]int
+	var group []int
+	n := 0
+	for i, s := range statuses {
+		l := length(s)
+		if len(group) > 0 && n+length(sep)+l <= max {
+			group = append(group, i)
+			n += length(sep) + l
+			continue
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+		group, n = []int{i}, l
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// tweetDigest posts the rows packed into as few digests as fit, as given
+// by packDigest, instead of one post per row. The rows of a digest are
+// tweeted, and so marked complete, together. It stops at the first digest
+// that fails to post.
+//
+// Rows are skipped and rendered as tweet does, but a digest has no
+// hashtags or media, so doMain rejects the flags that add them, as it does
+// those that check or transform each post.
+func (r *runner) tweetDigest(ctx context.Context, rows []row) ([]row, []int, error) {
+	var statuses, display []string
+	var packed []row
+	var failed []int
+	var tweeted []row
+	for _, rw := range rows {
+		if rw.overrides.Skip {
+			log.Printf("row %d: skipping row, as its overrides say to", rw.num)
+			r.explain.note(rw.num, "skipped, as its overrides say to")
+			continue
+		}
+		if blankRow(rw) {
+			r.explain.note(rw.num, "skipped, as it's empty")
+			continue
+		}
+		status, err := renderStatus(rw, r.rc)
+		if err == nil && strings.TrimSpace(status) == "" {
+			err = errEmptyStatus
+		}
+		if errors.Is(err, errEmptyStatus) {
+			log.Printf("warning: row %d: skipping row: %v", rw.num, err)
+			r.explain.note(rw.num, "skipped: %v", err)
+			if r.rc.markEmpty {
+				tweeted = append(tweeted, rw)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("row %d: skipping row: %v", rw.num, err)
+			failed = append(failed, r.failRow(rw.num, err))
+			continue
+		}
+		if r.rc.asciiPunctuation {
+			status = normalizePunctuation(status)
+		}
+		if len(rowMedia(rw, r.rc)) > 0 {
+			log.Printf("warning: row %d: leaving out its media, which can't be attached to a digest", rw.num)
+		}
+		statuses = append(statuses, status)
+		display = append(display, r.displayStatus(rw))
+		packed = append(packed, rw)
+	}
+
+	length := lengthFunc(r.bc)
+	for _, group := range packDigest(statuses, r.rc.digestSeparator, statusLimit(r.bc), length) {
+		parts := make([]string, len(group))
+		for i, j := range group {
+			parts[i] = statuses[j]
+		}
+		status := truncate(strings.Join(parts, r.rc.digestSeparator), statusLimit(r.bc), length)
+
+		var id string
+		if r.rc.markOnly {
+			shown := make([]string, len(group))
+			for i, j := range group {
+				shown[i] = display[j]
+			}
+			log.Printf("mark_only: not tweeting a digest of %d rows: %q", len(group), truncate(strings.Join(shown, r.rc.digestSeparator), statusLimit(r.bc), length))
+		} else {
+			var err error
+			id, err = r.poster.Post(ctx, &post{status: status})
+			if err != nil {
+				for _, j := range group {
+					failed = append(failed, r.failRow(packed[j].num, err))
+				}
+				return tweeted, failed, fmt.Errorf("digest of rows starting at %d: %w", packed[group[0]].num, err)
+			}
+			if r.audit != nil {
+				for _, j := range group {
+					if err := r.audit.record(packed[j].num, id, status); err != nil {
+						return tweeted, failed, fmt.Errorf("a digest was posted as %s, but failed to write audit log: %v", id, err)
+					}
+				}
+			}
+		}
+
+		for _, j := range group {
+			rw := packed[j]
+			rw.postID = id
+			tweeted = append(tweeted, rw)
+		}
+	}
+
+	if len(failed) > 0 {
+		return tweeted, failed, fmt.Errorf("failed to render rows %v", failed)
+	}
+	return tweeted, nil, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPackDigest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []string
+		max      int
+		want     [][]int
+	}{
+		{name: "none"},
+		{name: "all fit", statuses: []string{"aa", "bb", "cc"}, max: 10, want: [][]int{{0, 1, 2}}},
+		{name: "exactly fits", statuses: []string{"aa", "bb"}, max: 5, want: [][]int{{0, 1}}},
+		{name: "split", statuses: []string{"aa", "bb", "cc"}, max: 5, want: [][]int{{0, 1}, {2}}},
+		{name: "too long alone", statuses: []string{"aa", "toolong", "bb"}, max: 5, want: [][]int{{0}, {1}, {2}}},
+		{name: "order is kept", statuses: []string{"aaaa", "b", "cccc"}, max: 6, want: [][]int{{0, 1}, {2}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := packDigest(tc.statuses, " ", tc.max, runeLength); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("packDigest() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// emptyStatusRows returns rows whose second value is their status, which
+// is empty for the second row, though the row itself isn't.
+func emptyStatusRows() []row {
+	return []row{
+		{num: 2, values: []interface{}{"x", "one"}},
+		{num: 3, values: []interface{}{"x", " "}},
+		{num: 4, values: []interface{}{"x", "three"}},
+	}
+}
+
+func TestTweetDigest(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		rows         []row
+		configure    func(rc *runConfig)
+		wantStatuses []string
+		wantTweeted  []int
+	}{
+		{
+			name:         "packed",
+			rows:         testRows("one", "two", "three"),
+			wantStatuses: []string{"one | two | three"},
+			wantTweeted:  []int{2, 3, 4},
+		},
+		{
+			name:         "empty statuses are left out",
+			rows:         emptyStatusRows(),
+			configure:    func(rc *runConfig) { rc.template = "{1}" },
+			wantStatuses: []string{"one | three"},
+			wantTweeted:  []int{2, 4},
+		},
+		{
+			name:         "empty statuses are marked with --mark_empty",
+			rows:         emptyStatusRows(),
+			configure:    func(rc *runConfig) { rc.template = "{1}"; rc.markEmpty = true },
+			wantStatuses: []string{"one | three"},
+			wantTweeted:  []int{3, 2, 4},
+		},
+		{
+			name: "overrides skip rows",
+			rows: func() []row {
+				rows := testRows("one", "two")
+				rows[0].overrides.Skip = true
+				return rows
+			}(),
+			wantStatuses: []string{"two"},
+			wantTweeted:  []int{3},
+		},
+		{
+			name:         "punctuation is normalized",
+			rows:         testRows("“one”", "two…"),
+			configure:    func(rc *runConfig) { rc.asciiPunctuation = true },
+			wantStatuses: []string{`"one" | two...`},
+			wantTweeted:  []int{2, 3},
+		},
+		{
+			name:        "nothing is posted with --mark_only",
+			rows:        testRows("one", "two"),
+			configure:   func(rc *runConfig) { rc.markOnly = true },
+			wantTweeted: []int{2, 3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.digest = true
+			rc.digestSeparator = " | "
+			if tc.configure != nil {
+				tc.configure(rc)
+			}
+			p := &fakePoster{}
+			r := newTestRunner(p, rc)
+
+			tweeted, failed, err := r.tweetDigest(context.Background(), tc.rows)
+			if err != nil || len(failed) > 0 {
+				t.Fatalf("tweetDigest() = %v, failed rows %v", err, failed)
+			}
+			if got := p.statuses(); !reflect.DeepEqual(got, tc.wantStatuses) {
+				t.Errorf("posted %q, want %q", got, tc.wantStatuses)
+			}
+			if got := rowNums(tweeted); !reflect.DeepEqual(got, tc.wantTweeted) {
+				t.Errorf("tweeted rows %v, want %v", got, tc.wantTweeted)
+			}
+		})
+	}
+}
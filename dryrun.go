@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// dryRun prints the status that would be posted for each pending row,
+// without posting any or marking them complete. With a status column, it
+// also reports whether markComplete could write to it, by making an update
+// that changes no cells, which still requires write access.
+func (r *runner) dryRun(ctx context.Context, w io.Writer) error {
+	rows, err := r.readRows()
+	if err != nil && !errors.Is(err, ErrNoData) {
+		return err
+	}
+
+	fmt.Fprintf(w, "%d pending rows\n", len(rows))
+	for _, rw := range rows {
+		fmt.Fprintf(w, "row %d: %q\n", rw.num, r.displayStatus(rw))
+	}
+
+	if r.statusColumn == "" || r.srv == nil {
+		return nil
+	}
+	ok := true
+	for _, sr := range r.ranges {
+		if err := r.probeWrite(ctx, sr); err != nil {
+			ok = false
+			fmt.Fprintf(w, "write access to column %s of %s: FAIL (%v)\n", r.statusColumn, sr.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "write access to column %s of %s: OK\n", r.statusColumn, sr.name)
+	}
+	if !ok {
+		return fmt.Errorf("%w: rows couldn't be marked complete", ErrSheetWrite)
+	}
+	return nil
+}
+
+// probeWrite checks that the spreadsheet of sr can be written to, with an
+// update of no cells.
+func (r *runner) probeWrite(ctx context.Context, sr *sheetRange) error {
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: "RAW"}
+	_, err := r.srv.Spreadsheets.Values.BatchUpdate(sr.id, req).Context(ctx).Do()
+	return err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		failWrite bool
+		wantErr   error
+		wantLines []string
+	}{
+		{
+			name:      "writable",
+			wantLines: []string{"2 pending rows", `row 2: "a one"`, `row 3: "b two"`, "write access to column D of Posts: OK"},
+		},
+		{
+			name:      "read only",
+			failWrite: true,
+			wantErr:   ErrSheetWrite,
+			wantLines: []string{"2 pending rows", "write access to column D of Posts: FAIL"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, srv := newFakeSheet(t,
+				[]string{"Word", "Number"},
+				[]string{"a", "one"},
+				[]string{"b", "two"},
+			)
+			f.failUpdate = func([]string) bool { return tc.failWrite }
+			poster := &fakePoster{}
+			rc := testRunConfig()
+			rc.template = "{0} {1}"
+			r := newSheetRunner(poster, rc, srv)
+
+			var b strings.Builder
+			err := r.dryRun(context.Background(), &b)
+			if !errors.Is(err, tc.wantErr) || (err != nil) != (tc.wantErr != nil) {
+				t.Errorf("dryRun() = %v, want %v", err, tc.wantErr)
+			}
+			for _, l := range tc.wantLines {
+				if !strings.Contains(b.String(), l) {
+					t.Errorf("printed %q, want %q", b.String(), l)
+				}
+			}
+			if len(f.updates) != 1 || len(f.updates[0]) != 0 {
+				t.Errorf("batch updates = %q, want one of no ranges", f.updates)
+			}
+			if s := poster.statuses(); len(s) != 0 {
+				t.Errorf("posted %q, want nothing", s)
+			}
+			if got := f.cell("D", 2); got != "" {
+				t.Errorf("status of row 2 = %q, want it unmarked", got)
+			}
+		})
+	}
+}
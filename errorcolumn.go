@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// maxErrorMessage is the most runes of a row's error written to
+// --error_column.
+const maxErrorMessage = 200
+
+// failRow records err as why the row numbered num failed, to be written to
+// --error_column and explained with --explain, and returns num.
+func (r *runner) failRow(num int, err error) int {
+	r.explain.note(num, "failed: %v", err)
+	if r.rowErrors != nil && err != nil {
+		r.rowErrors[num] = err.Error()
+	}
+	return num
+}
+
+// writeErrors writes the recorded error of each failed row to its cell in
+// --error_column, and clears those of the tweeted rows, which may have
+// failed on an earlier run. A row that failed without a recorded error is
+// given a generic one.
+func (r *runner) writeErrors(tweeted []row, failed []int) error {
+	if len(tweeted) == 0 && len(failed) == 0 {
+		return nil
+	}
+
+	messages := make(map[int]string)
+	var nums []int
+	for _, rw := range tweeted {
+		messages[rw.num] = ""
+		nums = append(nums, rw.num)
+	}
+	for _, num := range failed {
+		msg, ok := r.rowErrors[num]
+		if !ok {
+			msg = "failed to post"
+		}
+		if _, seen := messages[num]; !seen {
+			nums = append(nums, num)
+		}
+		messages[num] = truncate(msg, maxErrorMessage, runeLength)
+	}
+
+	// --error_column needs a single source, so every row is from it.
+	sr := r.ranges[0]
+	req := &sheets.BatchUpdateValuesRequest{
+		Data: columnRanges(sr.name, r.sc.errorColumn, nums, func(num int) interface{} {
+			return messages[num]
+		}),
+		ValueInputOption: "RAW",
+	}
+	if _, err := r.srv.Spreadsheets.Values.BatchUpdate(sr.id, req).Do(); err != nil {
+		return fmt.Errorf("failed to update %d cells in column %s: %w", len(nums), r.sc.errorColumn, err)
+	}
+	log.Printf("wrote the errors of %d failed rows to column %s", len(failed), r.sc.errorColumn)
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFailRow(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		rowErrors map[int]string
+		err       error
+		want      map[int]string
+	}{
+		{name: "recorded", rowErrors: map[int]string{}, err: errors.New("rate limited"), want: map[int]string{5: "rate limited"}},
+		{name: "no error", rowErrors: map[int]string{}, want: map[int]string{}},
+		{name: "without --error_column", err: errors.New("rate limited")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRunner(&fakePoster{}, testRunConfig())
+			r.rowErrors = tc.rowErrors
+			if got := r.failRow(5, tc.err); got != 5 {
+				t.Errorf("failRow() = %d, want 5", got)
+			}
+			if len(r.rowErrors) != len(tc.want) || r.rowErrors[5] != tc.want[5] {
+				t.Errorf("row errors = %v, want %v", r.rowErrors, tc.want)
+			}
+		})
+	}
+}
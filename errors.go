@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+)
+
+// These errors categorize the failures of a run. Errors returned by doMain
+// wrap one of them, so they can be told apart with errors.Is.
+var (
+	ErrConfig     = errors.New("bad configuration")
+	ErrAuth       = errors.New("authentication failed")
+	ErrSheetRead  = errors.New("failed to read sheet")
+	ErrSheetWrite = errors.New("failed to write sheet")
+	ErrNoData     = errors.New("no data found from spreadsheet")
+	ErrPost       = errors.New("failed to post")
+	ErrTooFew     = errors.New("too few rows tweeted")
+)
+
+// exitCode maps err to the process's exit code, so that monitoring can
+// tell failures apart.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, ErrConfig):
+		return 2
+	case errors.Is(err, ErrAuth):
+		return 3
+	case errors.Is(err, ErrSheetRead):
+		return 4
+	case errors.Is(err, ErrSheetWrite):
+		return 5
+	case errors.Is(err, ErrNoData):
+		return 6
+	case errors.Is(err, ErrPost):
+		return 7
+	case errors.Is(err, ErrTooFew):
+		return 8
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{err: errors.New("other"), want: 1},
+		{err: ErrConfig, want: 2},
+		{err: fmt.Errorf("%w: --source is required", ErrConfig), want: 2},
+		{err: fmt.Errorf("%w: token expired", ErrAuth), want: 3},
+		{err: fmt.Errorf("%w: 500", ErrSheetRead), want: 4},
+		{err: fmt.Errorf("%w: 403", ErrSheetWrite), want: 5},
+		{err: ErrNoData, want: 6},
+		{err: fmt.Errorf("row 2: %w", fmt.Errorf("%w: duplicate", ErrPost)), want: 7},
+		{err: fmt.Errorf("%w: 1 of 3", ErrTooFew), want: 8},
+	} {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// explainer records, for --explain, the decisions a run makes about each
+// row: whether it's left out as complete, filtered, a duplicate, skipped
+// or deferred, and whether it's finally posted or fails. Its methods do
+// nothing on a nil explainer, so calls needn't check for --explain.
+type explainer struct {
+	order []int
+	steps map[int][]string
+}
+
+func newExplainer() *explainer {
+	return &explainer{steps: make(map[int][]string)}
+}
+
+// note records a decision about the row numbered num.
+func (e *explainer) note(num int, format string, args ...interface{}) {
+	if e == nil {
+		return
+	}
+	if _, ok := e.steps[num]; !ok {
+		e.order = append(e.order, num)
+	}
+	e.steps[num] = append(e.steps[num], fmt.Sprintf(format, args...))
+}
+
+// noteRows records the same decision about each of rows.
+func (e *explainer) noteRows(rows []row, format string, args ...interface{}) {
+	for _, rw := range rows {
+		e.note(rw.num, format, args...)
+	}
+}
+
+// log logs the decisions about each row, in the order the rows were first
+// noted.
+func (e *explainer) log() {
+	if e == nil {
+		return
+	}
+	for _, num := range e.order {
+		log.Printf("explain: row %d: %s", num, strings.Join(e.steps[num], "; then "))
+	}
+}
+
+// dropped returns those of before that aren't in after, by row number.
+func dropped(before, after []row) []row {
+	kept := make(map[int]bool, len(after))
+	for _, rw := range after {
+		kept[rw.num] = true
+	}
+	var out []row
+	for _, rw := range before {
+		if !kept[rw.num] {
+			out = append(out, rw)
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDropped(t *testing.T) {
+	rows := testRows("a", "b", "c", "d")
+	for _, tc := range []struct {
+		name  string
+		after []row
+		want  []int
+	}{
+		{name: "none dropped", after: rows},
+		{name: "some dropped", after: []row{rows[1], rows[3]}, want: []int{2, 4}},
+		{name: "all dropped", want: []int{2, 3, 4, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rowNums(dropped(rows, tc.after)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("dropped() = rows %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExplainerNote(t *testing.T) {
+	e := newExplainer()
+	e.note(3, "read")
+	e.noteRows(testRows("a", "b"), "left out, as it's %s", "complete")
+	e.note(3, "posted as %s", "9")
+
+	if want := []int{3, 2}; !reflect.DeepEqual(e.order, want) {
+		t.Errorf("order = %v, want %v", e.order, want)
+	}
+	want := map[int][]string{
+		2: {"left out, as it's complete"},
+		3: {"read", "left out, as it's complete", "posted as 9"},
+	}
+	if !reflect.DeepEqual(e.steps, want) {
+		t.Errorf("steps = %q, want %q", e.steps, want)
+	}
+
+	// A nil explainer ignores notes, as it's what runs without --explain
+	// have.
+	var none *explainer
+	none.note(2, "read")
+	none.noteRows(testRows("a"), "read")
+	none.log()
+}
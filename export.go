@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// urlRE matches the http(s) URLs in a status.
+var urlRE = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// exportRenderers maps each --export_format to its renderer.
+var exportRenderers = map[string]func(string) string{
+	"md":   renderMarkdown,
+	"html": renderHTML,
+}
+
+// exportStatuses appends each of the statuses, as rendered by render,
+// to the file at path.
+func exportStatuses(path string, render func(string) string, statuses []string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if _, err := f.WriteString(render(s)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// markdownEscaper escapes the characters that Markdown would otherwise
+// treat as formatting.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+)
+
+// renderMarkdown renders status as a Markdown paragraph, with its URLs as
+// autolinks and its line breaks kept.
+func renderMarkdown(status string) string {
+	var b strings.Builder
+	forEachLink(status, func(text string) {
+		b.WriteString(strings.Replace(markdownEscaper.Replace(text), "\n", "  \n", -1))
+	}, func(link string) {
+		fmt.Fprintf(&b, "<%s>", link)
+	})
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// renderHTML renders status as an HTML paragraph, with its URLs as links and
+// its line breaks kept.
+func renderHTML(status string) string {
+	var b strings.Builder
+	b.WriteString("<p>")
+	forEachLink(status, func(text string) {
+		b.WriteString(strings.Replace(html.EscapeString(text), "\n", "<br>\n", -1))
+	}, func(link string) {
+		l := html.EscapeString(link)
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`, l, l)
+	})
+	b.WriteString("</p>\n")
+	return b.String()
+}
+
+// forEachLink splits s into runs of plain text and URLs, calling text or
+// link for each in order.
+func forEachLink(s string, text, link func(string)) {
+	last := 0
+	for _, loc := range urlRE.FindAllStringIndex(s, -1) {
+		if loc[0] > last {
+			text(s[last:loc[0]])
+		}
+		link(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	if last < len(s) {
+		text(s[last:])
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "plain", want: "plain\n\n"},
+		{in: "*bold* _it_ #tag", want: `\*bold\* \_it\_ \#tag` + "\n\n"},
+		{in: "see https://example.com/a_b", want: "see <https://example.com/a_b>\n\n"},
+		{in: "one\ntwo", want: "one  \ntwo\n\n"},
+	} {
+		if got := renderMarkdown(tc.in); got != tc.want {
+			t.Errorf("renderMarkdown(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "plain", want: "<p>plain</p>\n"},
+		{in: "a <b> & c", want: "<p>a &lt;b&gt; &amp; c</p>\n"},
+		{in: "see https://example.com/?a=1&b=2", want: `<p>see <a href="https://example.com/?a=1&amp;b=2">https://example.com/?a=1&amp;b=2</a></p>` + "\n"},
+		{in: "one\ntwo", want: "<p>one<br>\ntwo</p>\n"},
+	} {
+		if got := renderHTML(tc.in); got != tc.want {
+			t.Errorf("renderHTML(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestForEachLink(t *testing.T) {
+	var got []string
+	forEachLink("a http://x.co b https://y.co", func(s string) {
+		got = append(got, "text:"+s)
+	}, func(s string) {
+		got = append(got, "link:"+s)
+	})
+	want := []string{"text:a ", "link:http://x.co", "text: b ", "link:https://y.co"}
+	if len(got) != len(want) {
+		t.Fatalf("forEachLink() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("forEachLink()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExportStatusesAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.md")
+	if err := exportStatuses(path, renderMarkdown, []string{"one"}); err != nil {
+		t.Fatalf("exportStatuses() = %v", err)
+	}
+	if err := exportStatuses(path, renderMarkdown, []string{"two", "three"}); err != nil {
+		t.Fatalf("exportStatuses() = %v", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "one\n\ntwo\n\nthree\n\n"; got != want {
+		t.Errorf("exported %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// rssFeed is an RSS 2.0 document of the posts made.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	Items       []feedItem `xml:"item"`
+}
+
+// feedItem is a single post in the feed.
+type feedItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link,omitempty"`
+	Description string `xml:"description"`
+	GUID        string `xml:"guid,omitempty"`
+	PubDate     string `xml:"pubDate"`
+}
+
+// maxFeedTitle is the most runes of a status used as its item's title.
+const maxFeedTitle = 100
+
+// newFeedItem returns the feed item for status, posted at t as link.
+func newFeedItem(status, link string, t time.Time) feedItem {
+	title := strings.SplitN(status, "\n", 2)[0]
+	if r := []rune(title); len(r) > maxFeedTitle {
+		title = string(r[:maxFeedTitle-1]) + "…"
+	}
+	return feedItem{
+		Title:       title,
+		Link:        link,
+		Description: status,
+		GUID:        link,
+		PubDate:     t.UTC().Format(time.RFC1123Z),
+	}
+}
+
+// writeFeed adds items to the front of the feed at path, creating it if it
+// doesn't exist, and keeps at most max of its newest items.
+func writeFeed(path string, items []feedItem, max int) error {
+	feed := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{Title: "hitlist", Description: "Posts made by hitlist"},
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := xml.Unmarshal(data, feed); err != nil {
+			return err
+		}
+	}
+
+	// The newest items come first, so reverse those being added, which
+	// are in the order they were posted.
+	var merged []feedItem
+	for i := len(items) - 1; i >= 0; i-- {
+		merged = append(merged, items[i])
+	}
+	merged = append(merged, feed.Channel.Items...)
+	if max > 0 && len(merged) > max {
+		merged = merged[:max]
+	}
+	feed.Channel.Items = merged
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append([]byte(xml.Header), append(out, '\n')...))
+}
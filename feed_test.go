@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFeedItem(t *testing.T) {
+	at := time.Date(2024, 6, 3, 14, 5, 0, 0, time.FixedZone("EST", -5*3600))
+	for _, tc := range []struct {
+		name      string
+		status    string
+		wantTitle string
+	}{
+		{name: "short", status: "hello", wantTitle: "hello"},
+		{name: "first line", status: "hello\nworld", wantTitle: "hello"},
+		{name: "exactly max", status: strings.Repeat("é", maxFeedTitle), wantTitle: strings.Repeat("é", maxFeedTitle)},
+		{name: "too long", status: strings.Repeat("é", maxFeedTitle+1), wantTitle: strings.Repeat("é", maxFeedTitle-1) + "…"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			item := newFeedItem(tc.status, "https://x.com/jack/status/20", at)
+			if item.Title != tc.wantTitle {
+				t.Errorf("Title = %q, want %q", item.Title, tc.wantTitle)
+			}
+			if item.Description != tc.status {
+				t.Errorf("Description = %q, want %q", item.Description, tc.status)
+			}
+			if want := "Mon, 03 Jun 2024 19:05:00 +0000"; item.PubDate != want {
+				t.Errorf("PubDate = %q, want %q", item.PubDate, want)
+			}
+		})
+	}
+}
+
+func feedTitles(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed rssFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("the feed isn't valid XML: %v", err)
+	}
+	var titles []string
+	for _, item := range feed.Channel.Items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+func TestWriteFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	at := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	items := func(titles ...string) []feedItem {
+		var items []feedItem
+		for _, title := range titles {
+			items = append(items, newFeedItem(title, "", at))
+		}
+		return items
+	}
+
+	if err := writeFeed(path, items("a", "b"), 3); err != nil {
+		t.Fatalf("writeFeed() = %v", err)
+	}
+	if got, want := feedTitles(t, path), []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("feed = %q, want %q", got, want)
+	}
+
+	if err := writeFeed(path, items("c", "d"), 3); err != nil {
+		t.Fatalf("writeFeed() = %v", err)
+	}
+	if got, want := feedTitles(t, path), []string{"d", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("feed = %q, want %q", got, want)
+	}
+}
+
+func TestWriteFeedCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := ioutil.WriteFile(path, []byte("<rss><channel>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFeed(path, nil, 0); err == nil {
+		t.Error("writeFeed() over a corrupt feed = nil, want an error")
+	}
+}
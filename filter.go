@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// filterByAge splits rows into those whose date in column col is within
+// maxAge of now and those that are older. Rows whose date can't be parsed
+// are in neither.
+func filterByAge(rows []row, col int, maxAge time.Duration, now time.Time) (fresh, aged []row) {
+	cutoff := now.Add(-maxAge)
+	for _, r := range rows {
+		t, err := parseSheetTime(r.cell(col), now.Location())
+		if err != nil {
+			log.Printf("warning: row %d: skipping row with a bad date: %v", r.num, err)
+			continue
+		}
+		if t.Before(cutoff) {
+			aged = append(aged, r)
+			continue
+		}
+		fresh = append(fresh, r)
+	}
+	return fresh, aged
+}
+
+// filterModifiedSince returns the rows whose modification time in column
+// col is after since, or all of them if since is zero. Rows whose time can't
+// be parsed are left out. Times without a zone are taken to be in loc.
+func filterModifiedSince(rows []row, col int, since time.Time, loc *time.Location) []row {
+	var modified []row
+	for _, r := range rows {
+		t, err := parseSheetTime(r.cell(col), loc)
+		if err != nil {
+			log.Printf("warning: row %d: skipping row with a bad modification time: %v", r.num, err)
+			continue
+		}
+		if since.IsZero() || t.After(since) {
+			modified = append(modified, r)
+		}
+	}
+	return modified
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterByAge(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	rows := testRows("2024-06-10", "2024-06-03 12:00", "2024-06-01", "not a date", "2024-06-09")
+
+	fresh, aged := filterByAge(rows, 0, 7*24*time.Hour, now)
+	if got, want := rowNums(fresh), []int{2, 3, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fresh rows = %v, want %v", got, want)
+	}
+	if got, want := rowNums(aged), []int{4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("aged rows = %v, want %v", got, want)
+	}
+}
+
+func TestFilterModifiedSince(t *testing.T) {
+	rows := testRows("2024-06-03 10:00", "2024-06-03 12:00", "garbage", "2024-06-04")
+	for _, tc := range []struct {
+		name  string
+		since time.Time
+		want  []int
+	}{
+		{name: "zero", want: []int{2, 3, 5}},
+		{name: "between", since: time.Date(2024, 6, 3, 11, 0, 0, 0, time.UTC), want: []int{3, 5}},
+		{name: "equal is not after", since: time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC), want: []int{5}},
+		{name: "after all", since: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rowNums(filterModifiedSince(rows, 0, tc.since, time.UTC))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("filterModifiedSince() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// viewFilter is the criteria of a filter view, by 0-based column.
+type viewFilter map[int]*sheets.FilterCriteria
+
+// loadFilterView returns the criteria of the filter view with the given ID
+// in the spreadsheet, checking that hitlist can apply them.
+func loadFilterView(srv *sheets.Service, spreadsheetID string, viewID int64) (viewFilter, error) {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.filterViews").Do()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get the filter views of spreadsheet %q: %w", ErrSheetRead, spreadsheetID, err)
+	}
+
+	for _, s := range resp.Sheets {
+		for _, fv := range s.FilterViews {
+			if fv.FilterViewId == viewID {
+				return newViewFilter(fv)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: spreadsheet %q has no filter view %d", ErrConfig, spreadsheetID, viewID)
+}
+
+// newViewFilter returns the criteria of fv, from either its filter specs or
+// its older criteria map.
+func newViewFilter(fv *sheets.FilterView) (viewFilter, error) {
+	vf := make(viewFilter)
+	for key, c := range fv.Criteria {
+		col, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: filter view %d has criteria for bad column %q", ErrConfig, fv.FilterViewId, key)
+		}
+		c := c
+		vf[col] = &c
+	}
+	for _, spec := range fv.FilterSpecs {
+		if spec.FilterCriteria != nil {
+			vf[int(spec.ColumnIndex)] = spec.FilterCriteria
+		}
+	}
+
+	for col, c := range vf {
+		if c.Condition == nil {
+			continue
+		}
+		switch c.Condition.Type {
+		case "TEXT_EQ", "NUMBER_EQ", "NOT_BLANK", "BLANK":
+		default:
+			return nil, fmt.Errorf("%w: filter view %d has a %s condition on column %s, which isn't supported", ErrConfig, fv.FilterViewId, c.Condition.Type, columnLetters(col))
+		}
+	}
+	return vf, nil
+}
+
+// filter returns the rows that vf shows.
+func (vf viewFilter) filter(rows []row) []row {
+	var shown []row
+	for _, rw := range rows {
+		if vf.shows(rw) {
+			shown = append(shown, rw)
+		}
+	}
+	return shown
+}
+
+// shows reports whether rw meets each of vf's criteria: its value isn't
+// one of those hidden, and meets the condition, if any.
+func (vf viewFilter) shows(rw row) bool {
+	for col, c := range vf {
+		v := rw.cell(col)
+		for _, hidden := range c.HiddenValues {
+			if v == hidden {
+				return false
+			}
+		}
+		if c.Condition != nil && !meetsCondition(v, c.Condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// meetsCondition reports whether the value v meets the condition cond,
+// which is one of the types newViewFilter allows.
+func meetsCondition(v string, cond *sheets.BooleanCondition) bool {
+	switch cond.Type {
+	case "NOT_BLANK":
+		return strings.TrimSpace(v) != ""
+	case "BLANK":
+		return strings.TrimSpace(v) == ""
+	case "TEXT_EQ":
+		return len(cond.Values) > 0 && strings.EqualFold(v, cond.Values[0].UserEnteredValue)
+	case "NUMBER_EQ":
+		if len(cond.Values) == 0 {
+			return false
+		}
+		got, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		want, werr := strconv.ParseFloat(cond.Values[0].UserEnteredValue, 64)
+		return err == nil && werr == nil && got == want
+	default:
+		return true
+	}
+}
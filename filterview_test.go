@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+func condition(typ string, values ...string) *sheets.FilterCriteria {
+	c := &sheets.BooleanCondition{Type: typ}
+	for _, v := range values {
+		c.Values = append(c.Values, &sheets.ConditionValue{UserEnteredValue: v})
+	}
+	return &sheets.FilterCriteria{Condition: c}
+}
+
+func TestNewViewFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fv       *sheets.FilterView
+		wantCols []int
+		wantErr  bool
+	}{
+		{
+			name:     "criteria map",
+			fv:       &sheets.FilterView{Criteria: map[string]sheets.FilterCriteria{"2": {HiddenValues: []string{"x"}}}},
+			wantCols: []int{2},
+		},
+		{
+			name:     "filter specs",
+			fv:       &sheets.FilterView{FilterSpecs: []*sheets.FilterSpec{{ColumnIndex: 1, FilterCriteria: condition("NOT_BLANK")}, {ColumnIndex: 3}}},
+			wantCols: []int{1},
+		},
+		{
+			name:    "bad column",
+			fv:      &sheets.FilterView{Criteria: map[string]sheets.FilterCriteria{"B": {}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported condition",
+			fv:      &sheets.FilterView{FilterSpecs: []*sheets.FilterSpec{{ColumnIndex: 0, FilterCriteria: condition("TEXT_CONTAINS", "a")}}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vf, err := newViewFilter(tc.fv)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("newViewFilter() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrConfig) {
+					t.Errorf("newViewFilter() = %v, want an ErrConfig", err)
+				}
+				return
+			}
+			var cols []int
+			for col := range vf {
+				cols = append(cols, col)
+			}
+			if !reflect.DeepEqual(cols, tc.wantCols) {
+				t.Errorf("columns = %v, want %v", cols, tc.wantCols)
+			}
+		})
+	}
+}
+
+func TestMeetsCondition(t *testing.T) {
+	for _, tc := range []struct {
+		v    string
+		c    *sheets.FilterCriteria
+		want bool
+	}{
+		{v: "x", c: condition("NOT_BLANK"), want: true},
+		{v: " ", c: condition("NOT_BLANK"), want: false},
+		{v: "", c: condition("BLANK"), want: true},
+		{v: "x", c: condition("BLANK"), want: false},
+		{v: "Ready", c: condition("TEXT_EQ", "ready"), want: true},
+		{v: "Ready!", c: condition("TEXT_EQ", "ready"), want: false},
+		{v: "x", c: condition("TEXT_EQ"), want: false},
+		{v: " 3 ", c: condition("NUMBER_EQ", "3.0"), want: true},
+		{v: "4", c: condition("NUMBER_EQ", "3"), want: false},
+		{v: "three", c: condition("NUMBER_EQ", "3"), want: false},
+		{v: "3", c: condition("NUMBER_EQ"), want: false},
+	} {
+		if got := meetsCondition(tc.v, tc.c.Condition); got != tc.want {
+			t.Errorf("meetsCondition(%q, %s %v) = %t, want %t", tc.v, tc.c.Condition.Type, tc.c.Condition.Values, got, tc.want)
+		}
+	}
+}
+
+func TestViewFilterFilter(t *testing.T) {
+	vf := viewFilter{
+		1: &sheets.FilterCriteria{HiddenValues: []string{"draft"}},
+		2: condition("NOT_BLANK"),
+	}
+	rows := []row{
+		{num: 2, values: []interface{}{"a", "ready", "yes"}},
+		{num: 3, values: []interface{}{"b", "draft", "yes"}},
+		{num: 4, values: []interface{}{"c", "ready", ""}},
+		{num: 5, values: []interface{}{"d", "", "yes"}},
+	}
+	if got, want := rowNums(vf.filter(rows)), []int{2, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filter() = %v, want %v", got, want)
+	}
+}
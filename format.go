@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatCell formats value with spec, which is either a fmt verb for
+// numbers, such as "%.2f" or "%d", or a Go time layout for dates, such as
+// "2006-01-02". Dates may be in any form parseSheetTime accepts, including
+// Sheets' serial numbers. An empty value is left empty.
+func formatCell(value interface{}, spec string) (string, error) {
+	s := strings.TrimSpace(fmt.Sprint(value))
+	if value == nil || s == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(spec, "%") {
+		f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a number", s)
+		}
+		var out string
+		switch spec[len(spec)-1] {
+		case 'd', 'x', 'X', 'o', 'b', 'c':
+			out = fmt.Sprintf(spec, int64(f))
+		default:
+			out = fmt.Sprintf(spec, f)
+		}
+		if strings.Contains(out, "%!") {
+			return "", fmt.Errorf("bad number format %q", spec)
+		}
+		return out, nil
+	}
+
+	if err := validateLayout(spec); err != nil {
+		return "", err
+	}
+	t, err := parseSheetTime(s, time.Local)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(spec), nil
+}
+
+// validateLayout checks that layout formats at least part of a time, so
+// that a mistyped spec isn't output as is.
+func validateLayout(layout string) error {
+	if layout == "" || time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(layout) == layout {
+		return fmt.Errorf("%q is neither a number format nor a time layout", layout)
+	}
+	return nil
+}
+
+// startsWithIndex reports whether p starts with an index and a colon.
+func startsWithIndex(p string) bool {
+	i := strings.Index(p, ":")
+	if i < 0 {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimSpace(p[:i]))
+	return err == nil
+}
+
+// parseColumnFormats parses a --column_formats spec, such as
+// "2:%.2f,3:2006-01-02", into the format of each value index. Since layouts
+// may contain commas, a comma only separates formats when followed by an
+// index and a colon.
+func parseColumnFormats(spec string) (map[int]string, error) {
+	formats := make(map[int]string)
+	if spec == "" {
+		return formats, nil
+	}
+
+	var parts []string
+	for _, p := range strings.Split(spec, ",") {
+		if len(parts) > 0 && !startsWithIndex(p) {
+			parts[len(parts)-1] += "," + p
+			continue
+		}
+		parts = append(parts, p)
+	}
+
+	for _, p := range parts {
+		i := strings.Index(p, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not of the form INDEX:FORMAT", p)
+		}
+		idx, err := strconv.Atoi(strings.TrimSpace(p[:i]))
+		if err != nil || idx < 0 {
+			return nil, fmt.Errorf("bad index in %q", p)
+		}
+		format := p[i+1:]
+		if !strings.HasPrefix(format, "%") {
+			if err := validateLayout(format); err != nil {
+				return nil, err
+			}
+		} else if _, err := formatCell(0, format); err != nil {
+			return nil, err
+		}
+		formats[idx] = format
+	}
+	return formats, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatCell(t *testing.T) {
+	for _, tc := range []struct {
+		value   interface{}
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{value: "3.14159", spec: "%.2f", want: "3.14"},
+		{value: "1,234.5", spec: "%.0f", want: "1234"},
+		{value: "42.9", spec: "%d", want: "42"},
+		{value: "255", spec: "%x", want: "ff"},
+		{value: "", spec: "%.2f", want: ""},
+		{value: nil, spec: "%.2f", want: ""},
+		{value: "many", spec: "%.2f", wantErr: true},
+		{value: "1", spec: "%q", wantErr: true},
+		{value: "2024-06-03", spec: "Jan 2", want: "Jun 3"},
+		{value: "6/3/2024 14:05", spec: "15:04 on Monday", want: "14:05 on Monday"},
+		{value: "45446", spec: "2006-01-02", want: "2024-06-03"},
+		{value: "2024-06-03", spec: "no layout", wantErr: true},
+		{value: "soon", spec: "2006-01-02", wantErr: true},
+	} {
+		got, err := formatCell(tc.value, tc.spec)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("formatCell(%q, %q) = %v, want error: %t", tc.value, tc.spec, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("formatCell(%q, %q) = %q, want %q", tc.value, tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestParseColumnFormats(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    map[int]string
+		wantErr bool
+	}{
+		{in: "", want: map[int]string{}},
+		{in: "2:%.2f", want: map[int]string{2: "%.2f"}},
+		{in: "2:%.2f,3:2006-01-02", want: map[int]string{2: "%.2f", 3: "2006-01-02"}},
+		{in: "0:Jan 2, 2006,1:%d", want: map[int]string{0: "Jan 2, 2006", 1: "%d"}},
+		{in: "%.2f", wantErr: true},
+		{in: "x:%.2f", wantErr: true},
+		{in: "-1:%.2f", wantErr: true},
+		{in: "1:nothing", wantErr: true},
+	} {
+		got, err := parseColumnFormats(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseColumnFormats(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseColumnFormats(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// geoPoint is the location a post is tagged with.
+type geoPoint struct {
+	lat, long float64
+}
+
+// parseGeo parses a latitude and longitude in decimal degrees. It returns
+// nil, and no error, if either of them is missing.
+func parseGeo(lat, long string) (*geoPoint, error) {
+	lat, long = strings.TrimSpace(lat), strings.TrimSpace(long)
+	if lat == "" || long == "" {
+		return nil, nil
+	}
+
+	g := &geoPoint{}
+	var err error
+	if g.lat, err = strconv.ParseFloat(lat, 64); err != nil {
+		return nil, fmt.Errorf("bad latitude %q", lat)
+	}
+	if g.long, err = strconv.ParseFloat(long, 64); err != nil {
+		return nil, fmt.Errorf("bad longitude %q", long)
+	}
+
+	if g.lat < -90 || g.lat > 90 {
+		return nil, fmt.Errorf("latitude %v is not between -90 and 90", g.lat)
+	}
+	if g.long < -180 || g.long > 180 {
+		return nil, fmt.Errorf("longitude %v is not between -180 and 180", g.long)
+	}
+	return g, nil
+}
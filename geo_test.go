@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGeo(t *testing.T) {
+	for _, tc := range []struct {
+		lat, long string
+		want      *geoPoint
+		wantErr   bool
+	}{
+		{lat: "40.7128", long: "-74.0060", want: &geoPoint{lat: 40.7128, long: -74.006}},
+		{lat: " 90 ", long: "180", want: &geoPoint{lat: 90, long: 180}},
+		{lat: "", long: "-74"},
+		{lat: "40", long: " "},
+		{lat: "north", long: "-74", wantErr: true},
+		{lat: "40", long: "west", wantErr: true},
+		{lat: "91", long: "0", wantErr: true},
+		{lat: "0", long: "-181", wantErr: true},
+	} {
+		got, err := parseGeo(tc.lat, tc.long)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseGeo(%q, %q) = %v, want error: %t", tc.lat, tc.long, err, tc.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseGeo(%q, %q) = %+v, want %+v", tc.lat, tc.long, got, tc.want)
+		}
+	}
+}
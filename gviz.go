@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// gvizBase is the base URL of the spreadsheets whose visualization API
+// gvizSource queries.
+const gvizBase = "https://docs.google.com/spreadsheets/d/"
+
+// gvizSource reads rows by running a query on a spreadsheet through
+// Google's visualization API, which does the filtering instead of hitlist.
+// The spreadsheet must be public or published, as the API isn't
+// authorized.
+type gvizSource struct {
+	client *http.Client
+	// base is the base URL of spreadsheets, gvizBase unless testing.
+	base                        string
+	id, sheet, cellRange, query string
+}
+
+func newGvizSource(client *http.Client, sc *sheetsConfig, query string) *gvizSource {
+	return &gvizSource{client: client, base: gvizBase, id: sc.id, sheet: sc.name, cellRange: sc.cellRange, query: query}
+}
+
+func (s *gvizSource) String() string {
+	return fmt.Sprintf("--gviz_query %q", s.query)
+}
+
+// gvizResponse is the response of a visualization API query.
+type gvizResponse struct {
+	Status string `json:"status"`
+	Errors []struct {
+		Message         string `json:"message"`
+		DetailedMessage string `json:"detailed_message"`
+	} `json:"errors"`
+	Table struct {
+		Rows []struct {
+			// C holds the row's cells, which are null if empty.
+			C []*struct {
+				V interface{} `json:"v"`
+				// F is the value as it's formatted in the sheet, if it's
+				// formatted.
+				F *string `json:"f"`
+			} `json:"c"`
+		} `json:"rows"`
+	} `json:"table"`
+}
+
+// ReadRows runs the query and returns the values of its rows, as they're
+// formatted in the sheet.
+func (s *gvizSource) ReadRows() ([][]interface{}, error) {
+	v := url.Values{}
+	v.Set("tqx", "out:json")
+	v.Set("tq", s.query)
+	v.Set("sheet", s.sheet)
+	// Every row of the range is data, as it is when read with the API.
+	v.Set("headers", "0")
+	if s.cellRange != "" {
+		v.Set("range", s.cellRange)
+	}
+	u := s.base + url.PathEscape(s.id) + "/gviz/tq?" + v.Encode()
+
+	resp, err := s.client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query failed: %s", resp.Status)
+	}
+	return parseGviz(body)
+}
+
+// parseGviz parses the values of the rows of a visualization API response,
+// which is JSONP: JSON wrapped in a call to
+// google.visualization.Query.setResponse.
+func parseGviz(body []byte) ([][]interface{}, error) {
+	start, end := bytes.IndexByte(body, '('), bytes.LastIndexByte(body, ')')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the response isn't JSONP: %q", truncate(string(body), 100, runeLength))
+	}
+
+	var gr gvizResponse
+	if err := json.Unmarshal(body[start+1:end], &gr); err != nil {
+		return nil, fmt.Errorf("failed to parse the response: %v", err)
+	}
+	if gr.Status == "error" {
+		var msgs []string
+		for _, e := range gr.Errors {
+			msg := e.DetailedMessage
+			if msg == "" {
+				msg = e.Message
+			}
+			msgs = append(msgs, msg)
+		}
+		return nil, fmt.Errorf("query failed: %s", strings.Join(msgs, "; "))
+	}
+
+	rows := make([][]interface{}, len(gr.Table.Rows))
+	for i, r := range gr.Table.Rows {
+		rows[i] = make([]interface{}, len(r.C))
+		for j, c := range r.C {
+			if c == nil {
+				rows[i][j] = ""
+			} else if c.F != nil {
+				rows[i][j] = *c.F
+			} else if f, ok := c.V.(float64); ok {
+				// Unformatted numbers shouldn't be written in exponent form.
+				rows[i][j] = strconv.FormatFloat(f, 'f', -1, 64)
+			} else if c.V != nil {
+				rows[i][j] = fmt.Sprint(c.V)
+			} else {
+				rows[i][j] = ""
+			}
+		}
+	}
+	return rows, nil
+}
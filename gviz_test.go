@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseGviz(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		body    string
+		want    [][]interface{}
+		wantErr bool
+	}{
+		{
+			name: "rows",
+			body: `/*O_o*/
+google.visualization.Query.setResponse({"status":"ok","table":{"rows":[
+{"c":[{"v":"a"},null,{"v":1.5e6},{"v":0.25,"f":"25%"}]},
+{"c":[{"v":true},{"v":null}]}]}});`,
+			want: [][]interface{}{{"a", "", "1500000", "25%"}, {"true", ""}},
+		},
+		{
+			name: "no rows",
+			body: `google.visualization.Query.setResponse({"status":"ok","table":{"rows":[]}});`,
+			want: [][]interface{}{},
+		},
+		{
+			name:    "query error",
+			body:    `google.visualization.Query.setResponse({"status":"error","errors":[{"message":"bad","detailed_message":"Invalid query: NO_COLUMN: Z"}]});`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSONP",
+			body:    `<html>Sign in</html>`,
+			wantErr: true,
+		},
+		{
+			name:    "bad JSON",
+			body:    `setResponse({"status":)`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseGviz([]byte(tc.body))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseGviz() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseGviz() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGvizSourceReadRows(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		if req.URL.Path != "/sheet-id/gviz/tq" || q.Get("tq") != "select A where B = 'x'" || q.Get("sheet") != "Posts" ||
+			q.Get("range") != "A2:C" || q.Get("headers") != "0" || q.Get("tqx") != "out:json" {
+			http.Error(w, "unexpected request "+req.URL.String(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`google.visualization.Query.setResponse({"status":"ok","table":{"rows":[{"c":[{"v":"hi"}]}]}});`))
+	}))
+	defer srv.Close()
+
+	s := newGvizSource(srv.Client(), &sheetsConfig{id: "sheet-id", name: "Posts", cellRange: "A2:C"}, "select A where B = 'x'")
+	s.base = srv.URL + "/"
+	got, err := s.ReadRows()
+	if err != nil {
+		t.Fatalf("ReadRows() failed: %v", err)
+	}
+	if want := [][]interface{}{{"hi"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadRows() = %q, want %q", got, want)
+	}
+}
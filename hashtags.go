@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalizeHashtag turns s into a hashtag by removing spaces and any
+// characters that can't be part of one, and prefixing it with '#'. It
+// returns false if nothing taggable is left, or only digits, which Twitter
+// doesn't link.
+func normalizeHashtag(s string) (string, bool) {
+	var b strings.Builder
+	digits := true
+	for _, r := range strings.TrimLeft(strings.TrimSpace(s), "#") {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			continue
+		}
+		if !unicode.IsDigit(r) {
+			digits = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 || digits {
+		return "", false
+	}
+	return "#" + b.String(), true
+}
+
+// splitHashtags normalizes each of the comma-separated tags in spec,
+// leaving out those that can't be tagged. It also returns the ones left out.
+func splitHashtags(spec string) (tags, invalid []string) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	for _, s := range strings.Split(spec, ",") {
+		if tag, ok := normalizeHashtag(s); ok {
+			tags = append(tags, tag)
+		} else if s = strings.TrimSpace(s); s != "" {
+			invalid = append(invalid, s)
+		}
+	}
+	return tags, invalid
+}
+
+// rowHashtags returns the static --hashtags followed by those in the row's
+// --hashtag_column, without duplicates. Hashtags are compared ignoring case,
+// as Twitter does.
+func rowHashtags(r row, rc *runConfig) []string {
+	tags := rc.hashtags
+	if rc.hashtagColumn >= 0 {
+		dynamic, _ := splitHashtags(r.cell(rc.hashtagColumn))
+		tags = append(append([]string(nil), tags...), dynamic...)
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, tag := range tags {
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, tag)
+	}
+	return unique
+}
+
+// fitHashtags returns the tags, each preceded by a space, that fit within
+// budget, in order. A tag that doesn't fit is dropped, but later, shorter
+// ones may still be included.
+func fitHashtags(tags []string, budget int, length func(string) int) string {
+	var b strings.Builder
+	n := 0
+	for _, tag := range tags {
+		l := length(" " + tag)
+		if n+l > budget {
+			continue
+		}
+		b.WriteString(" " + tag)
+		n += l
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeHashtag(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{in: "golang", want: "#golang", wantOK: true},
+		{in: "#golang", want: "#golang", wantOK: true},
+		{in: "  go lang ", want: "#golang", wantOK: true},
+		{in: "go-lang!", want: "#golang", wantOK: true},
+		{in: "web_dev2", want: "#web_dev2", wantOK: true},
+		{in: "café", want: "#café", wantOK: true},
+		{in: "2024", wantOK: false},
+		{in: "#", wantOK: false},
+		{in: "!?", wantOK: false},
+		{in: "", wantOK: false},
+	} {
+		got, ok := normalizeHashtag(tc.in)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("normalizeHashtag(%q) = %q, %t, want %q, %t", tc.in, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestSplitHashtags(t *testing.T) {
+	for _, tc := range []struct {
+		in          string
+		wantTags    []string
+		wantInvalid []string
+	}{
+		{in: ""},
+		{in: "  "},
+		{in: "go, #rust", wantTags: []string{"#go", "#rust"}},
+		{in: "go,,2024, !", wantTags: []string{"#go"}, wantInvalid: []string{"2024", "!"}},
+	} {
+		tags, invalid := splitHashtags(tc.in)
+		if !reflect.DeepEqual(tags, tc.wantTags) || !reflect.DeepEqual(invalid, tc.wantInvalid) {
+			t.Errorf("splitHashtags(%q) = %q, %q, want %q, %q", tc.in, tags, invalid, tc.wantTags, tc.wantInvalid)
+		}
+	}
+}
+
+func TestRowHashtags(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		static []string
+		column string
+		want   []string
+	}{
+		{name: "static only", static: []string{"#go"}, want: []string{"#go"}},
+		{name: "column appended", static: []string{"#go"}, column: "rust, zig", want: []string{"#go", "#rust", "#zig"}},
+		{name: "duplicates ignoring case", static: []string{"#Go"}, column: "go, GO, rust", want: []string{"#Go", "#rust"}},
+		{name: "empty column", static: []string{"#go"}, column: "", want: []string{"#go"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.hashtags = tc.static
+			rc.hashtagColumn = 1
+			r := row{num: 2, values: []interface{}{"status", tc.column}}
+			if got := rowHashtags(r, rc); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rowHashtags() = %q, want %q", got, tc.want)
+			}
+			if !reflect.DeepEqual(rc.hashtags, tc.static) {
+				t.Errorf("rowHashtags() changed --hashtags to %q", rc.hashtags)
+			}
+		})
+	}
+}
+
+func TestFitHashtags(t *testing.T) {
+	tags := []string{"#longertag", "#go", "#rust"}
+	for _, tc := range []struct {
+		budget int
+		want   string
+	}{
+		{budget: 100, want: " #longertag #go #rust"},
+		{budget: 11, want: " #longertag"},
+		{budget: 10, want: " #go #rust"},
+		{budget: 4, want: " #go"},
+		{budget: 3, want: ""},
+		{budget: 0, want: ""},
+	} {
+		if got := fitHashtags(tags, tc.budget, runeLength); got != tc.want {
+			t.Errorf("fitHashtags(%d) = %q, want %q", tc.budget, got, tc.want)
+		}
+	}
+}
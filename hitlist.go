@@ -13,10 +13,13 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
 
-	"github.com/chimeracoder/anaconda"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	calendar "google.golang.org/api/calendar/v3"
 	sheets "google.golang.org/api/sheets/v4"
 )
 
@@ -26,97 +29,1166 @@ var (
 	spreadsheetIDFlag        = flag.String("sheet_id", "", "the id of the spreadsheet to read")
 	sheetNameFlag            = flag.String("sheet_name", "Sheet1", "the name of the sheet from which to read")
 	readRangeFlag            = flag.String("read_range", "", "the range to read from the sheet (e.g. 'A2:E')")
+	autoRangeFlag            = flag.Bool("auto_range", false, "read every column of the sheet from --start_row on, instead of --read_range")
+	startRowFlag             = flag.Int("start_row", 2, "the first row that --auto_range reads, after any header rows")
+	majorDimensionFlag       = flag.String("major_dimension", dimensionRows, "how the sheet is laid out: 'ROWS', with a row of data in each row, or 'COLUMNS', with one in each column, whose Nth value is then its '{N}'")
+	errorColumnFlag          = flag.String("error_column", "", "the column (e.g. 'G') in which to write why each row failed to post, which is cleared once it's posted")
+	statusColumnFlag         = flag.String("status_column", "", "the column (e.g. 'F') in which tweeted rows are marked complete; rows already marked are skipped")
+	filterViewIDFlag         = flag.Int64("filter_view_id", 0, "if set, the ID of a filter view of the sheet, whose criteria (equals, blank and not blank conditions and hidden values) rows must meet to be posted")
+	overridesRangeFlag       = flag.String("overrides_range", "", "if set, a range (e.g. 'K2:M' or 'Overrides!A2:C') of per-row skip, media URL and reply-to settings, matched to the read range's rows in order")
+	completeValueFlag        = flag.String("complete_value", defaultCompleteValue, "the marker written to the status column of tweeted rows; '{date}', '{user}' and '{tweet_id}' are replaced by the date, the user running hitlist and the ID of the row's tweet")
+	markOnlyColumnFlag       = flag.String("mark_only_column", "", "the column that --mark_only writes completion markers to, in place of --status_column")
+	serviceAccountFileFlag   = flag.String("service_account_file", "", "if set, the path of a service account key file to authorize Sheets access with, instead of --client_secret_file")
+	useADCFlag               = flag.Bool("use_adc", false, "authorize Sheets access with Application Default Credentials, instead of --client_secret_file")
+	accessTypeFlag           = flag.String("access_type", accessOffline, "the access to request when authorizing Sheets in the browser: 'offline' gets a refresh token, which is cached, while 'online' gets a short-lived token that isn't")
+	authCodeFileFlag         = flag.String("auth_code_file", "", "if set, the path of a file holding the authorization code for Sheets access, which is read instead of prompting for it, if the file exists")
+	deviceFlowFlag           = flag.Bool("device_flow", false, "authorize Sheets access by entering a code on another device, for machines without a browser; Google only allows the drive.file scope with it, so the spreadsheet must have been created or opened with the same OAuth client")
+	// Config flags.
+	configFileFlag    = flag.String("config", "", "if set, the path of a JSON file mapping flag names to values, for flags not set on the command line")
+	configKeyFileFlag = flag.String("config_key_file", "", "the path of a file holding the key, as hex or base64, with which --config is encrypted; or set "+configKeyEnv)
+	// Run flags.
+	logFileFlag            = flag.String("log_file", "", "if set, the path of a file to which the log is appended, instead of stderr")
+	syslogFlag             = flag.Bool("syslog", false, "send the log to syslog instead of stderr")
+	userAgentFlag          = flag.String("user_agent", "hitlist/"+version, "the User-Agent header of all HTTP requests")
+	explainFlag            = flag.Bool("explain", false, "log, for each row read, why it was or wasn't posted: left out as complete or filtered, skipped, deferred, posted or failed")
+	dryRunFlag             = flag.Bool("dry_run", false, "print what would be posted, and check that rows could be marked complete in --status_column, without posting or writing anything")
+	onlyRowFlag            = flag.Int("only_row", 0, "if set, post only the Nth row of the read range, counting its rows from 1, to post or repost one known row")
+	forceFlag              = flag.Bool("force", false, "with --only_row, post the row even if it's already marked complete or was recently posted, and mark it again")
+	probeFlag              = flag.Int("probe", 0, "if set, only print this many of the rows read, to check the range and columns, without rendering or posting them")
+	jsonFlag               = flag.Bool("json", false, "print --probe's rows as JSON rather than a table")
+	checkFlag              = flag.Bool("check", false, "only check that the Sheets and backend credentials work, without posting")
+	expectMinFlag          = flag.Int("expect_min", 0, "exit with an error if fewer than this many rows were tweeted, not counting those only marked complete, to catch misconfiguration")
+	tuiFlag                = flag.Bool("tui", false, "review the pending rows in a full-screen terminal UI, choosing to post, skip or edit each in turn")
+	everyFlag              = flag.Duration("every", 0, "if set, keep running, checking for rows to post this often, until interrupted")
+	dailyCharBudgetFlag    = flag.Int("daily_char_budget", 0, "if set, the most characters to post each day, in --timezone, as recorded in --checkpoint_file; rows that would exceed it are left for the next run")
+	dailyFlag              = flag.Bool("daily", false, "do nothing if there was already a successful run today, in --timezone, as recorded in --checkpoint_file")
+	activeHoursFlag        = flag.String("active_hours", "", "if set, the hours (e.g. '09:00-17:00', or '22:00-02:00' across midnight), in --timezone, outside of which runs do nothing")
+	skipDaysFlag           = flag.String("skip_days", "", "a comma-separated list of days of the week (e.g. 'Sat,Sun'), in --timezone, on which runs do nothing")
+	holidaysFileFlag       = flag.String("holidays_file", "", "if set, the path of a file of dates (e.g. '2024-12-25'), one per line, in --timezone, on which runs do nothing")
+	timezoneFlag           = flag.String("timezone", "", "the IANA name (e.g. 'Europe/London') of the timezone of --daily's days and of the dates and times read by --max_age and --modified_column; defaults to the local timezone")
+	startPausedFlag        = flag.Bool("start_paused", false, "with --every, start with scheduled runs paused, until resumed by SIGUSR1, which toggles pausing")
+	serveFlag              = flag.String("serve", "", "if set, the address (e.g. '127.0.0.1:8080') on which to serve a page for reviewing and posting pending rows one at a time; with no host, as in ':8080', it's served on localhost only. Rows posted are no longer listed while it runs, even without --status_column")
+	markOnlyFlag           = flag.Bool("mark_only", false, "skip tweeting, but still mark rows complete in --mark_only_column (to verify sheet write access)")
+	backendFlag            = flag.String("backend", backendTwitter, "where to post: 'twitter', 'bluesky' or 'mastodon'")
+	maxLenFlag             = flag.Int("max_len", 0, "the maximum length of a post; defaults to the backend's limit")
+	recapFlag              = flag.Int("recap", 0, "if set, post a thread recapping the titles and links of the last this many posts in --audit_log, instead of reading the sheet")
+	auditLogFlag           = flag.String("audit_log", "", "if set, the path of a file to which a line is appended for every post")
+	templateFlag           = flag.String("template", "", "the template for each post; '{N}' is replaced by the row's Nth value, counting from 0, and '{sheet}' and '{spreadsheet}' by the name of the sheet the row was read from and its spreadsheet's ID")
+	templateEngineFlag     = flag.String("template_engine", engineSimple, "how --template is rendered: 'simple' replaces '{N}', while 'go' renders it as a Go text/template with the row's values as dot and upper, lower, trim, truncate and default funcs")
+	rawTemplateFlag        = flag.Bool("raw_template", false, "use --template as is, instead of turning the escapes '\\n' and '\\t' into a newline and a tab")
+	jsonColumnsFlag        = flag.String("json_columns", "", "a comma-separated list of columns (e.g. 'D,F') whose values are parsed as JSON for --template_engine=go, so that '{{.col3.name}}', or '{{(index . 3).name}}', gets a field of column D's object")
+	columnsFlag            = flag.String("columns", "", "a comma-separated list of columns (e.g. 'C,A,E') whose values, in that order, are all the template sees, so '{0}' is column C")
+	emptyPlaceholderFlag   = flag.String("empty_placeholder", "", "the text (e.g. 'N/A') that empty cells render as in the template; rows whose cells are all empty are still skipped")
+	columnFormatsFlag      = flag.String("column_formats", "", "formats for the template's values by index, e.g. '2:%.2f,3:2006-01-02' formats {2} as a number and {3} as a date")
+	redactColumnsFlag      = flag.String("redact_columns", "", "a comma-separated list of columns (e.g. 'B,C') whose values are shown as '"+redacted+"' in logs and previews, though they are still posted")
+	joinFlag               = flag.String("join", "", "without --template, post each row's non-empty values joined by this separator")
+	threadFlag             = flag.Bool("thread", false, "post a status too long for a single post as a thread of replies, split between words, instead of truncating it")
+	threadHeaderFlag       = flag.String("thread_header", "", "with --thread, a template for a post to start each row's thread, before its status; '{N}' is replaced by the row's Nth value")
+	numberThreadFlag       = flag.Bool("number_thread", false, "with --thread, end each post of a thread with its number, as '(n/m)'")
+	numberHeaderFlag       = flag.Bool("number_header", false, "with --number_thread, count the --thread_header post in the numbering")
+	collapseDuplicatesFlag = flag.Bool("collapse_duplicates", false, "post a run of consecutive rows with identical statuses once, with a suffix such as '(x3)' counting them, and mark them all complete")
+	asciiPunctuationFlag   = flag.Bool("ascii_punctuation", false, "replace smart quotes, en and em dashes and ellipses in each status with ASCII")
+	digestFlag             = flag.Bool("digest", false, "pack as many rows as fit into each post, joined by --digest_separator, instead of posting one per row")
+	digestSeparatorFlag    = flag.String("digest_separator", `\n`, "the separator between the rows of a --digest post; '\\n' and '\\t' are a newline and a tab")
+	perTweetTimeoutFlag    = flag.Duration("per_tweet_timeout", 0, "if set, how long attaching a row's media and posting it may take before the row is given up on as failed and the next is posted; a post given up on may still be made")
+	transformCmdFlag       = flag.String("transform_cmd", "", "if set, a shell command that is passed each status on stdin and whose stdout is posted instead; a row is skipped if it fails")
+	transformTimeoutFlag   = flag.Duration("transform_timeout", 10*time.Second, "how long --transform_cmd may run for each row")
+	validateFlag           = flag.Bool("validate", false, "check each tweet against Twitter's rules for length, characters, hashtags and mentions, skipping invalid ones instead of posting them")
+	moderationURLFlag      = flag.String("moderation_url", "", "if set, the URL of a hook that is sent each status as JSON and must allow it before it's posted")
+	spreadWindowFlag       = flag.Duration("spread_window", 0, "if set, spread the posts evenly, with jitter, across this long, leaving any rows not posted by its end for the next run")
+	spreadSeedFlag         = flag.Int64("spread_seed", 0, "the seed of the jitter of --spread_window; 0 picks one at random")
+	cwColumnFlag           = flag.String("cw_column", "", "the column (e.g. 'J') holding a content warning to hide each row's post behind; Mastodon only")
+	emptyMessageFlag       = flag.String("empty_message", "", "if set, printed, and sent to --webhook_url, when there's nothing to tweet; '{sheet}' and '{time}' are replaced by the sheet's name and the time")
+	webhookURLFlag         = flag.String("webhook_url", "", "if set, the URL of a chat webhook that is sent --empty_message as JSON")
+	replyToFlag            = flag.String("reply_to", "", "if set, the ID or URL of a post that every post replies to, unless its row's reply-to override says otherwise; leading @mentions then don't count toward a tweet's length")
+	replySettingsFlag      = flag.String("reply_settings", repliesEveryone, "who can reply to the posts: 'everyone', 'following' (the accounts the poster follows) or 'mentioned' (the accounts mentioned); on Mastodon, 'mentioned' makes the posts direct")
+	continueThreadForFlag  = flag.String("continue_thread_for", "", "if set, the screen name of a Twitter account whose latest tweet the posts reply to, each replying to the one before, to continue a running thread")
+	retweetColumnFlag      = flag.String("retweet_column", "", "the column (e.g. 'L') holding the ID or URL of a tweet to retweet for each row, instead of posting its status; rows with it empty are posted as usual")
+	cardURLColumnFlag      = flag.String("card_url_column", "", "the column (e.g. 'M') holding the URL of a link to add to each row's post, which Twitter shows as a card")
+	utmSourceFlag          = flag.String("utm_source", "", "if set, the utm_source query parameter to add to --card_url_column's links")
+	utmMediumFlag          = flag.String("utm_medium", "", "if set, the utm_medium query parameter to add to --card_url_column's links")
+	utmCampaignFlag        = flag.String("utm_campaign", "", "if set, the utm_campaign query parameter to add to --card_url_column's links")
+	quoteColumnFlag        = flag.String("quote_column", "", "the column (e.g. 'G') holding the URL of a tweet for each row's tweet to quote")
+	hashtagsFlag           = flag.String("hashtags", "", "a comma-separated list of hashtags to add to every post, as room allows")
+	hashtagColumnFlag      = flag.String("hashtag_column", "", "the column (e.g. 'H') holding comma-separated hashtags to add to each row's post, after --hashtags")
+	latColumnFlag          = flag.String("lat_column", "", "the column holding the latitude, in decimal degrees, to tag each post with; needs --long_column")
+	longColumnFlag         = flag.String("long_column", "", "the column holding the longitude, in decimal degrees, to tag each post with; needs --lat_column")
+	// Input flags.
+	gvizQueryFlag     = flag.String("gviz_query", "", "if set, read rows by running this query (e.g. 'select A, B where C > 5') on --sheet_id's --sheet_name, which must be public, with Google's visualization API, instead of reading the sheet")
+	inputFileFlag     = flag.String("input_file", "", "if set, read rows from this file, or stdin if '-', instead of the sheet")
+	inputFormatFlag   = flag.String("input_format", formatTSV, "the format of --input_file: 'tsv' or 'csv'")
+	csvDelimiterFlag  = flag.String("csv_delimiter", "", "if set, the character (e.g. ';' or '\\t') separating the values of --input_file, instead of --input_format's")
+	csvLazyQuotesFlag = flag.Bool("csv_lazy_quotes", false, "allow stray quotes in the values of --input_file, as messy files have")
+	// Plan flags.
+	planOutFlag    = flag.String("plan_out", "", "if set, write the posts that would be made to this file for review, instead of posting them")
+	calendarIDFlag = flag.String("calendar_id", "", "if set, the ID of a Google Calendar to which each post scheduled by --plan_out with --spread_window is added as an event; needs the calendar.events scope, so a cached token must be deleted to authorize it")
+	planInFlag     = flag.String("plan_in", "", "if set, post exactly the posts in this file, as written by --plan_out, instead of reading the sheet")
+	// Retry queue flags.
+	retryQueueFileFlag = flag.String("retry_queue_file", "", "if set, the path of a file recording the rows that failed, which are retried first on the next run")
+	maxAttemptsFlag    = flag.Int("max_attempts", 3, "the number of times a row in --retry_queue_file is attempted before it's given up on")
+	// Filter flags.
+	stateFileFlag      = flag.String("state_file", "", "if set, the path of a file recording when each status was posted, so that identical statuses are skipped")
+	dedupeWindowFlag   = flag.Duration("dedupe_window", 0, "if set, only skip statuses in --state_file posted within this long, allowing reposts after it")
+	checkpointFileFlag = flag.String("checkpoint_file", "", "if set, the path of a file recording the last row processed, and those before it that were skipped for now, such as by moderation, so that the next run starts after it and tries those again")
+	modifiedColumnFlag = flag.String("modified_column", "", "the column (e.g. 'I') holding when each row was last edited; with --checkpoint_file, only rows edited since the last successful run are tweeted")
+	dateColumnFlag     = flag.String("date_column", "", "the column (e.g. 'B') holding the date of each row, for --max_age")
+	maxAgeFlag         = flag.Duration("max_age", 0, "if set, skip rows whose --date_column is older than this")
+	markEmptyFlag      = flag.Bool("mark_empty", false, "mark rows whose status renders empty, which are skipped, as complete")
+	markAgedFlag       = flag.Bool("mark_aged", false, "mark rows skipped by --max_age as complete")
+	// Export flags.
+	feedFileFlag     = flag.String("feed_file", "", "if set, the path of an RSS feed file to which each post is added")
+	feedMaxFlag      = flag.Int("feed_max", 50, "the most items kept in --feed_file")
+	exportFileFlag   = flag.String("export_file", "", "if set, the path of a file to which each post is also appended, for cross-posting")
+	exportFormatFlag = flag.String("export_format", "md", "the format of --export_file: 'md' or 'html'")
+	exportOnlyFlag   = flag.Bool("export_only", false, "write --export_file without posting or marking rows complete")
+	// Retry flags.
+	simulateFailureRateFlag  = flag.Float64("simulate_failure_rate", 0, "for testing only, so hidden: the fraction, from 0 to 1, of posts to fail at random without posting; each attempt of a failed post fails, so it's retried and then given up on")
+	simulateSeedFlag         = flag.Int64("simulate_seed", 0, "for testing only, so hidden: the seed of --simulate_failure_rate; 0 picks one at random")
+	retryBaseFlag            = flag.Duration("retry_base", time.Second, "the delay before the first retry of a failed request")
+	retryMaxFlag             = flag.Duration("retry_max", 30*time.Second, "the maximum delay between retries")
+	retryableStatusCodesFlag = flag.String("retryable_status_codes", "429,500,502,503,504", "a comma-separated list of the HTTP statuses from Sheets, Twitter and token endpoints that are retried; tweets and token exchanges, which mustn't be made twice, are only retried on 429 and 503, which show they weren't")
+	retryFactorFlag          = flag.Float64("retry_factor", 2, "the factor by which the delay grows after each retry")
+	// Media flags.
+	mediaColumnFlag              = flag.String("media_column", "", "the column (e.g. 'D') holding the URL of an image to attach to each post")
+	mediaColumnsFlag             = flag.String("media_columns", "", "a comma-separated list of columns (e.g. 'D,E') holding the URLs of up to 4 images, or one GIF or video, to attach to each post")
+	mediaURLFlag                 = flag.String("media_url", "", "a template for the URL of an image to attach to each post, such as '{2}/thumb.jpg', where '{N}' is replaced by the row's Nth value")
+	altTextFlag                  = flag.String("alt_text", "", "a template for the alt text of each post's media that has none from --alt_columns, where '{N}' is replaced by the row's Nth value")
+	qrURLColumnFlag              = flag.String("qr_url_column", "", "the column (e.g. 'M') holding a URL of which a QR code image is attached to each post")
+	altColumnsFlag               = flag.String("alt_columns", "", "a comma-separated list of columns holding the alt text of the media in each media column, in the same order")
+	describeMediaFlag            = flag.Bool("describe_media", false, "after each post, reply with the alt text of each of its images, for screen readers")
+	onMediaErrorFlag             = flag.String("on_media_error", mediaErrorTextOnly, "what to do with a row whose media can't be downloaded or uploaded: 'skip' it for a later run, post it 'text-only', or 'fail' it")
+	mediaDownloadConcurrencyFlag = flag.Int("media_download_concurrency", 1, "how many media files may be downloaded at once; above 1, the media of upcoming rows is downloaded in the background while earlier rows are posted")
+	requireMediaFlag             = flag.Bool("require_media", false, "the same as --on_media_error=fail")
 	// Twitter flags.
-	consumerKeyFlag    = flag.String("twitter_consumer_key", "", "the consumer key for the Twitter account")
-	consumerSecretFlag = flag.String("twitter_consumer_secret", "", "the consumer secret for the Twitter account")
-	accessTokenFlag    = flag.String("twitter_access_token", "", "the access token for the Twitter account")
-	accessSecretFlag   = flag.String("twitter_access_secret", "", "the access token secret for the Twitter account")
+	accountsFileFlag      = flag.String("accounts_file", "", "if set, the path of a JSON list of Twitter accounts, each with a name, access_token and access_secret, to spread the posts across, as --account_strategy says; they post through the app of --twitter_consumer_key")
+	accountStrategyFlag   = flag.String("account_strategy", accountsPerRowColumn, "how the account of --accounts_file that posts each row is picked: 'per-row-column' takes the one named in --account_column, or the first if it's empty, while 'round-robin' takes each in turn and 'random' one at random")
+	accountColumnFlag     = flag.String("account_column", "", "the column (e.g. 'N') holding the name of the account of --accounts_file to post each row from, for --account_strategy=per-row-column")
+	accountSeedFlag       = flag.Int64("account_seed", 0, "the seed of --account_strategy=random, to make its picks reproducible; 0 picks one at random")
+	consumerKeyFlag       = flag.String("twitter_consumer_key", "", "the consumer key for the Twitter account")
+	consumerSecretFlag    = flag.String("twitter_consumer_secret", "", "the consumer secret for the Twitter account")
+	accessTokenFlag       = flag.String("twitter_access_token", "", "the access token for the Twitter account")
+	accessSecretFlag      = flag.String("twitter_access_secret", "", "the access token secret for the Twitter account")
+	twitterSecretFileFlag = flag.String("twitter_secret_file", "", "if set, the path of a JSON file holding all four Twitter credentials, as consumer_key, consumer_secret, access_token and access_secret; the flags take precedence over it, and it over the environment")
+	useKeyringFlag        = flag.Bool("use_keyring", false, "read the Twitter credentials from the system keyring, falling back to the flags and then the environment")
+	keyringServiceFlag    = flag.String("keyring_service", "hitlist", "the keyring service under which the Twitter credentials are stored")
+	twitterAPIBaseFlag    = flag.String("twitter_api_base", "", "if set, the base URL of the Twitter API (e.g. a local mock server's), instead of the real one")
+	videoTimeoutFlag      = flag.Duration("video_timeout", 5*time.Minute, "how long to wait for Twitter to process an uploaded video before giving up on it")
+	// Bluesky flags.
+	blueskyHandleFlag      = flag.String("bluesky_handle", "", "the handle of the Bluesky account (e.g. 'me.bsky.social')")
+	blueskyAppPasswordFlag = flag.String("bluesky_app_password", "", "an app password for the Bluesky account")
+	blueskyPDSFlag         = flag.String("bluesky_pds", "https://bsky.social", "the base URL of the Bluesky account's PDS")
+	// Mastodon flags.
+	mastodonServerFlag      = flag.String("mastodon_server", "", "the base URL of the Mastodon account's server (e.g. 'https://mastodon.social')")
+	mastodonRegisterFlag    = flag.Bool("mastodon_register", false, "register hitlist as an app, named --app_name, on --mastodon_server, and authorize it to get an access token for --mastodon_access_token")
+	appNameFlag             = flag.String("app_name", "hitlist", "the name of the app registered by --mastodon_register, which Mastodon shows as the source of its posts")
+	mastodonAccessTokenFlag = flag.String("mastodon_access_token", "", "an access token for the Mastodon account, with the write:statuses and write:media scopes")
 )
 
+// sourceFlags is repeatable, so it's registered in init.
+var sourceFlags sourcesFlag
+
+func init() {
+	flag.Var(&sourceFlags, "source", "a source of rows of the form 'spreadsheetID:sheetName!range', instead of --sheet_id, --sheet_name and --read_range; may be repeated to read from several spreadsheets")
+}
+
 type sheetsConfig struct {
 	secretPath, id, name, cellRange string
+	statusColumn, markOnlyColumn    string
+	overridesRange                  string
+	errorColumn                     string
+	majorDimension                  string
+	sources                         []*sheetRange // nil unless --source is set.
+	autoRange                       bool
+	startRow                        int
+	serviceAccountFile              string
+	useADC                          bool
+	deviceFlow                      bool
+	authCodeFile                    string
+	calendarID                      string
+	filterViewID                    int64
+	accessType                      string
 }
 
 type twitterConfig struct {
 	consumerKey, consumerSecret string
 	accessToken, accessSecret   string
+	// apiBase, if set, replaces the Twitter API's base URL, and that of its
+	// video uploads.
+	apiBase string
+	// videoTimeout is how long to wait for an uploaded video to be
+	// processed.
+	videoTimeout time.Duration
+}
+
+type blueskyConfig struct {
+	handle, appPassword, pds string
+}
+
+type mastodonConfig struct {
+	server, accessToken string
+	appName             string // for --mastodon_register.
+	maxChars            int    // the server's limit, if known.
+}
+
+type runConfig struct {
+	check               bool
+	mastodonRegister    bool
+	tui                 bool
+	serveAddr           string
+	every               time.Duration
+	startPaused         bool
+	daily               bool
+	probe               int
+	dryRun              bool
+	probeJSON           bool
+	thread              bool
+	replySettings       string
+	asciiPunctuation    bool
+	collapseDuplicates  bool
+	perTweetTimeout     time.Duration
+	threadHeader        string
+	numberThread        bool
+	numberHeader        bool
+	location            *time.Location
+	dailyCharBudget     int
+	skipDays            map[time.Weekday]bool
+	activeHours         *hoursWindow    // nil if unset.
+	holidays            map[string]bool // dates in dayFormat.
+	expectMin           int
+	markOnly            bool
+	mediaColumns        []int
+	onMediaError        string
+	mediaConcurrency    int
+	altColumns          []int
+	jsonColumns         []int
+	mediaURLTemplate    string
+	altTextTemplate     string
+	describeMedia       bool
+	latColumn           int // -1 if unset.
+	longColumn          int // -1 if unset.
+	auditLogPath        string
+	accountsFile        string
+	accountStrategy     string
+	accountColumn       int // -1 if unset.
+	accountSeed         int64
+	recap               int
+	template            string
+	goTemplate          *template.Template // nil unless --template_engine=go.
+	columns             []int              // nil unless --columns is set.
+	join                string
+	completeValue       string
+	emptyPlaceholder    string
+	columnFormats       map[int]string
+	redactColumns       map[int]bool
+	quoteColumn         int // -1 if unset.
+	cardURLColumn       int // -1 if unset.
+	utm                 url.Values
+	retweetColumn       int // -1 if unset.
+	qrURLColumn         int // -1 if unset.
+	continueThreadFor   string
+	cwColumn            int // -1 if unset.
+	hashtags            []string
+	hashtagColumn       int // -1 if unset.
+	digest              bool
+	digestSeparator     string
+	transformCmd        string
+	transformTimeout    time.Duration
+	validate            bool
+	moderationURL       string
+	emptyMessage        string
+	webhookURL          string
+	spreadWindow        time.Duration
+	spreadSeed          int64
+	modifiedColumn      int // -1 if unset.
+	dateColumn          int // -1 if unset.
+	maxAge              time.Duration
+	inputFile           string
+	gvizQuery           string
+	inputFormat         string
+	csvDelimiter        string
+	csvLazyQuotes       bool
+	planOut             string
+	planIn              string
+	onlyRow             int // 0 if unset.
+	explain             bool
+	force               bool
+	retryQueueFile      string
+	simulateFailureRate float64
+	simulateSeed        int64
+	maxAttempts         int
+	checkpointFile      string
+	stateFile           string
+	dedupeWindow        time.Duration
+	markAged            bool
+	markEmpty           bool
+	feedFile            string
+	feedMax             int
+	exportFile          string
+	exportFormat        string
+	exportOnly          bool
+}
+
+// row is a single row of sheet data along with its 1-based row number and
+// the 0-based index of the sheet column its values start at.
+type row struct {
+	num, firstCol int
+	values        []interface{}
+	overrides     Overrides
+	sheet         *sheetRange // where the row was read from; nil for --input_file.
+	postID        string      // the ID of the row's post, once it's tweeted.
+	edited        string      // if set, the status to post in place of the rendered one.
+}
+
+// cell returns the value of the row in the 0-based sheet column col,
+// or "" if the row has no such value.
+func (r row) cell(col int) string {
+	i := col - r.firstCol
+	if i < 0 || i >= len(r.values) {
+		return ""
+	}
+	return fmt.Sprint(r.values[i])
 }
 
 // This code is inspired by the guide here:
 // https://developers.google.com/sheets/api/quickstart/go
 
+// hiddenFlags are the flags, for testing only, left out of the usage
+// message.
+var hiddenFlags = map[string]bool{
+	"simulate_failure_rate": true,
+	"simulate_seed":         true,
+}
+
+// usage prints the usage message, without hiddenFlags.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	visible.PrintDefaults()
+}
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
+	if *configFileFlag != "" {
+		key := os.Getenv(configKeyEnv)
+		if *configKeyFileFlag != "" {
+			b, err := ioutil.ReadFile(*configKeyFileFlag)
+			if err != nil {
+				log.Fatalf("failed to read --config_key_file: %v", err)
+			}
+			key = string(b)
+		}
+		data, err := readConfig(*configFileFlag, key)
+		if err != nil {
+			log.Fatalf("failed to read --config: %v", err)
+		}
+		if err := applyConfig(flag.CommandLine, data); err != nil {
+			log.Fatalf("bad --config: %v", err)
+		}
+	}
+
+	if err := setupLogging(*logFileFlag, *syslogFlag); err != nil {
+		log.Fatalf("failed to set up logging: %v", err)
+	}
+
+	setUserAgent(*userAgentFlag)
+
+	retryBackoff.base = *retryBaseFlag
+	retryBackoff.max = *retryMaxFlag
+	retryBackoff.factor = *retryFactorFlag
+	if err := retryBackoff.validate(); err != nil {
+		log.Fatalf("bad retry flags: %v", err)
+	}
+	codes, err := parseStatusCodes(*retryableStatusCodesFlag)
+	if err != nil {
+		log.Fatalf("bad --retryable_status_codes: %v", err)
+	}
+	retryableStatusCodes = codes
+
 	sc := &sheetsConfig{
-		secretPath: *clientSecretFilePathFlag,
-		id:         *spreadsheetIDFlag,
-		name:       *sheetNameFlag,
-		cellRange:  *readRangeFlag,
+		secretPath:         *clientSecretFilePathFlag,
+		id:                 *spreadsheetIDFlag,
+		name:               *sheetNameFlag,
+		cellRange:          *readRangeFlag,
+		autoRange:          *autoRangeFlag,
+		startRow:           *startRowFlag,
+		statusColumn:       *statusColumnFlag,
+		markOnlyColumn:     *markOnlyColumnFlag,
+		overridesRange:     *overridesRangeFlag,
+		errorColumn:        *errorColumnFlag,
+		majorDimension:     strings.ToUpper(*majorDimensionFlag),
+		sources:            sourceFlags,
+		serviceAccountFile: *serviceAccountFileFlag,
+		useADC:             *useADCFlag,
+		deviceFlow:         *deviceFlowFlag,
+		authCodeFile:       *authCodeFileFlag,
+		calendarID:         *calendarIDFlag,
+		filterViewID:       *filterViewIDFlag,
+		accessType:         *accessTypeFlag,
 	}
 
-	tc := &twitterConfig{
+	tc := &twitterConfig{}
+	if *useKeyringFlag {
+		ktc, err := twitterConfigFromKeyring(*keyringServiceFlag)
+		if err != nil {
+			log.Fatalf("failed to read Twitter credentials from the keyring: %v", err)
+		}
+		tc.merge(ktc)
+	}
+	tc.merge(&twitterConfig{
 		consumerKey:    *consumerKeyFlag,
 		consumerSecret: *consumerSecretFlag,
 		accessToken:    *accessTokenFlag,
 		accessSecret:   *accessSecretFlag,
+	})
+	if *twitterSecretFileFlag != "" {
+		ftc, err := loadTwitterSecretFile(*twitterSecretFileFlag)
+		if err != nil {
+			log.Fatalf("failed to read --twitter_secret_file: %v", err)
+		}
+		tc.merge(ftc)
+	}
+	tc.merge(twitterConfigFromEnv())
+	tc.apiBase = *twitterAPIBaseFlag
+	tc.videoTimeout = *videoTimeoutFlag
+	if tc.videoTimeout <= 0 {
+		log.Fatalf("--video_timeout must be positive")
 	}
 
-	if err := doMain(sc, tc); err != nil {
-		log.Fatal(err)
+	bc := &backendConfig{
+		name:    *backendFlag,
+		maxLen:  *maxLenFlag,
+		replyTo: *replyToFlag,
+		twitter: tc,
+		bluesky: &blueskyConfig{
+			handle:      *blueskyHandleFlag,
+			appPassword: *blueskyAppPasswordFlag,
+			pds:         *blueskyPDSFlag,
+		},
+		mastodon: &mastodonConfig{
+			server:      *mastodonServerFlag,
+			accessToken: *mastodonAccessTokenFlag,
+			appName:     *appNameFlag,
+		},
 	}
-}
 
-const permScope = "https://www.googleapis.com/auth/spreadsheets.readonly"
+	mediaColumns, err := parseColumnList(*mediaColumnsFlag)
+	if err != nil {
+		log.Fatalf("bad --media_columns: %v", err)
+	}
+	if *mediaColumnFlag != "" {
+		col, err := columnIndex(*mediaColumnFlag)
+		if err != nil {
+			log.Fatalf("bad --media_column: %v", err)
+		}
+		mediaColumns = append([]int{col}, mediaColumns...)
+	}
 
-func doMain(sc *sheetsConfig, tc *twitterConfig) error {
-	ctx := context.Background()
-	secretContent, err := ioutil.ReadFile(sc.secretPath)
+	latColumn, err := optionalColumn(*latColumnFlag)
 	if err != nil {
-		return fmt.Errorf("failed to read client secret file: %v", err)
+		log.Fatalf("bad --lat_column: %v", err)
+	}
+	longColumn, err := optionalColumn(*longColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --long_column: %v", err)
+	}
+	if (latColumn < 0) != (longColumn < 0) {
+		log.Fatalf("--lat_column and --long_column must be set together")
 	}
 
-	config, err := google.ConfigFromJSON(secretContent, permScope)
+	cwColumn, err := optionalColumn(*cwColumnFlag)
 	if err != nil {
-		return fmt.Errorf("failed to create config from secret file at %q: %v", sc.secretPath, err)
+		log.Fatalf("bad --cw_column: %v", err)
 	}
 
-	client, err := getClient(ctx, config)
+	onMediaError := *onMediaErrorFlag
+	if *requireMediaFlag {
+		onMediaError = mediaErrorFail
+	}
+	switch onMediaError {
+	case mediaErrorSkip, mediaErrorTextOnly, mediaErrorFail:
+	default:
+		log.Fatalf("unknown --on_media_error %q", onMediaError)
+	}
+
+	altColumns, err := parseColumnList(*altColumnsFlag)
 	if err != nil {
-		return fmt.Errorf("failed to get client for Sheets: %v", err)
+		log.Fatalf("bad --alt_columns: %v", err)
+	}
+	if len(altColumns) > len(mediaColumns) {
+		log.Fatalf("--alt_columns has more columns than there are media columns")
 	}
 
-	srv, err := sheets.New(client)
+	accountColumn, err := optionalColumn(*accountColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --account_column: %v", err)
+	}
+	cardURLColumn, err := optionalColumn(*cardURLColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --card_url_column: %v", err)
+	}
+	quoteColumn, err := optionalColumn(*quoteColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --quote_column: %v", err)
+	}
+	retweetColumn, err := optionalColumn(*retweetColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --retweet_column: %v", err)
+	}
+	qrURLColumn, err := optionalColumn(*qrURLColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --qr_url_column: %v", err)
+	}
+	jsonColumns, err := parseColumnList(*jsonColumnsFlag)
+	if err != nil {
+		log.Fatalf("bad --json_columns: %v", err)
+	}
+
+	hashtags, invalid := splitHashtags(*hashtagsFlag)
+	if len(invalid) > 0 {
+		log.Fatalf("bad --hashtags: can't tag %q", invalid)
+	}
+	hashtagColumn, err := optionalColumn(*hashtagColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --hashtag_column: %v", err)
+	}
+
+	modifiedColumn, err := optionalColumn(*modifiedColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --modified_column: %v", err)
+	}
+
+	dateColumn, err := optionalColumn(*dateColumnFlag)
+	if err != nil {
+		log.Fatalf("bad --date_column: %v", err)
+	}
+
+	tmpl := *templateFlag
+	if !*rawTemplateFlag {
+		tmpl = unescapeTemplate(tmpl)
+	}
+
+	columns, err := parseColumnList(*columnsFlag)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve client for Sheets: %v", err)
+		log.Fatalf("bad --columns: %v", err)
+	}
+
+	redactList, err := parseColumnList(*redactColumnsFlag)
+	if err != nil {
+		log.Fatalf("bad --redact_columns: %v", err)
+	}
+	redactColumns := make(map[int]bool)
+	for _, col := range redactList {
+		redactColumns[col] = true
 	}
 
-	r := fmt.Sprintf("%s!%s", sc.name, sc.cellRange)
-	resp, err := srv.Spreadsheets.Values.Get(sc.id, r).Do()
+	columnFormats, err := parseColumnFormats(*columnFormatsFlag)
 	if err != nil {
-		return fmt.Errorf("failed to read sheet with id=%q and range=%q: %v", sc.id, r, err)
+		log.Fatalf("bad --column_formats: %v", err)
+	}
+
+	if err := validateCompleteValue(*completeValueFlag); err != nil {
+		log.Fatalf("bad --complete_value: %v", err)
+	}
+
+	var goTemplate *template.Template
+	switch *templateEngineFlag {
+	case engineSimple:
+	case engineGo:
+		if tmpl != "" {
+			if goTemplate, err = parseGoTemplate(tmpl); err != nil {
+				log.Fatalf("bad --template: %v", err)
+			}
+		}
+	default:
+		log.Fatalf("unknown --template_engine %q", *templateEngineFlag)
+	}
+	if len(jsonColumns) > 0 && goTemplate == nil {
+		log.Fatalf("--json_columns needs a --template with --template_engine=go")
+	}
+
+	accountSeed := *accountSeedFlag
+	if accountSeed == 0 {
+		accountSeed = time.Now().UnixNano()
+	}
+
+	simulateSeed := *simulateSeedFlag
+	if simulateSeed == 0 {
+		simulateSeed = time.Now().UnixNano()
+	}
+
+	skipDays, err := parseSkipDays(*skipDaysFlag)
+	if err != nil {
+		log.Fatalf("bad --skip_days: %v", err)
+	}
+	var activeHours *hoursWindow
+	if *activeHoursFlag != "" {
+		if activeHours, err = parseActiveHours(*activeHoursFlag); err != nil {
+			log.Fatalf("bad --active_hours: %v", err)
+		}
+	}
+	var holidays map[string]bool
+	if *holidaysFileFlag != "" {
+		if holidays, err = loadHolidays(*holidaysFileFlag); err != nil {
+			log.Fatalf("bad --holidays_file: %v", err)
+		}
+	}
+
+	location := time.Local
+	if *timezoneFlag != "" {
+		var err error
+		if location, err = time.LoadLocation(*timezoneFlag); err != nil {
+			log.Fatalf("bad --timezone: %v", err)
+		}
+	}
+
+	spreadSeed := *spreadSeedFlag
+	if spreadSeed == 0 {
+		spreadSeed = time.Now().UnixNano()
+	}
+
+	rc := &runConfig{
+		check:               *checkFlag,
+		mastodonRegister:    *mastodonRegisterFlag,
+		tui:                 *tuiFlag,
+		serveAddr:           *serveFlag,
+		every:               *everyFlag,
+		startPaused:         *startPausedFlag,
+		daily:               *dailyFlag,
+		probe:               *probeFlag,
+		dryRun:              *dryRunFlag,
+		probeJSON:           *jsonFlag,
+		thread:              *threadFlag,
+		replySettings:       *replySettingsFlag,
+		asciiPunctuation:    *asciiPunctuationFlag,
+		collapseDuplicates:  *collapseDuplicatesFlag,
+		perTweetTimeout:     *perTweetTimeoutFlag,
+		threadHeader:        unescapeTemplate(*threadHeaderFlag),
+		numberThread:        *numberThreadFlag,
+		numberHeader:        *numberHeaderFlag,
+		location:            location,
+		dailyCharBudget:     *dailyCharBudgetFlag,
+		skipDays:            skipDays,
+		activeHours:         activeHours,
+		holidays:            holidays,
+		expectMin:           *expectMinFlag,
+		markOnly:            *markOnlyFlag,
+		mediaColumns:        mediaColumns,
+		onMediaError:        onMediaError,
+		mediaConcurrency:    *mediaDownloadConcurrencyFlag,
+		altColumns:          altColumns,
+		jsonColumns:         jsonColumns,
+		mediaURLTemplate:    *mediaURLFlag,
+		altTextTemplate:     unescapeTemplate(*altTextFlag),
+		describeMedia:       *describeMediaFlag,
+		latColumn:           latColumn,
+		longColumn:          longColumn,
+		auditLogPath:        *auditLogFlag,
+		accountsFile:        *accountsFileFlag,
+		accountStrategy:     *accountStrategyFlag,
+		accountColumn:       accountColumn,
+		accountSeed:         accountSeed,
+		recap:               *recapFlag,
+		template:            tmpl,
+		goTemplate:          goTemplate,
+		columns:             columns,
+		join:                *joinFlag,
+		completeValue:       *completeValueFlag,
+		emptyPlaceholder:    *emptyPlaceholderFlag,
+		columnFormats:       columnFormats,
+		redactColumns:       redactColumns,
+		quoteColumn:         quoteColumn,
+		cardURLColumn:       cardURLColumn,
+		utm:                 utmParams(*utmSourceFlag, *utmMediumFlag, *utmCampaignFlag),
+		retweetColumn:       retweetColumn,
+		qrURLColumn:         qrURLColumn,
+		continueThreadFor:   strings.TrimPrefix(*continueThreadForFlag, "@"),
+		cwColumn:            cwColumn,
+		hashtags:            hashtags,
+		hashtagColumn:       hashtagColumn,
+		digest:              *digestFlag,
+		digestSeparator:     unescapeTemplate(*digestSeparatorFlag),
+		transformCmd:        *transformCmdFlag,
+		transformTimeout:    *transformTimeoutFlag,
+		validate:            *validateFlag,
+		moderationURL:       *moderationURLFlag,
+		emptyMessage:        *emptyMessageFlag,
+		webhookURL:          *webhookURLFlag,
+		spreadWindow:        *spreadWindowFlag,
+		spreadSeed:          spreadSeed,
+		modifiedColumn:      modifiedColumn,
+		dateColumn:          dateColumn,
+		maxAge:              *maxAgeFlag,
+		inputFile:           *inputFileFlag,
+		gvizQuery:           *gvizQueryFlag,
+		inputFormat:         *inputFormatFlag,
+		csvDelimiter:        unescapeTemplate(*csvDelimiterFlag),
+		csvLazyQuotes:       *csvLazyQuotesFlag,
+		planOut:             *planOutFlag,
+		planIn:              *planInFlag,
+		onlyRow:             *onlyRowFlag,
+		explain:             *explainFlag,
+		force:               *forceFlag,
+		retryQueueFile:      *retryQueueFileFlag,
+		simulateFailureRate: *simulateFailureRateFlag,
+		simulateSeed:        simulateSeed,
+		maxAttempts:         *maxAttemptsFlag,
+		checkpointFile:      *checkpointFileFlag,
+		stateFile:           *stateFileFlag,
+		dedupeWindow:        *dedupeWindowFlag,
+		markAged:            *markAgedFlag,
+		markEmpty:           *markEmptyFlag,
+		feedFile:            *feedFileFlag,
+		feedMax:             *feedMaxFlag,
+		exportFile:          *exportFileFlag,
+		exportFormat:        *exportFormatFlag,
+		exportOnly:          *exportOnlyFlag,
+	}
+
+	if err := doMain(sc, bc, rc); err != nil {
+		log.Print(err)
+		if hint := explainSheetsError(err); hint != "" {
+			log.Print(hint)
+		}
+		os.Exit(exitCode(err))
 	}
+}
 
-	if len(resp.Values) < 1 {
-		return errors.New("no data found from spreadsheet")
+// openSheet creates the Sheets service and returns the ranges to read:
+// those of --source or else the read range, which --auto_range detects.
+func openSheet(ctx context.Context, sc *sheetsConfig, rc *runConfig) (*sheets.Service, []*sheetRange, error) {
+	switch {
+	case len(sc.sources) > 0:
+		if sc.cellRange != "" || sc.autoRange {
+			return nil, nil, fmt.Errorf("%w: --source can't be used with --read_range or --auto_range", ErrConfig)
+		}
+		if len(sc.sources) > 1 && (sc.overridesRange != "" || rc.checkpointFile != "" || rc.retryQueueFile != "" || rc.planIn != "" || rc.planOut != "") {
+			return nil, nil, fmt.Errorf("%w: --overrides_range, --checkpoint_file, --retry_queue_file and plans track rows by number, so can't be used with several sources", ErrConfig)
+		}
+		if len(sc.sources) > 1 && (rc.onlyRow > 0 || sc.errorColumn != "") {
+			return nil, nil, fmt.Errorf("%w: --only_row and --error_column can't be used with several sources", ErrConfig)
+		}
+	case sc.autoRange:
+		if sc.cellRange != "" {
+			return nil, nil, fmt.Errorf("%w: --auto_range and --read_range are mutually exclusive", ErrConfig)
+		}
+		if sc.startRow < 1 {
+			return nil, nil, fmt.Errorf("%w: --start_row must be at least 1", ErrConfig)
+		}
+	default:
+		if _, err := parseA1Range(sc.cellRange); err != nil {
+			return nil, nil, fmt.Errorf("%w: failed to parse read range: %w", ErrConfig, err)
+		}
 	}
 
-	anaconda.SetConsumerKey(tc.consumerKey)
-	anaconda.SetConsumerKey(tc.consumerSecret)
-	api := anaconda.NewTwitterApi(tc.accessToken, tc.accessSecret)
+	srv, err := newSheetsService(ctx, sc)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if err := tweet(api, resp.Values); err != nil {
-		return fmt.Errorf("failed to tweet: %v", err)
+	ranges := sc.sources
+	if ranges == nil {
+		if sc.autoRange {
+			if sc.cellRange, err = detectRange(srv, sc); err != nil {
+				return nil, nil, err
+			}
+			log.Printf("reading the detected range %q", sc.cellRange)
+		}
+		rng, err := parseA1Range(sc.cellRange)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: failed to parse read range: %w", ErrConfig, err)
+		}
+		ranges = []*sheetRange{{id: sc.id, name: sc.name, cellRange: sc.cellRange, rng: rng}}
 	}
 
-	if err := markComplete(); err != nil {
-		return fmt.Errorf("failed to mark Tweeted data as complete: %v", err)
+	for _, sr := range ranges {
+		for _, col := range rc.columns {
+			if col < sr.rng.startCol {
+				return nil, nil, fmt.Errorf("%w: --columns includes column %s, which is before the read range %q", ErrConfig, columnLetters(col), sr.cellRange)
+			}
+		}
 	}
 
-	return nil
+	if sc.filterViewID != 0 {
+		if len(ranges) > 1 {
+			return nil, nil, fmt.Errorf("%w: --filter_view_id can't be used with several sources", ErrConfig)
+		}
+		if ranges[0].view, err = loadFilterView(srv, ranges[0].id, sc.filterViewID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return srv, ranges, nil
 }
 
-func getClient(ctx context.Context, config *oauth2.Config) (*http.Client, error) {
+// Write access is needed to mark rows complete.
+const permScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// driveFileScope reads and writes only the files that the user created or
+// opened with this client. Unlike permScope, Google allows it with the
+// device flow.
+const driveFileScope = "https://www.googleapis.com/auth/drive.file"
+
+func doMain(sc *sheetsConfig, bc *backendConfig, rc *runConfig) error {
+	ctx := context.Background()
+
+	if sc.accessType != accessOnline && sc.accessType != accessOffline {
+		return fmt.Errorf("%w: unknown --access_type %q", ErrConfig, sc.accessType)
+	}
+	if sc.deviceFlow {
+		if err := checkDeviceScopes(authScopes(sc)); err != nil {
+			return err
+		}
+	}
+
+	if rc.mastodonRegister {
+		if bc.mastodon.server == "" {
+			return fmt.Errorf("%w: --mastodon_register requires --mastodon_server", ErrConfig)
+		}
+		return registerMastodonApp(ctx, bc.mastodon, http.DefaultClient, os.Stdin, os.Stdout)
+	}
+
+	if rc.check {
+		return checkCredentials(ctx, sc, bc)
+	}
+
+	statusColumn := sc.statusColumn
+	if rc.markOnly {
+		if sc.markOnlyColumn == "" {
+			return fmt.Errorf("%w: --mark_only requires --mark_only_column", ErrConfig)
+		}
+		statusColumn = sc.markOnlyColumn
+	}
+
+	var exportRender func(string) string
+	if rc.exportFile != "" {
+		var ok bool
+		if exportRender, ok = exportRenderers[rc.exportFormat]; !ok {
+			return fmt.Errorf("%w: unknown export format %q", ErrConfig, rc.exportFormat)
+		}
+	} else if rc.exportOnly {
+		return fmt.Errorf("%w: --export_only requires --export_file", ErrConfig)
+	}
+
+	if rc.mediaConcurrency < 1 {
+		return fmt.Errorf("%w: --media_download_concurrency must be at least 1", ErrConfig)
+	}
+	if rc.modifiedColumn >= 0 && rc.checkpointFile == "" {
+		return fmt.Errorf("%w: --modified_column requires --checkpoint_file", ErrConfig)
+	}
+	if rc.maxAge > 0 && rc.dateColumn < 0 {
+		return fmt.Errorf("%w: --max_age requires --date_column", ErrConfig)
+	}
+	if rc.planIn != "" && rc.planOut != "" {
+		return fmt.Errorf("%w: --plan_in and --plan_out are mutually exclusive", ErrConfig)
+	}
+	if sc.calendarID != "" && (rc.planOut == "" || rc.spreadWindow == 0) {
+		return fmt.Errorf("%w: --calendar_id requires --plan_out and --spread_window", ErrConfig)
+	}
+	if rc.tui && rc.inputFile == "-" {
+		return fmt.Errorf("%w: --tui reads from stdin, so rows can't be read from it too", ErrConfig)
+	}
+	if rc.onlyRow < 0 {
+		return fmt.Errorf("%w: --only_row must not be negative", ErrConfig)
+	}
+	if rc.onlyRow > 0 && (rc.every > 0 || rc.planIn != "") {
+		return fmt.Errorf("%w: --only_row can't be used with --every or --plan_in", ErrConfig)
+	}
+	if rc.force && rc.onlyRow == 0 {
+		return fmt.Errorf("%w: --force requires --only_row", ErrConfig)
+	}
+	if rc.probe < 0 {
+		return fmt.Errorf("%w: --probe must not be negative", ErrConfig)
+	}
+	if rc.probeJSON && rc.probe == 0 {
+		return fmt.Errorf("%w: --json requires --probe", ErrConfig)
+	}
+	if rc.perTweetTimeout < 0 {
+		return fmt.Errorf("%w: --per_tweet_timeout must not be negative", ErrConfig)
+	}
+	if rc.every < 0 {
+		return fmt.Errorf("%w: --every must not be negative", ErrConfig)
+	}
+	if rc.every > 0 && (rc.serveAddr != "" || rc.tui || rc.planIn != "") {
+		return fmt.Errorf("%w: --every can't be used with --serve, --tui or --plan_in", ErrConfig)
+	}
+	switch sc.majorDimension {
+	case dimensionRows:
+	case dimensionColumns:
+		if statusColumn != "" || sc.overridesRange != "" || sc.errorColumn != "" || sc.filterViewID != 0 {
+			return fmt.Errorf("%w: --status_column, --mark_only_column, --overrides_range, --error_column and --filter_view_id work on rows, so can't be used with --major_dimension=COLUMNS", ErrConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown --major_dimension %q", ErrConfig, sc.majorDimension)
+	}
+	if rc.recap < 0 {
+		return fmt.Errorf("%w: --recap must not be negative", ErrConfig)
+	}
+	if rc.recap > 0 && (rc.auditLogPath == "" || rc.inputFile != "" || rc.gvizQuery != "" || rc.planIn != "" || rc.every > 0) {
+		return fmt.Errorf("%w: --recap requires --audit_log, and can't be used with --input_file, --gviz_query, --plan_in or --every", ErrConfig)
+	}
+	if rc.dailyCharBudget < 0 {
+		return fmt.Errorf("%w: --daily_char_budget must not be negative", ErrConfig)
+	}
+	if rc.dailyCharBudget > 0 && (rc.checkpointFile == "" || rc.digest) {
+		return fmt.Errorf("%w: --daily_char_budget requires --checkpoint_file, and can't be used with --digest", ErrConfig)
+	}
+	if rc.digest && (rc.moderationURL != "" || rc.validate || rc.transformCmd != "" || rc.stateFile != "" || len(rc.hashtags) > 0 || rc.hashtagColumn >= 0 ||
+		len(rc.mediaColumns) > 0 || rc.mediaURLTemplate != "" || rc.qrURLColumn >= 0 || rc.feedFile != "") {
+		return fmt.Errorf("%w: --digest can't be used with --moderation_url, --validate, --transform_cmd, --state_file, --hashtags, --hashtag_column, --media_columns, --media_url, --qr_url_column or --feed_file", ErrConfig)
+	}
+	if rc.daily && rc.checkpointFile == "" {
+		return fmt.Errorf("%w: --daily requires --checkpoint_file", ErrConfig)
+	}
+	if rc.startPaused && rc.every == 0 {
+		return fmt.Errorf("%w: --start_paused requires --every", ErrConfig)
+	}
+	if rc.spreadWindow > 0 && (rc.serveAddr != "" || rc.tui) {
+		return fmt.Errorf("%w: --spread_window can't be used with --serve or --tui", ErrConfig)
+	}
+	if rc.dedupeWindow > 0 && rc.stateFile == "" {
+		return fmt.Errorf("%w: --dedupe_window requires --state_file", ErrConfig)
+	}
+	if rc.markAged && statusColumn == "" {
+		return fmt.Errorf("%w: --mark_aged requires --status_column", ErrConfig)
+	}
+	if rc.markEmpty && statusColumn == "" {
+		return fmt.Errorf("%w: --mark_empty requires --status_column", ErrConfig)
+	}
+
+	if err := validateReplySettings(bc, rc.replySettings); err != nil {
+		return err
+	}
+	if bc.name == backendTwitter && rc.replySettings != "" && rc.replySettings != repliesEveryone && rc.latColumn >= 0 {
+		return fmt.Errorf("%w: --reply_settings=%s can't be used with --lat_column, as tweets that limit replies can't be tagged with a location", ErrConfig, rc.replySettings)
+	}
+	if rc.retweetColumn >= 0 && bc.name != backendTwitter {
+		return fmt.Errorf("%w: --retweet_column is not supported by the %s backend", ErrConfig, bc.name)
+	}
+	if len(rc.utm) > 0 && rc.cardURLColumn < 0 {
+		return fmt.Errorf("%w: --utm_source, --utm_medium and --utm_campaign require --card_url_column", ErrConfig)
+	}
+	if rc.quoteColumn >= 0 && bc.name != backendTwitter {
+		return fmt.Errorf("%w: --quote_column is not supported by the %s backend", ErrConfig, bc.name)
+	}
+	if (rc.threadHeader != "" || rc.numberThread) && !rc.thread {
+		return fmt.Errorf("%w: --thread_header and --number_thread require --thread", ErrConfig)
+	}
+	if rc.numberHeader && (rc.threadHeader == "" || !rc.numberThread) {
+		return fmt.Errorf("%w: --number_header requires --thread_header and --number_thread", ErrConfig)
+	}
+	if rc.thread && bc.name == backendBluesky {
+		return fmt.Errorf("%w: --thread is not supported by the %s backend, which can't reply", ErrConfig, bc.name)
+	}
+	if rc.recap > 0 && bc.name == backendBluesky {
+		return fmt.Errorf("%w: --recap is not supported by the %s backend, which can't reply", ErrConfig, bc.name)
+	}
+	if rc.thread && (rc.digest || rc.serveAddr != "" || rc.tui || rc.planOut != "") {
+		return fmt.Errorf("%w: --thread can't be used with --digest, --serve, --tui or --plan_out", ErrConfig)
+	}
+	if rc.describeMedia && bc.name == backendBluesky {
+		return fmt.Errorf("%w: --describe_media is not supported by the %s backend, which can't reply", ErrConfig, bc.name)
+	}
+	if bc.replyTo != "" {
+		if rc.continueThreadFor != "" {
+			return fmt.Errorf("%w: --reply_to can't be used with --continue_thread_for", ErrConfig)
+		}
+		if bc.name == backendBluesky {
+			return fmt.Errorf("%w: --reply_to is not supported by the %s backend", ErrConfig, bc.name)
+		}
+		if _, err := replyToID(bc.replyTo); err != nil {
+			return fmt.Errorf("%w: bad --reply_to %q: %v", ErrConfig, bc.replyTo, err)
+		}
+	}
+	if rc.cwColumn >= 0 && bc.name != backendMastodon {
+		return fmt.Errorf("%w: --cw_column is not supported by the %s backend", ErrConfig, bc.name)
+	}
+
+	var srv *sheets.Service
+	var source RowSource
+	var ranges []*sheetRange
+	var err error
+	if rc.inputFile != "" || rc.gvizQuery != "" {
+		switch {
+		case rc.inputFile != "" && rc.gvizQuery != "":
+			return fmt.Errorf("%w: --input_file and --gviz_query are mutually exclusive", ErrConfig)
+		case statusColumn != "":
+			return fmt.Errorf("%w: rows read from --input_file or --gviz_query can't be marked complete", ErrConfig)
+		case sc.autoRange, sc.overridesRange != "", sc.errorColumn != "":
+			return fmt.Errorf("%w: --auto_range, --overrides_range and --error_column need a sheet, not --input_file or --gviz_query", ErrConfig)
+		case rc.serveAddr != "":
+			return fmt.Errorf("%w: --serve can't be used with --input_file or --gviz_query", ErrConfig)
+		case rc.gvizQuery != "" && (sc.id == "" || len(sc.sources) > 0):
+			return fmt.Errorf("%w: --gviz_query requires --sheet_id, and can't be used with --source", ErrConfig)
+		}
+		if rc.gvizQuery != "" {
+			source = newGvizSource(http.DefaultClient, sc, rc.gvizQuery)
+		} else if source, err = newFileSource(rc.inputFile, rc.inputFormat, rc.csvDelimiter, rc.csvLazyQuotes); err != nil {
+			return fmt.Errorf("%w: %w", ErrConfig, err)
+		}
+	} else if rc.recap == 0 {
+		if srv, ranges, err = openSheet(ctx, sc, rc); err != nil {
+			return err
+		}
+	}
+
+	poster, err := newPoster(bc)
+	if err != nil {
+		return err
+	}
+	if mp, ok := poster.(*mastodonPoster); ok && bc.maxLen == 0 {
+		if bc.mastodon.maxChars, err = mp.MaxStatusChars(ctx); err != nil {
+			log.Printf("warning: failed to get the server's status limit, assuming %d: %v", maxMastodonPostSize, err)
+		} else if bc.mastodon.maxChars > 0 {
+			log.Printf("the server allows statuses of up to %d characters", bc.mastodon.maxChars)
+		}
+	}
+	var router *accountRouter
+	if rc.accountsFile != "" {
+		if bc.name != backendTwitter || rc.digest || rc.planIn != "" || rc.recap > 0 || rc.serveAddr != "" || rc.tui {
+			return fmt.Errorf("%w: --accounts_file is only supported by the %s backend, and can't be used with --digest, --plan_in, --recap, --serve or --tui", ErrConfig, backendTwitter)
+		}
+		if (rc.accountStrategy == accountsPerRowColumn) != (rc.accountColumn >= 0) {
+			return fmt.Errorf("%w: --account_column is needed by, and only used by, --account_strategy=%s", ErrConfig, accountsPerRowColumn)
+		}
+		accounts, err := loadAccounts(rc.accountsFile)
+		if err != nil {
+			return fmt.Errorf("%w: failed to load --accounts_file %q: %w", ErrConfig, rc.accountsFile, err)
+		}
+		if router, err = newAccountRouter(rc.accountStrategy, accounts, bc.twitter, rc.accountSeed); err != nil {
+			return fmt.Errorf("%w: %w", ErrConfig, err)
+		}
+		poster = router
+	} else if rc.accountColumn >= 0 {
+		return fmt.Errorf("%w: --account_column requires --accounts_file", ErrConfig)
+	}
+	if rc.simulateFailureRate > 0 {
+		if rc.simulateFailureRate > 1 {
+			return fmt.Errorf("%w: --simulate_failure_rate must be between 0 and 1", ErrConfig)
+		}
+		log.Printf("simulating the failure of %v of attempts to post", rc.simulateFailureRate)
+		poster = newChaosPoster(poster, rc.simulateFailureRate, rc.simulateSeed)
+	}
+
+	var audit *auditLog
+	if rc.auditLogPath != "" {
+		audit, err = openAuditLog(rc.auditLogPath, bc)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %v", err)
+		}
+		defer audit.Close()
+	}
+
+	var state *postState
+	if rc.stateFile != "" {
+		state, err = loadState(rc.stateFile)
+		if err != nil {
+			return fmt.Errorf("%w: failed to load state file %q: %w", ErrConfig, rc.stateFile, err)
+		}
+	}
+
+	r := &runner{
+		sc:           sc,
+		bc:           bc,
+		rc:           rc,
+		srv:          srv,
+		source:       source,
+		poster:       poster,
+		audit:        audit,
+		state:        state,
+		now:          time.Now,
+		ranges:       ranges,
+		statusColumn: statusColumn,
+		exportRender: exportRender,
+		router:       router,
+	}
+	if rc.mediaConcurrency > 1 {
+		r.media = newMediaCache(http.DefaultClient, rc.mediaConcurrency)
+	}
+	if sc.calendarID != "" {
+		client, err := newSheetsClient(ctx, sc)
+		if err != nil {
+			return err
+		}
+		if r.calendar, err = calendar.New(client); err != nil {
+			return fmt.Errorf("%w: failed to retrieve client for Calendar: %w", ErrAuth, err)
+		}
+	}
+
+	if rc.probe > 0 {
+		return r.probe(os.Stdout, rc.probe)
+	}
+	if rc.dryRun {
+		return r.dryRun(ctx, os.Stdout)
+	}
+	if rc.serveAddr != "" {
+		return r.serve()
+	}
+	if rc.tui {
+		return r.tui(ctx, os.Stdin, os.Stdout)
+	}
+	if rc.planIn != "" {
+		return r.postPlan(ctx)
+	}
+	if rc.recap > 0 {
+		return r.postRecap(ctx)
+	}
+	if rc.every > 0 {
+		return runEvery(ctx, rc.every, rc.startPaused, func(ctx context.Context) error {
+			// Each run downloads its own media, so the cache doesn't
+			// grow for as long as the scheduler runs.
+			if rc.mediaConcurrency > 1 {
+				r.media = newMediaCache(http.DefaultClient, rc.mediaConcurrency)
+			}
+			return r.run(ctx)
+		})
+	}
+	return r.run(ctx)
+}
+
+func newSheetsService(ctx context.Context, sc *sheetsConfig) (*sheets.Service, error) {
+	client, err := newSheetsClient(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := sheets.New(client)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to retrieve client for Sheets: %w", ErrAuth, err)
+	}
+	return srv, nil
+}
+
+// oauthClient authorizes Sheets access as a user, with the client secret
+// file.
+func oauthClient(ctx context.Context, sc *sheetsConfig) (*http.Client, error) {
+	secretContent, err := ioutil.ReadFile(sc.secretPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read client secret file: %w", ErrAuth, err)
+	}
+
+	config, err := google.ConfigFromJSON(secretContent, authScopes(sc)...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to create config from secret file at %q: %w", ErrAuth, sc.secretPath, err)
+	}
+
+	client, err := getClient(ctx, config, sc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get client for Sheets: %w", ErrAuth, err)
+	}
+	return client, nil
+}
+
+func getClient(ctx context.Context, config *oauth2.Config, sc *sheetsConfig) (*http.Client, error) {
 	cacheFile, err := createCacheFile()
 	if err != nil {
 		return nil, fmt.Errorf("unable to get path to cached credential file: %v", err)
@@ -125,11 +1197,19 @@ func getClient(ctx context.Context, config *oauth2.Config) (*http.Client, error)
 	tok, err := tokenFromFile(cacheFile)
 	if err != nil {
 		// The token DNE or is invalid, so fetch and cache a new one.
-		tok, err = getTokenFromWeb(config)
+		if sc.deviceFlow {
+			tok, err = getTokenFromDevice(ctx, config)
+		} else {
+			tok, err = getTokenFromWeb(ctx, config, sc)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to get token from web: %v", err)
 		}
-		saveToken(cacheFile, tok)
+		// An online token can't be refreshed, so there's no use in
+		// caching it.
+		if sc.deviceFlow || sc.accessType != accessOnline {
+			saveToken(cacheFile, tok)
+		}
 	}
 
 	return config.Client(ctx, tok), nil
@@ -158,49 +1238,103 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 	return t, json.NewDecoder(f).Decode(t)
 }
 
-func getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	log.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
+// readAuthCodeFile returns the authorization code in the file at path, or
+// "" if path is empty or there's no such file, so that it's read from stdin.
+func readAuthCodeFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
 
-	var code string
-	if _, err := fmt.Scan(&code); err != nil {
-		return nil, fmt.Errorf("Unable to read authorization code %v", err)
+// The access types of --access_type.
+const (
+	accessOnline  = "online"
+	accessOffline = "offline"
+)
+
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config, sc *sheetsConfig) (*oauth2.Token, error) {
+	opt := oauth2.AccessTypeOffline
+	if sc.accessType == accessOnline {
+		opt = oauth2.AccessTypeOnline
+	}
+	authURL := config.AuthCodeURL("state-token", opt)
+
+	code, err := readAuthCodeFile(sc.authCodeFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read authorization code file %v", err)
+	}
+	if code != "" {
+		log.Printf("Using the authorization code in %s, for the link:\n%v\n", sc.authCodeFile, authURL)
+	} else {
+		log.Printf("Go to the following link in your browser then type the "+
+			"authorization code: \n%v\n", authURL)
+		if _, err := fmt.Scan(&code); err != nil {
+			return nil, fmt.Errorf("Unable to read authorization code %v", err)
+		}
 	}
 
-	tok, err := config.Exchange(oauth2.NoContext, code)
+	tok, err := exchangeToken(ctx, config, code)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to retrieve token from web %v", err)
 	}
 	return tok, nil
 }
 
-func saveToken(file string, token *oauth2.Token) error {
-	log.Printf("Saving credential file to: %s\n", file)
-	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+// tokenExchanger trades an authorization code for a token.
+// It is satisfied by *oauth2.Config.
+type tokenExchanger interface {
+	Exchange(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error)
+}
+
+// exchangeToken exchanges code for a token, retrying only if the exchange
+// never got through, as a code can be used just once. A rejected code
+// (invalid_grant) is not retried since it can never succeed.
+func exchangeToken(ctx context.Context, ex tokenExchanger, code string) (*oauth2.Token, error) {
+	var tok *oauth2.Token
+	err := retry(isUnprocessed, func() error {
+		var err error
+		tok, err = ex.Exchange(ctx, code)
+		return err
+	})
+	if isInvalidGrant(err) {
+		return nil, fmt.Errorf("%w: the authorization code was rejected (invalid_grant); re-run and authorize again: %w", ErrAuth, err)
+	}
 	if err != nil {
-		return fmt.Errorf("Unable to cache oauth token: %v", err)
+		return nil, err
 	}
-	defer f.Close()
-	return json.NewEncoder(f).Encode(token)
+	return tok, nil
 }
 
-const maxTweetSize = 280 // wowee!
-
-func tweet(api *anaconda.TwitterApi, data interface{}) error {
-	log.Printf("would have tweeted data: %v", data)
-	status := fmt.Sprintf("some cool data: %v", data)
-	if len(status) > maxTweetSize {
-		status = status[:maxTweetSize]
+// isInvalidGrant reports whether err is a token endpoint response with
+// the OAuth2 error code "invalid_grant".
+func isInvalidGrant(err error) bool {
+	var rErr *oauth2.RetrieveError
+	if !errors.As(err, &rErr) {
+		return false
 	}
-
-	if _, err := api.PostTweet(status, url.Values{}); err != nil {
-		return err
+	var body struct {
+		Error string `json:"error"`
 	}
-
-	return nil
+	if err := json.Unmarshal(rErr.Body, &body); err != nil {
+		return false
+	}
+	return body.Error == "invalid_grant"
 }
 
-func markComplete() error {
-	return nil
+func saveToken(file string, token *oauth2.Token) error {
+	log.Printf("Saving credential file to: %s\n", file)
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("Unable to cache oauth token: %v", err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(token)
 }
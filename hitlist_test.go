@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRowCell(t *testing.T) {
+	r := row{num: 2, firstCol: 2, values: []interface{}{"c", 4.5}}
+	for _, tc := range []struct {
+		col  int
+		want string
+	}{
+		{col: 0, want: ""},
+		{col: 2, want: "c"},
+		{col: 3, want: "4.5"},
+		{col: 4, want: ""},
+	} {
+		if got := r.cell(tc.col); got != tc.want {
+			t.Errorf("cell(%d) = %q, want %q", tc.col, got, tc.want)
+		}
+	}
+}
+
+// fakeExchanger returns each of errs from successive exchanges, then a
+// token.
+type fakeExchanger struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeExchanger) Exchange(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return &oauth2.Token{AccessToken: "tok"}, nil
+}
+
+func TestExchangeTokenRetries(t *testing.T) {
+	fastRetries(t)
+	ex := &fakeExchanger{errs: []error{&net.OpError{Op: "dial", Err: errors.New("connection refused")}}}
+	tok, err := exchangeToken(context.Background(), ex, "code")
+	if err != nil {
+		t.Fatalf("exchangeToken() = %v", err)
+	}
+	if tok.AccessToken != "tok" || ex.calls != 2 {
+		t.Errorf("exchangeToken() = %q after %d calls, want %q after 2", tok.AccessToken, ex.calls, "tok")
+	}
+}
+
+func TestExchangeTokenInvalidGrant(t *testing.T) {
+	fastRetries(t)
+	ex := &fakeExchanger{errs: []error{&oauth2.RetrieveError{Body: []byte(`{"error":"invalid_grant"}`)}}}
+	_, err := exchangeToken(context.Background(), ex, "code")
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("exchangeToken() = %v, want %v", err, ErrAuth)
+	}
+	if ex.calls != 1 {
+		t.Errorf("Exchange called %d times, want 1", ex.calls)
+	}
+}
+
+// Each of these configurations is rejected before anything is read or
+// posted.
+func TestDoMainRejectsBadConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		sc   func(*sheetsConfig)
+		bc   func(*backendConfig)
+		rc   func(*runConfig)
+		want string
+	}{
+		{
+			name: "device flow with a calendar",
+			sc:   func(sc *sheetsConfig) { sc.deviceFlow = true; sc.calendarID = "primary" },
+			want: "--device_flow",
+		},
+		{
+			name: "mark only without its column",
+			rc:   func(rc *runConfig) { rc.markOnly = true },
+			want: "--mark_only_column",
+		},
+		{
+			name: "digest with hashtags",
+			rc:   func(rc *runConfig) { rc.digest = true; rc.hashtags = []string{"#go"} },
+			want: "--digest can't be used",
+		},
+		{
+			name: "digest with a state file",
+			rc:   func(rc *runConfig) { rc.digest = true; rc.stateFile = "state.json" },
+			want: "--digest can't be used",
+		},
+		{
+			name: "digest with media",
+			rc:   func(rc *runConfig) { rc.digest = true; rc.mediaColumns = []int{3} },
+			want: "--digest can't be used",
+		},
+		{
+			name: "digest with a feed",
+			rc:   func(rc *runConfig) { rc.digest = true; rc.feedFile = "feed.xml" },
+			want: "--digest can't be used",
+		},
+		{
+			name: "limited replies with a location",
+			rc:   func(rc *runConfig) { rc.replySettings = repliesFollowing; rc.latColumn = 2 },
+			want: "--lat_column",
+		},
+		{
+			name: "unknown reply settings",
+			rc:   func(rc *runConfig) { rc.replySettings = "nobody" },
+			want: "nobody",
+		},
+		{
+			name: "tui reading stdin",
+			rc:   func(rc *runConfig) { rc.tui = true; rc.inputFile = "-" },
+			want: "--tui",
+		},
+		{
+			name: "every with tui",
+			rc:   func(rc *runConfig) { rc.every = 1; rc.tui = true },
+			want: "--every can't be used",
+		},
+		{
+			name: "spread window with serve",
+			rc:   func(rc *runConfig) { rc.spreadWindow = 1; rc.serveAddr = ":8080" },
+			want: "--spread_window",
+		},
+		{
+			name: "thread on Bluesky",
+			bc:   func(bc *backendConfig) { bc.name = backendBluesky },
+			rc:   func(rc *runConfig) { rc.thread = true },
+			want: "--thread",
+		},
+		{
+			name: "quote column on Mastodon",
+			bc:   func(bc *backendConfig) { bc.name = backendMastodon },
+			rc:   func(rc *runConfig) { rc.quoteColumn = 2 },
+			want: "--quote_column",
+		},
+		{
+			name: "calendar without a plan",
+			sc:   func(sc *sheetsConfig) { sc.calendarID = "primary" },
+			want: "--calendar_id",
+		},
+		{
+			name: "unknown major dimension",
+			sc:   func(sc *sheetsConfig) { sc.majorDimension = "DIAGONAL" },
+			want: "--major_dimension",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sc := &sheetsConfig{accessType: accessOnline, majorDimension: dimensionRows}
+			bc := &backendConfig{name: backendTwitter}
+			rc := testRunConfig()
+			rc.mediaConcurrency = 1
+			if tc.sc != nil {
+				tc.sc(sc)
+			}
+			if tc.bc != nil {
+				tc.bc(bc)
+			}
+			if tc.rc != nil {
+				tc.rc(rc)
+			}
+
+			err := doMain(sc, bc, rc)
+			if !errors.Is(err, ErrConfig) {
+				t.Fatalf("doMain() = %v, want an ErrConfig", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("doMain() = %v, want it to mention %q", err, tc.want)
+			}
+		})
+	}
+}
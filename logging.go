@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+)
+
+// setupLogging sends the log to the file at path, appending to it, or to
+// syslog, instead of stderr. It does nothing if neither is asked for.
+func setupLogging(path string, useSyslog bool) error {
+	switch {
+	case path != "" && useSyslog:
+		return errors.New("--log_file and --syslog can't both be set")
+	case path != "":
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+		if err != nil {
+			return err
+		}
+		log.SetOutput(f)
+	case useSyslog:
+		w, err := newSyslogWriter()
+		if err != nil {
+			return err
+		}
+		// syslog timestamps each message itself.
+		log.SetFlags(0)
+		log.SetOutput(w)
+	}
+	return nil
+}
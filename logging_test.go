@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+
+	if err := setupLogging("", false); err != nil {
+		t.Errorf("setupLogging() with neither = %v", err)
+	}
+	if err := setupLogging("hitlist.log", true); err == nil {
+		t.Error("setupLogging() with both --log_file and --syslog = nil, want an error")
+	}
+
+	path := filepath.Join(t.TempDir(), "hitlist.log")
+	if err := ioutil.WriteFile(path, []byte("earlier\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := setupLogging(path, false); err != nil {
+		t.Fatalf("setupLogging() = %v", err)
+	}
+	log.Print("later")
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); !strings.HasPrefix(got, "earlier\n") || !strings.Contains(got, "later") {
+		t.Errorf("log file = %q, want the new line appended", got)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultCompleteValue is the value written to the status column of tweeted
+// rows, unless --complete_value is set.
+const defaultCompleteValue = "DONE"
+
+// markerPlaceholderRE matches the placeholders of a --complete_value.
+var markerPlaceholderRE = regexp.MustCompile(`\{(\w+)\}`)
+
+// markerPlaceholders are the placeholders a --complete_value may use.
+var markerPlaceholders = map[string]bool{
+	"date":     true,
+	"user":     true,
+	"tweet_id": true,
+}
+
+// validateCompleteValue checks that tmpl only uses known placeholders.
+func validateCompleteValue(tmpl string) error {
+	for _, m := range markerPlaceholderRE.FindAllStringSubmatch(tmpl, -1) {
+		if !markerPlaceholders[m[1]] {
+			return fmt.Errorf("unknown placeholder %q; the placeholders are {date}, {user} and {tweet_id}", m[0])
+		}
+	}
+	return nil
+}
+
+// renderCompleteValue renders the marker written for a row posted as id,
+// replacing "{date}" with now's date, "{user}" with the user running
+// hitlist and "{tweet_id}" with id.
+func renderCompleteValue(tmpl, id, username string, now time.Time) string {
+	return strings.NewReplacer(
+		"{date}", now.Format("2006-01-02"),
+		"{user}", username,
+		"{tweet_id}", id,
+	).Replace(tmpl)
+}
+
+// currentUsername returns the name of the user running hitlist.
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateCompleteValue(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "DONE"},
+		{in: "{date}"},
+		{in: "posted {tweet_id} by {user} on {date}"},
+		{in: "{ not a placeholder }"},
+		{in: "{when}", wantErr: true},
+		{in: "{date} {tweetid}", wantErr: true},
+	} {
+		if err := validateCompleteValue(tc.in); (err != nil) != tc.wantErr {
+			t.Errorf("validateCompleteValue(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRenderCompleteValue(t *testing.T) {
+	now := time.Date(2024, 6, 3, 23, 59, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "DONE", want: "DONE"},
+		{in: "{date}", want: "2024-06-03"},
+		{in: "{tweet_id} by {user}", want: "20 by jack"},
+		{in: "{date} {date}", want: "2024-06-03 2024-06-03"},
+	} {
+		if got := renderCompleteValue(tc.in, "20", "jack", now); got != tc.want {
+			t.Errorf("renderCompleteValue(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
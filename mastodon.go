@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Mastodon's default limit; instances may allow more, with --max_len.
+const maxMastodonPostSize = 500
+
+const (
+	// mastodonMediaTimeout is how long to wait for the server to process
+	// uploaded media before giving up on it.
+	mastodonMediaTimeout = 2 * time.Minute
+	// The shortest and longest waits between checks of media's processing.
+	minMastodonMediaPoll = time.Second
+	maxMastodonMediaPoll = 10 * time.Second
+)
+
+// mastodonPoster posts statuses through the Mastodon REST API.
+type mastodonPoster struct {
+	mc     *mastodonConfig
+	client *http.Client
+}
+
+func newMastodonPoster(mc *mastodonConfig, client *http.Client) *mastodonPoster {
+	return &mastodonPoster{mc: mc, client: client}
+}
+
+// mastodonStatus is the body of a request to post a status.
+type mastodonStatus struct {
+	Status      string   `json:"status"`
+	MediaIDs    []string `json:"media_ids,omitempty"`
+	InReplyToID string   `json:"in_reply_to_id,omitempty"`
+	// SpoilerText is the content warning behind which the status is hidden.
+	SpoilerText string `json:"spoiler_text,omitempty"`
+	Visibility  string `json:"visibility,omitempty"`
+}
+
+// newMastodonStatus builds the request to post p.
+func newMastodonStatus(p *post) *mastodonStatus {
+	return &mastodonStatus{
+		Status:      p.status,
+		MediaIDs:    p.mediaIDs,
+		InReplyToID: p.replyTo,
+		SpoilerText: p.contentWarning,
+		Visibility:  mastodonVisibility(p.replySettings),
+	}
+}
+
+// mastodonVisibility returns the visibility that comes closest to limiting
+// replies as replySettings does, or "" for the account's default.
+func mastodonVisibility(replySettings string) string {
+	if replySettings == repliesMentioned {
+		return "direct"
+	}
+	return ""
+}
+
+func (m *mastodonPoster) Post(ctx context.Context, p *post) (string, error) {
+	body, err := json.Marshal(newMastodonStatus(p))
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := m.do(ctx, http.MethodPost, "/api/v1/statuses", "application/json", bytes.NewReader(body), &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (m *mastodonPoster) Verify(ctx context.Context) error {
+	var resp struct {
+		Acct string `json:"acct"`
+	}
+	return m.do(ctx, http.MethodGet, "/api/v1/accounts/verify_credentials", "", nil, &resp)
+}
+
+func (m *mastodonPoster) UploadMedia(ctx context.Context, data []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", "media")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	// Large media is processed after the upload, with the server returning
+	// 202 and no URL until it's done, and can't be attached until then.
+	var resp struct {
+		ID  string  `json:"id"`
+		URL *string `json:"url"`
+	}
+	if err := m.do(ctx, http.MethodPost, "/api/v2/media", w.FormDataContentType(), &body, &resp); err != nil {
+		return "", err
+	}
+	if resp.URL != nil {
+		return resp.ID, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mastodonMediaTimeout)
+	defer cancel()
+	id := resp.ID
+	for wait := minMastodonMediaPoll; resp.URL == nil; {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("gave up waiting for media %s to be processed: %w", id, ctx.Err())
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxMastodonMediaPoll {
+			wait = maxMastodonMediaPoll
+		}
+		// The server returns 206 while the media is still being processed.
+		if err := m.do(ctx, http.MethodGet, "/api/v1/media/"+id, "", nil, &resp); err != nil {
+			return "", fmt.Errorf("failed to check processing of media %s: %w", id, err)
+		}
+	}
+	return id, nil
+}
+
+// MaxStatusChars returns the longest status the server allows, as its
+// instance API advertises, or 0 if it doesn't say. Mastodon gives it in its
+// configuration, while Pleroma and some forks give max_toot_chars.
+func (m *mastodonPoster) MaxStatusChars(ctx context.Context) (int, error) {
+	var resp struct {
+		MaxTootChars  int `json:"max_toot_chars"`
+		Configuration struct {
+			Statuses struct {
+				MaxCharacters int `json:"max_characters"`
+			} `json:"statuses"`
+		} `json:"configuration"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/api/v1/instance", "", nil, &resp); err != nil {
+		return 0, err
+	}
+	if n := resp.Configuration.Statuses.MaxCharacters; n > 0 {
+		return n, nil
+	}
+	return resp.MaxTootChars, nil
+}
+
+// do sends a request for path to the server, decoding the JSON response
+// into out. Any 2xx status is a success, as the server returns 202 and 206
+// for media that's still being processed.
+func (m *mastodonPoster) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(m.mc.server, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if m.mc.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+m.mc.accessToken)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMastodonPosterPostAcceptsAny2xx(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusCreated, http.StatusAccepted} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"id": "42"})
+		}))
+		m := newMastodonPoster(&mastodonConfig{server: srv.URL + "/", accessToken: "token"}, srv.Client())
+		id, err := m.Post(context.Background(), &post{status: "hi"})
+		if err != nil || id != "42" {
+			t.Errorf("with status %d, Post() = %q, %v, want %q", status, id, err, "42")
+		}
+		srv.Close()
+	}
+}
+
+// A row's cell in --cw_column is posted as the status's spoiler_text.
+func TestRunPostsContentWarning(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/statuses" {
+			http.NotFound(w, req)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(map[string]string{"id": strconv.Itoa(len(bodies))})
+	}))
+	defer srv.Close()
+
+	rc := testRunConfig()
+	rc.cwColumn = 1
+	mc := &mastodonConfig{server: srv.URL}
+	r := newTestRunner(newMastodonPoster(mc, srv.Client()), rc)
+	r.bc = &backendConfig{name: backendMastodon, mastodon: mc}
+	r.source = staticSource{{"The ending", "Spoilers"}, {"Hello"}}
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("run() = %v", err)
+	}
+
+	want := []map[string]interface{}{
+		{"status": "The ending", "spoiler_text": "Spoilers"},
+		{"status": "Hello"},
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("posted %v, want %v", bodies, want)
+	}
+}
+
+func TestMastodonPosterPostFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, `{"error": "Validation failed"}`, http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+	m := newMastodonPoster(&mastodonConfig{server: srv.URL}, srv.Client())
+	if _, err := m.Post(context.Background(), &post{status: "hi"}); err == nil {
+		t.Error("Post() succeeded despite a 422")
+	}
+}
+
+// Media that's processed asynchronously is only returned once it's ready.
+func TestMastodonPosterUploadMediaWaitsForProcessing(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// uploadStatus and uploadURL are the upload's response.
+		uploadStatus int
+		uploadURL    interface{}
+		// checks is how many checks of the media find it still processing.
+		checks    int32
+		wantPolls int32
+	}{
+		{name: "processed at once", uploadStatus: http.StatusOK, uploadURL: "https://files/1.png", wantPolls: 0},
+		{name: "processed later", uploadStatus: http.StatusAccepted, uploadURL: nil, checks: 1, wantPolls: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var polls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.Method == http.MethodPost && req.URL.Path == "/api/v2/media":
+					w.WriteHeader(tc.uploadStatus)
+					json.NewEncoder(w).Encode(map[string]interface{}{"id": "7", "url": tc.uploadURL})
+				case req.Method == http.MethodGet && req.URL.Path == "/api/v1/media/7":
+					if atomic.AddInt32(&polls, 1) <= tc.checks {
+						w.WriteHeader(http.StatusPartialContent)
+						json.NewEncoder(w).Encode(map[string]interface{}{"id": "7", "url": nil})
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"id": "7", "url": "https://files/7.png"})
+				default:
+					http.NotFound(w, req)
+				}
+			}))
+			defer srv.Close()
+
+			m := newMastodonPoster(&mastodonConfig{server: srv.URL}, srv.Client())
+			id, err := m.UploadMedia(context.Background(), []byte("png"))
+			if err != nil || id != "7" {
+				t.Errorf("UploadMedia() = %q, %v, want %q", id, err, "7")
+			}
+			if got := atomic.LoadInt32(&polls); got != tc.wantPolls {
+				t.Errorf("checked the media %d times, want %d", got, tc.wantPolls)
+			}
+		})
+	}
+}
+
+func TestMastodonPosterUploadMediaGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "7", "url": nil})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := newMastodonPoster(&mastodonConfig{server: srv.URL}, srv.Client())
+	if _, err := m.UploadMedia(ctx, []byte("png")); err == nil {
+		t.Error("UploadMedia() succeeded with a cancelled context")
+	}
+}
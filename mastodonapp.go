@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mastodonRedirectURI is the out-of-band redirect, for which the server
+// shows the authorization code to be pasted in, rather than redirecting.
+const mastodonRedirectURI = "urn:ietf:wg:oauth:2.0:oob"
+
+// mastodonScopes are the scopes hitlist needs to post.
+const mastodonScopes = "write:statuses write:media read:accounts"
+
+// registerMastodonApp registers hitlist as an app named mc.appName on the
+// server, which is shown as the source of its posts, then has the user
+// authorize it and prints the access token to use with
+// --mastodon_access_token.
+func registerMastodonApp(ctx context.Context, mc *mastodonConfig, client *http.Client, in io.Reader, out io.Writer) error {
+	m := newMastodonPoster(&mastodonConfig{server: mc.server}, client)
+
+	var app struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	form := url.Values{
+		"client_name":   {mc.appName},
+		"redirect_uris": {mastodonRedirectURI},
+		"scopes":        {mastodonScopes},
+	}
+	if err := m.do(ctx, http.MethodPost, "/api/v1/apps", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), &app); err != nil {
+		return fmt.Errorf("failed to register the app: %v", err)
+	}
+
+	authURL := strings.TrimSuffix(mc.server, "/") + "/oauth/authorize?" + url.Values{
+		"client_id":     {app.ClientID},
+		"redirect_uri":  {mastodonRedirectURI},
+		"response_type": {"code"},
+		"scope":         {mastodonScopes},
+	}.Encode()
+	fmt.Fprintf(out, "Go to the following link in your browser then type the authorization code:\n%s\n", authURL)
+
+	code, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && code == "" {
+		return fmt.Errorf("failed to read the authorization code: %v", err)
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	form = url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {strings.TrimSpace(code)},
+		"client_id":     {app.ClientID},
+		"client_secret": {app.ClientSecret},
+		"redirect_uri":  {mastodonRedirectURI},
+		"scope":         {mastodonScopes},
+	}
+	if err := m.do(ctx, http.MethodPost, "/oauth/token", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), &tok); err != nil {
+		return fmt.Errorf("failed to get an access token: %v", err)
+	}
+
+	fmt.Fprintf(out, "Access token for --mastodon_access_token: %s\n", tok.AccessToken)
+	return nil
+}
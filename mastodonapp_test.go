@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterMastodonApp(t *testing.T) {
+	var gotApp, gotToken map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		form := make(map[string]string)
+		for k := range req.PostForm {
+			form[k] = req.PostForm.Get(k)
+		}
+		switch req.URL.Path {
+		case "/api/v1/apps":
+			gotApp = form
+			json.NewEncoder(w).Encode(map[string]string{"client_id": "id", "client_secret": "secret"})
+		case "/oauth/token":
+			gotToken = form
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	mc := &mastodonConfig{server: srv.URL + "/", appName: "My Bot"}
+	if err := registerMastodonApp(context.Background(), mc, srv.Client(), strings.NewReader("the-code\n"), &out); err != nil {
+		t.Fatalf("registerMastodonApp() = %v", err)
+	}
+
+	if gotApp["client_name"] != "My Bot" || gotApp["scopes"] != mastodonScopes {
+		t.Errorf("the app registered was %v", gotApp)
+	}
+	if gotToken["code"] != "the-code" || gotToken["client_id"] != "id" || gotToken["client_secret"] != "secret" {
+		t.Errorf("the token request was %v", gotToken)
+	}
+	if !strings.Contains(out.String(), srv.URL+"/oauth/authorize?client_id=id") {
+		t.Errorf("output has no authorization link: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "--mastodon_access_token: token") {
+		t.Errorf("output has no access token: %q", out.String())
+	}
+}
+
+func TestRegisterMastodonAppFails(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		path string
+		code string
+	}{
+		{name: "registration rejected", path: "/api/v1/apps", code: "the-code\n"},
+		{name: "token rejected", path: "/oauth/token", code: "the-code\n"},
+		{name: "no code", code: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == tc.path {
+					http.Error(w, `{"error": "nope"}`, http.StatusForbidden)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"client_id": "id", "access_token": "token"})
+			}))
+			defer srv.Close()
+
+			mc := &mastodonConfig{server: srv.URL, appName: "hitlist"}
+			if err := registerMastodonApp(context.Background(), mc, srv.Client(), strings.NewReader(tc.code), &bytes.Buffer{}); err == nil {
+				t.Error("registerMastodonApp() = nil, want an error")
+			}
+		})
+	}
+}
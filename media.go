@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// The policies of --on_media_error, for when media can't be downloaded or
+// uploaded.
+const (
+	// mediaErrorSkip skips the row, leaving it for a later run.
+	mediaErrorSkip = "skip"
+	// mediaErrorTextOnly posts the row without the media.
+	mediaErrorTextOnly = "text-only"
+	// mediaErrorFail reports the row as failed.
+	mediaErrorFail = "fail"
+)
+
+// errSkipRow is returned for a row that should be skipped, rather than
+// posted or reported as failed.
+var errSkipRow = errors.New("skipping row")
+
+// maxImages is the most images that can be attached to a post. A GIF or a
+// video must be attached alone.
+const maxImages = 4
+
+const (
+	mediaImage = "image"
+	mediaGIF   = "GIF"
+	mediaVideo = "video"
+)
+
+// mediaKind guesses the kind of the media at u from its extension.
+func mediaKind(u string) string {
+	p := u
+	if parsed, err := url.Parse(u); err == nil {
+		p = parsed.Path
+	}
+
+	switch strings.ToLower(path.Ext(p)) {
+	case ".gif":
+		return mediaGIF
+	case ".mp4", ".mov", ".m4v":
+		return mediaVideo
+	default:
+		return mediaImage
+	}
+}
+
+// validateMedia checks that the media at urls can be attached to a single
+// post: up to maxImages images, or a lone GIF or video.
+func validateMedia(urls []string) error {
+	if len(urls) > maxImages {
+		return fmt.Errorf("%d media attachments exceed the limit of %d", len(urls), maxImages)
+	}
+	if len(urls) < 2 {
+		return nil
+	}
+	for _, u := range urls {
+		if k := mediaKind(u); k != mediaImage {
+			return fmt.Errorf("the %s %q can't be attached alongside other media", k, u)
+		}
+	}
+	return nil
+}
+
+// uploadMedia downloads the media at mediaURL and uploads it with poster,
+// returning the media ID. The media is taken from cache, if it's not nil.
+// Videos are uploaded as a videoUploader does, if poster is one.
+func uploadMedia(ctx context.Context, poster Poster, cache *mediaCache, mediaURL string) (string, error) {
+	u, ok := optional[mediaUploader](poster)
+	if !ok {
+		return "", errors.New("the backend does not support media")
+	}
+
+	var data []byte
+	var err error
+	if cache != nil {
+		data, err = cache.get(ctx, mediaURL)
+	} else {
+		data, err = fetchMedia(ctx, http.DefaultClient, mediaURL)
+	}
+	if err != nil {
+		return "", err
+	}
+	if v, ok := optional[videoUploader](poster); ok && mediaKind(mediaURL) == mediaVideo {
+		return v.UploadVideo(ctx, data, videoMIMEType(mediaURL))
+	}
+	return u.UploadMedia(ctx, data)
+}
+
+// fetchMedia downloads the media at mediaURL.
+func fetchMedia(ctx context.Context, client *http.Client, mediaURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %q: %s", mediaURL, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
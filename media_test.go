@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMediaKind(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "https://example.com/a.png", want: mediaImage},
+		{in: "https://example.com/a.JPG", want: mediaImage},
+		{in: "https://example.com/a", want: mediaImage},
+		{in: "https://example.com/a.gif", want: mediaGIF},
+		{in: "https://example.com/a.gif?size=large", want: mediaGIF},
+		{in: "https://example.com/a.mp4", want: mediaVideo},
+		{in: "https://example.com/a.MOV", want: mediaVideo},
+		{in: "https://example.com/a.m4v#t=1", want: mediaVideo},
+		{in: "https://example.com/a.mp4/thumb", want: mediaImage},
+	} {
+		if got := mediaKind(tc.in); got != tc.want {
+			t.Errorf("mediaKind(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestValidateMedia(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		urls    []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "lone video", urls: []string{"v.mp4"}},
+		{name: "lone GIF", urls: []string{"a.gif"}},
+		{name: "four images", urls: []string{"1.png", "2.png", "3.jpg", "4.webp"}},
+		{name: "five images", urls: []string{"1.png", "2.png", "3.png", "4.png", "5.png"}, wantErr: true},
+		{name: "GIF and image", urls: []string{"a.gif", "b.png"}, wantErr: true},
+		{name: "image and video", urls: []string{"b.png", "v.mov"}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateMedia(tc.urls); (err != nil) != tc.wantErr {
+				t.Errorf("validateMedia(%q) = %v, want error: %t", tc.urls, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("data"))
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name    string
+		poster  Poster
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "image", poster: &fullPoster{name: "p"}, path: "/a.png", want: "p:media"},
+		{name: "video", poster: &fullPoster{name: "p"}, path: "/a.mp4", want: "p:video"},
+		{name: "download fails", poster: &fullPoster{name: "p"}, path: "/missing.png", wantErr: true},
+		{name: "no media support", poster: &fakePoster{}, path: "/a.png", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := uploadMedia(context.Background(), tc.poster, nil, srv.URL+tc.path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("uploadMedia() = %v, want error: %t", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("uploadMedia() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// mediaCache downloads media in the background, at most a fixed number at a
+// time, so that the media of upcoming rows is ready by the time they're
+// posted. A URL isn't downloaded again while its download is pending, but
+// its media is dropped once it's used, so that the cache only holds the
+// media of the next lookahead rows.
+type mediaCache struct {
+	client *http.Client
+	sem    chan struct{}
+	// lookahead is how many of the rows after the one being posted have
+	// their media downloaded.
+	lookahead int
+
+	mu      sync.Mutex
+	fetches map[string]*mediaFetch
+}
+
+// mediaFetch is the download of a single URL.
+type mediaFetch struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// newMediaCache returns a mediaCache that runs up to concurrency downloads
+// at once, for up to twice as many rows ahead.
+func newMediaCache(client *http.Client, concurrency int) *mediaCache {
+	return &mediaCache{
+		client:    client,
+		sem:       make(chan struct{}, concurrency),
+		lookahead: 2 * concurrency,
+		fetches:   make(map[string]*mediaFetch),
+	}
+}
+
+// prefetch starts downloading each of urls that isn't already downloading.
+func (c *mediaCache) prefetch(ctx context.Context, urls []string) {
+	for _, u := range urls {
+		c.start(ctx, u)
+	}
+}
+
+// get returns the media at u, waiting for its download to finish, and
+// starting it if it hasn't been. The media is then dropped from the cache.
+func (c *mediaCache) get(ctx context.Context, u string) ([]byte, error) {
+	f := c.start(ctx, u)
+	select {
+	case <-f.done:
+		c.drop(u, f)
+		return f.data, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// drop removes f, the download of u, from the cache, unless it's already
+// been replaced.
+func (c *mediaCache) drop(u string, f *mediaFetch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetches[u] == f {
+		delete(c.fetches, u)
+	}
+}
+
+func (c *mediaCache) start(ctx context.Context, u string) *mediaFetch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.fetches[u]; ok {
+		return f
+	}
+	f := &mediaFetch{done: make(chan struct{})}
+	c.fetches[u] = f
+
+	go func() {
+		defer close(f.done)
+		// A download cut short by its context isn't cached, so that it's
+		// tried again by the next get, which may have more time.
+		defer func() {
+			if errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded) {
+				c.drop(u, f)
+			}
+		}()
+		select {
+		case c.sem <- struct{}{}:
+		case <-ctx.Done():
+			f.err = ctx.Err()
+			return
+		}
+		defer func() { <-c.sem }()
+		f.data, f.err = fetchMedia(ctx, c.client, u)
+	}()
+	return f
+}
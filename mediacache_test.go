@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMediaCache(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte("media"))
+	}))
+	defer srv.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tc := range []struct {
+		name string
+		// prefetchCtx, if set, is the context of a prefetch before get.
+		prefetchCtx context.Context
+		gets        int
+		wantHits    int32
+	}{
+		{name: "get downloads", gets: 1, wantHits: 1},
+		{name: "prefetch is used by get", prefetchCtx: context.Background(), gets: 1, wantHits: 1},
+		{name: "media is dropped once used", gets: 2, wantHits: 2},
+		{name: "cancelled download isn't cached", prefetchCtx: cancelled, gets: 1, wantHits: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&hits, 0)
+			c := newMediaCache(srv.Client(), 2)
+			if tc.prefetchCtx != nil {
+				c.prefetch(tc.prefetchCtx, []string{srv.URL})
+				f := c.start(tc.prefetchCtx, srv.URL)
+				<-f.done
+			}
+			for i := 0; i < tc.gets; i++ {
+				data, err := c.get(context.Background(), srv.URL)
+				if err != nil {
+					t.Fatalf("get() failed: %v", err)
+				}
+				if string(data) != "media" {
+					t.Errorf("get() = %q, want %q", data, "media")
+				}
+			}
+			if got := atomic.LoadInt32(&hits); got != tc.wantHits {
+				t.Errorf("downloaded %d times, want %d", got, tc.wantHits)
+			}
+			if n := len(c.fetches); n != 0 {
+				t.Errorf("cache holds %d downloads after use, want 0", n)
+			}
+		})
+	}
+}
+
+func TestMediaCacheCancelledGet(t *testing.T) {
+	c := newMediaCache(http.DefaultClient, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.get(ctx, "http://example.invalid/a.png"); !errors.Is(err, context.Canceled) {
+		t.Errorf("get() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// moderationTimeout bounds each call to the moderation hook.
+const moderationTimeout = 10 * time.Second
+
+// moderate asks the moderation hook at url whether status may be posted,
+// returning its verdict and the reason it gave.
+func moderate(ctx context.Context, url, status string) (bool, string, error) {
+	body, err := json.Marshal(map[string]string{"status": status})
+	if err != nil {
+		return false, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, moderationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("the moderation hook returned %s", resp.Status)
+	}
+
+	var verdict struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return false, "", fmt.Errorf("bad response from the moderation hook: %v", err)
+	}
+	return verdict.Allow, verdict.Reason, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModerate(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		code       int
+		body       string
+		wantAllow  bool
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "allowed", code: http.StatusOK, body: `{"allow": true}`, wantAllow: true},
+		{name: "rejected", code: http.StatusOK, body: `{"allow": false, "reason": "spam"}`, wantReason: "spam"},
+		{name: "server error", code: http.StatusInternalServerError, body: `{"allow": true}`, wantErr: true},
+		{name: "bad body", code: http.StatusOK, body: `allow`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tc.code)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			allow, reason, err := moderate(context.Background(), srv.URL, "hello")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("moderate() = %v, want error: %t", err, tc.wantErr)
+			}
+			if allow != tc.wantAllow || reason != tc.wantReason {
+				t.Errorf("moderate() = %t, %q, want %t, %q", allow, reason, tc.wantAllow, tc.wantReason)
+			}
+			if got["status"] != "hello" {
+				t.Errorf("the hook got %v, want the status", got)
+			}
+		})
+	}
+}
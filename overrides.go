@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Overrides are per-row settings, read from --overrides_range, whose
+// columns are, in order: skip, media and reply-to.
+type Overrides struct {
+	// Skip leaves the row out of the run, without marking it complete.
+	Skip bool
+	// Media is the URL of media to attach, besides any in the media
+	// columns.
+	Media string
+	// ReplyTo is the ID or URL of a post to reply to.
+	ReplyTo string
+}
+
+// parseOverrides parses the values of a row of the overrides range. Missing
+// values leave their settings unset.
+func parseOverrides(values []interface{}) Overrides {
+	get := func(i int) string {
+		if i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprint(values[i]))
+	}
+
+	var o Overrides
+	switch strings.ToLower(get(0)) {
+	case "x", "y", "yes", "true", "1", "skip":
+		o.Skip = true
+	}
+	o.Media = get(1)
+	o.ReplyTo = get(2)
+	return o
+}
+
+// replyToID returns the ID of the post to reply to, given its ID or, for a
+// tweet, its URL.
+func replyToID(replyTo string) (string, error) {
+	if strings.Contains(replyTo, "/") {
+		return tweetIDFromURL(replyTo)
+	}
+	return replyTo, nil
+}
+
+// mergeOverrides sets the overrides of each of rows from the row of
+// overrides at the same index, so that the overrides range can start at a
+// different row, or be on another sheet.
+func mergeOverrides(rows []row, overrides [][]interface{}) {
+	for i := range rows {
+		if i < len(overrides) {
+			rows[i].overrides = parseOverrides(overrides[i])
+		}
+	}
+}
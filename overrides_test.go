@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestParseOverrides(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []interface{}
+		want Overrides
+	}{
+		{name: "empty", in: nil, want: Overrides{}},
+		{name: "skip x", in: []interface{}{"x"}, want: Overrides{Skip: true}},
+		{name: "skip yes", in: []interface{}{" YES "}, want: Overrides{Skip: true}},
+		{name: "skip bool", in: []interface{}{true}, want: Overrides{Skip: true}},
+		{name: "no skip", in: []interface{}{"no"}, want: Overrides{}},
+		{name: "media", in: []interface{}{"", " https://example.com/a.png "}, want: Overrides{Media: "https://example.com/a.png"}},
+		{name: "all", in: []interface{}{"skip", "m.png", "20"}, want: Overrides{Skip: true, Media: "m.png", ReplyTo: "20"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseOverrides(tc.in); got != tc.want {
+				t.Errorf("parseOverrides(%v) = %+v, want %+v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReplyToID(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "20", want: "20"},
+		{in: "109876543210", want: "109876543210"},
+		{in: "https://twitter.com/jack/status/20", want: "20"},
+		{in: "https://example.com/@user/20", wantErr: true},
+	} {
+		got, err := replyToID(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("replyToID(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("replyToID(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMergeOverrides(t *testing.T) {
+	rows := testRows("a", "b", "c")
+	mergeOverrides(rows, [][]interface{}{{"x"}, {"", "m.png"}})
+
+	want := []Overrides{{Skip: true}, {Media: "m.png"}, {}}
+	for i, r := range rows {
+		if r.overrides != want[i] {
+			t.Errorf("row %d overrides = %+v, want %+v", r.num, r.overrides, want[i])
+		}
+	}
+}
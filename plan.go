@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+// plan is the list of posts a run would make, written by --plan_out so
+// that it can be reviewed, edited and then posted with --plan_in.
+type plan struct {
+	Entries []planEntry `json:"entries"`
+}
+
+// planEntry is a single post of a plan.
+type planEntry struct {
+	// Row is the number of the row the post is for, which is marked
+	// complete once it's posted.
+	Row    int      `json:"row"`
+	Status string   `json:"status"`
+	Media  []string `json:"media,omitempty"`
+	// At is when the post is scheduled for, with --spread_window. It's
+	// posted no sooner.
+	At *time.Time `json:"at,omitempty"`
+}
+
+// writePlan writes p to path, indented for editing by hand.
+func writePlan(path string, p *plan) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readPlan reads the plan at path.
+func readPlan(path string) (*plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	for i, e := range p.Entries {
+		if e.Status == "" {
+			return nil, fmt.Errorf("entry %d, for row %d, has no status", i, e.Row)
+		}
+	}
+	return p, nil
+}
+
+// makePlan composes the posts for rows into a plan and writes it to
+// --plan_out, without posting anything. With --spread_window, each post is
+// scheduled for a time across the window, starting now, and with
+// --calendar_id, is added to the calendar at that time.
+func (r *runner) makePlan(ctx context.Context, rows []row) error {
+	var spread []time.Duration
+	start := r.now()
+	if r.rc.spreadWindow > 0 {
+		spread = computeSpreadTimes(len(rows), r.rc.spreadWindow, r.rc.spreadSeed)
+	}
+
+	p := &plan{}
+	for i, rw := range rows {
+		if rw.overrides.Skip {
+			continue
+		}
+		status, err := composeStatus(rw, r.bc, r.rc)
+		if err != nil {
+			log.Printf("warning: row %d: leaving row out of the plan: %v", rw.num, err)
+			continue
+		}
+		e := planEntry{Row: rw.num, Status: status, Media: rowMedia(rw, r.rc)}
+		if spread != nil {
+			at := start.Add(spread[i])
+			e.At = &at
+		}
+		p.Entries = append(p.Entries, e)
+	}
+
+	if err := writePlan(r.rc.planOut, p); err != nil {
+		return fmt.Errorf("failed to write plan to %q: %v", r.rc.planOut, err)
+	}
+	log.Printf("wrote a plan of %d posts to %q", len(p.Entries), r.rc.planOut)
+
+	if r.calendar != nil {
+		return r.addPlanToCalendar(ctx, p)
+	}
+	return nil
+}
+
+// postPlan posts the statuses of the plan at --plan_in, exactly as they
+// are and in order, and marks their rows complete. The sheet isn't read.
+// It stops at the first post that fails.
+func (r *runner) postPlan(ctx context.Context) error {
+	p, err := readPlan(r.rc.planIn)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read plan %q: %w", ErrConfig, r.rc.planIn, err)
+	}
+
+	var posted []row
+	var postErr error
+	for _, e := range p.Entries {
+		if e.At != nil {
+			if err := sleepUntil(ctx, *e.At); err != nil {
+				postErr = err
+				break
+			}
+		}
+
+		pp := &post{status: e.Status}
+		if err := r.attachMediaURLs(ctx, e.Row, e.Media, pp); errors.Is(err, errSkipRow) {
+			log.Printf("row %d: %v", e.Row, err)
+			continue
+		} else if err != nil {
+			postErr = fmt.Errorf("row %d: %v", e.Row, err)
+			break
+		}
+
+		id, err := r.poster.Post(ctx, pp)
+		if err != nil {
+			postErr = fmt.Errorf("row %d: %w", e.Row, err)
+			break
+		}
+		posted = append(posted, row{num: e.Row, postID: id})
+
+		if r.audit != nil {
+			if err := r.audit.record(e.Row, id, e.Status); err != nil {
+				postErr = fmt.Errorf("row %d was posted as %s, but failed to write audit log: %v", e.Row, id, err)
+				break
+			}
+		}
+	}
+
+	marked, err := r.markComplete(posted)
+	reportUnmarked(posted, marked)
+	if err != nil {
+		return fmt.Errorf("%w: failed to mark Tweeted data as complete: %w", ErrSheetWrite, err)
+	}
+	if postErr != nil {
+		return fmt.Errorf("%w: %w", ErrPost, postErr)
+	}
+	log.Printf("posted %d of the plan's %d posts", len(posted), len(p.Entries))
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	at := time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)
+	want := &plan{Entries: []planEntry{
+		{Row: 2, Status: "one", Media: []string{"https://example.com/a.png"}, At: &at},
+		{Row: 4, Status: "two"},
+	}}
+	if err := writePlan(path, want); err != nil {
+		t.Fatalf("writePlan() = %v", err)
+	}
+	got, err := readPlan(path)
+	if err != nil {
+		t.Fatalf("readPlan() = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readPlan() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadPlanInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{name: "not JSON", data: "entries"},
+		{name: "empty status", data: `{"entries": [{"row": 2, "status": "ok"}, {"row": 3}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.json")
+			if err := ioutil.WriteFile(path, []byte(tc.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readPlan(path); err == nil {
+				t.Error("readPlan() = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestMakePlan(t *testing.T) {
+	start := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name         string
+		spreadWindow time.Duration
+		wantRows     []int
+		wantAt       bool
+	}{
+		{name: "unscheduled", wantRows: []int{2, 4}},
+		{name: "spread", spreadWindow: time.Hour, wantRows: []int{2, 4}, wantAt: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.planOut = filepath.Join(t.TempDir(), "plan.json")
+			rc.spreadWindow = tc.spreadWindow
+			poster := &fakePoster{}
+			r := newTestRunner(poster, rc)
+			r.now = func() time.Time { return start }
+
+			rows := testRows("one", "skipped", "three")
+			rows[1].overrides.Skip = true
+			if err := r.makePlan(context.Background(), rows); err != nil {
+				t.Fatalf("makePlan() = %v", err)
+			}
+			if len(poster.statuses()) != 0 {
+				t.Errorf("makePlan() posted %q", poster.statuses())
+			}
+
+			p, err := readPlan(rc.planOut)
+			if err != nil {
+				t.Fatalf("readPlan() = %v", err)
+			}
+			var nums []int
+			for _, e := range p.Entries {
+				nums = append(nums, e.Row)
+				if (e.At != nil) != tc.wantAt {
+					t.Errorf("row %d: At = %v, want set: %t", e.Row, e.At, tc.wantAt)
+				}
+				if e.At != nil && (e.At.Before(start) || e.At.After(start.Add(tc.spreadWindow))) {
+					t.Errorf("row %d: At = %v, outside the window", e.Row, e.At)
+				}
+			}
+			if !reflect.DeepEqual(nums, tc.wantRows) {
+				t.Errorf("plan rows = %v, want %v", nums, tc.wantRows)
+			}
+		})
+	}
+}
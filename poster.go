@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Poster publishes statuses to a social network backend.
+type Poster interface {
+	// Post publishes p and returns the ID of the new post.
+	Post(ctx context.Context, p *post) (string, error)
+}
+
+// posterWrapper is implemented by Posters that wrap others, such as
+// chaosPoster. They have the methods of every optional interface, but only
+// support those that all of the Posters they wrap do.
+type posterWrapper interface {
+	wrapped() []Poster
+}
+
+// optional returns p as the optional interface T, such as mediaUploader,
+// and whether p supports it. Any Poster that p wraps must support it too.
+func optional[T any](p Poster) (T, bool) {
+	t, ok := p.(T)
+	if !ok {
+		return t, false
+	}
+	if w, ok := p.(posterWrapper); ok {
+		for _, inner := range w.wrapped() {
+			if _, ok := optional[T](inner); !ok {
+				var none T
+				return none, false
+			}
+		}
+	}
+	return t, true
+}
+
+// verifier is implemented by Posters that can check their credentials
+// without posting.
+type verifier interface {
+	Verify(ctx context.Context) error
+}
+
+// timelineReader is implemented by Posters that can look up an account's
+// latest post.
+type timelineReader interface {
+	// LatestPostID returns the ID of the latest post of the account with
+	// the given screen name, or "" if it has none.
+	LatestPostID(ctx context.Context, screenName string) (string, error)
+}
+
+// reposter is implemented by Posters that can repost (retweet or boost) an
+// existing post.
+type reposter interface {
+	// Repost reposts the post with the given ID and returns the ID of the
+	// repost. It returns errAlreadyReposted or errPostNotFound if the post
+	// was already reposted or doesn't exist.
+	Repost(ctx context.Context, id string) (string, error)
+}
+
+var (
+	errAlreadyReposted = errors.New("the post was already reposted")
+	errPostNotFound    = errors.New("the post doesn't exist")
+)
+
+// mediaUploader is implemented by Posters that can attach media to posts.
+type mediaUploader interface {
+	// UploadMedia uploads an image and returns its media ID.
+	UploadMedia(ctx context.Context, data []byte) (string, error)
+}
+
+// post is a single status to be published by a Poster.
+type post struct {
+	status   string
+	mediaIDs []string
+	// mediaURLs are the URLs the attached media was uploaded from, in the
+	// same order as mediaIDs.
+	mediaURLs []string
+	geo       *geoPoint // nil unless the post is tagged with a location.
+	// contentWarning, if set, hides the status behind it. Only Mastodon
+	// supports it.
+	contentWarning string
+	// replyTo, if set, is the ID of the post this one replies to.
+	replyTo string
+	// replySettings, if set, limits who can reply to the post, as one of
+	// the replies constants.
+	replySettings string
+}
+
+// Who can reply to a post, as set by --reply_settings.
+const (
+	repliesEveryone  = "everyone"
+	repliesFollowing = "following"
+	repliesMentioned = "mentioned"
+)
+
+// validateReplySettings checks that the backend can limit replies as
+// --reply_settings asks. Twitter supports each setting, posting through v2
+// of its API for any but "everyone", while Mastodon can only come close to
+// "mentioned", by making the post direct, so that only the accounts it
+// mentions can see and reply to it.
+func validateReplySettings(bc *backendConfig, settings string) error {
+	switch settings {
+	case "", repliesEveryone:
+		return nil
+	case repliesFollowing, repliesMentioned:
+	default:
+		return fmt.Errorf("%w: unknown --reply_settings %q", ErrConfig, settings)
+	}
+
+	switch {
+	case bc.name == backendTwitter:
+		return nil
+	case bc.name == backendMastodon && settings == repliesMentioned:
+		return nil
+	default:
+		return fmt.Errorf("%w: --reply_settings=%s is not supported by the %s backend", ErrConfig, settings, bc.name)
+	}
+}
+
+const (
+	backendTwitter  = "twitter"
+	backendBluesky  = "bluesky"
+	backendMastodon = "mastodon"
+)
+
+// backendConfig selects and configures the Poster used for a run.
+type backendConfig struct {
+	name   string
+	maxLen int
+	// replyTo is the ID or URL of the post every post replies to, if any.
+	replyTo  string
+	twitter  *twitterConfig
+	bluesky  *blueskyConfig
+	mastodon *mastodonConfig
+}
+
+// newPoster returns the Poster for the configured backend.
+func newPoster(bc *backendConfig) (Poster, error) {
+	switch bc.name {
+	case backendTwitter:
+		return newTwitterPoster(bc.twitter), nil
+	case backendBluesky:
+		return newBlueskyPoster(bc.bluesky, http.DefaultClient), nil
+	case backendMastodon:
+		return newMastodonPoster(bc.mastodon, http.DefaultClient), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", ErrConfig, bc.name)
+	}
+}
+
+// postURL returns the web URL of the post with the given ID, or "" if it
+// can't be known.
+func postURL(bc *backendConfig, id string) string {
+	switch bc.name {
+	case backendTwitter:
+		return "https://twitter.com/i/web/status/" + id
+	case backendBluesky:
+		// IDs are at://DID/app.bsky.feed.post/RKEY URIs.
+		parts := strings.Split(strings.TrimPrefix(id, "at://"), "/")
+		if len(parts) != 3 {
+			return ""
+		}
+		return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", parts[0], parts[2])
+	case backendMastodon:
+		return strings.TrimSuffix(bc.mastodon.server, "/") + "/web/statuses/" + id
+	default:
+		return ""
+	}
+}
+
+// statusLimit returns the maximum status length for the configured backend,
+// preferring an explicit --max_len, then a Mastodon server's own limit.
+func statusLimit(bc *backendConfig) int {
+	if bc.maxLen > 0 {
+		return bc.maxLen
+	}
+	switch bc.name {
+	case backendBluesky:
+		return maxBlueskyPostSize
+	case backendMastodon:
+		if bc.mastodon.maxChars > 0 {
+			return bc.mastodon.maxChars
+		}
+		return maxMastodonPostSize
+	default:
+		return maxTweetSize
+	}
+}
+
+// lengthFunc returns the function measuring statuses for the configured
+// backend.
+func lengthFunc(bc *backendConfig) func(string) int {
+	if bc.name == backendTwitter {
+		if bc.replyTo != "" {
+			return weightedReplyLength
+		}
+		return weightedLength
+	}
+	return runeLength
+}
+
+// truncate returns the longest prefix of s whose length is at most max.
+func truncate(s string, max int, length func(string) int) string {
+	if length(s) <= max {
+		return s
+	}
+	r := []rune(s)
+	for n := len(r) - 1; n > 0; n-- {
+		if prefix := string(r[:n]); length(prefix) <= max {
+			return prefix
+		}
+	}
+	return ""
+}
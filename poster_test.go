@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePoster records the posts made through it, returning their 1-based
+// index as their IDs, or err if it's set.
+type fakePoster struct {
+	mu    sync.Mutex
+	posts []*post
+	err   error
+}
+
+func (f *fakePoster) Post(ctx context.Context, p *post) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return "", f.err
+	}
+	f.posts = append(f.posts, p)
+	return strconv.Itoa(len(f.posts)), nil
+}
+
+// statuses returns the statuses posted, in order.
+func (f *fakePoster) statuses() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var s []string
+	for _, p := range f.posts {
+		s = append(s, p.status)
+	}
+	return s
+}
+
+func TestValidateReplySettings(t *testing.T) {
+	for _, tc := range []struct {
+		backend  string
+		settings string
+		wantErr  bool
+	}{
+		{backend: backendTwitter, settings: ""},
+		{backend: backendTwitter, settings: repliesEveryone},
+		{backend: backendTwitter, settings: repliesFollowing},
+		{backend: backendTwitter, settings: repliesMentioned},
+		{backend: backendTwitter, settings: "nobody", wantErr: true},
+		{backend: backendMastodon, settings: repliesMentioned},
+		{backend: backendMastodon, settings: repliesFollowing, wantErr: true},
+		{backend: backendBluesky, settings: repliesEveryone},
+		{backend: backendBluesky, settings: repliesMentioned, wantErr: true},
+	} {
+		err := validateReplySettings(&backendConfig{name: tc.backend}, tc.settings)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("validateReplySettings(%s, %q) = %v, want error: %t", tc.backend, tc.settings, err, tc.wantErr)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{s: "hello", max: 10, want: "hello"},
+		{s: "hello", max: 5, want: "hello"},
+		{s: "hello", max: 3, want: "hel"},
+		{s: "héllo", max: 2, want: "hé"},
+		{s: "hello", max: 0, want: ""},
+	} {
+		if got := truncate(tc.s, tc.max, runeLength); got != tc.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.s, tc.max, got, tc.want)
+		}
+	}
+}
+
+// fullPoster is a fakePoster that implements every optional interface,
+// recording the calls made to them, prefixed by its name.
+type fullPoster struct {
+	fakePoster
+	name      string
+	remaining int
+	calls     []string
+}
+
+func (f *fullPoster) record(call string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return f.name + ":" + call
+}
+
+func (f *fullPoster) UploadMedia(ctx context.Context, data []byte) (string, error) {
+	return f.record("media"), nil
+}
+
+func (f *fullPoster) UploadVideo(ctx context.Context, data []byte, mimeType string) (string, error) {
+	return f.record("video"), nil
+}
+
+func (f *fullPoster) Repost(ctx context.Context, id string) (string, error) {
+	return f.record("repost " + id), nil
+}
+
+func (f *fullPoster) LatestPostID(ctx context.Context, screenName string) (string, error) {
+	return f.record("latest " + screenName), nil
+}
+
+func (f *fullPoster) RateLimit() (int, time.Time, bool) {
+	return f.remaining, time.Unix(int64(f.remaining), 0), true
+}
+
+func (f *fullPoster) Verify(ctx context.Context) error {
+	f.record("verify")
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// probeRow is a row as printed by --probe with --json.
+type probeRow struct {
+	Row    int               `json:"row"`
+	Values map[string]string `json:"values"`
+}
+
+// probe prints the first n rows read, as a table headed by their column
+// letters or, with --json, as JSON, without rendering or posting them.
+// The values of --redact_columns are redacted, as in any other output.
+func (r *runner) probe(w io.Writer, n int) error {
+	rows, err := r.readRows()
+	if errors.Is(err, ErrNoData) {
+		fmt.Fprintln(w, "no rows")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(rows) > n {
+		rows = rows[:n]
+	}
+	if len(r.rc.redactColumns) > 0 {
+		for i := range rows {
+			rows[i].values = redactRow(rows[i], r.rc.redactColumns)
+		}
+	}
+
+	if r.rc.probeJSON {
+		out := make([]probeRow, len(rows))
+		for i, rw := range rows {
+			out[i] = probeRow{Row: rw.num, Values: make(map[string]string)}
+			for j, v := range rw.values {
+				out[i].Values[columnLetters(rw.firstCol+j)] = fmt.Sprint(v)
+			}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	// The table is as wide as the widest row.
+	first, last := -1, -1
+	for _, rw := range rows {
+		if first < 0 || rw.firstCol < first {
+			first = rw.firstCol
+		}
+		if end := rw.firstCol + len(rw.values) - 1; end > last {
+			last = end
+		}
+	}
+
+	// Cells are kept to one line, in their own column.
+	flatten := strings.NewReplacer("\t", " ", "\n", " ")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	header := []string{"ROW"}
+	for col := first; col <= last; col++ {
+		header = append(header, columnLetters(col))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, rw := range rows {
+		cells := []string{fmt.Sprint(rw.num)}
+		for col := first; col <= last; col++ {
+			cells = append(cells, flatten.Replace(rw.cell(col)))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// staticSource is a RowSource of fixed values.
+type staticSource [][]interface{}
+
+func (s staticSource) ReadRows() ([][]interface{}, error) {
+	return s, nil
+}
+
+func TestProbe(t *testing.T) {
+	source := staticSource{{"Hello", "ada@example.com"}, {"Bye", "bob@example.com", "x"}}
+	for _, tc := range []struct {
+		name    string
+		json    bool
+		redact  map[int]bool
+		n       int
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "table",
+			n:    5,
+			want: []string{"ROW", "A", "B", "C", "Hello", "ada@example.com", "bob@example.com"},
+		},
+		{
+			name:    "first rows",
+			n:       1,
+			want:    []string{"Hello"},
+			notWant: []string{"Bye"},
+		},
+		{
+			name:    "redacted table",
+			n:       5,
+			redact:  map[int]bool{1: true},
+			want:    []string{"Hello", redacted},
+			notWant: []string{"ada@example.com", "bob@example.com"},
+		},
+		{
+			name:    "redacted JSON",
+			json:    true,
+			n:       5,
+			redact:  map[int]bool{1: true},
+			want:    []string{`"A": "Hello"`, `"B": "` + redacted + `"`},
+			notWant: []string{"ada@example.com"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.probeJSON = tc.json
+			rc.redactColumns = tc.redact
+			r := newTestRunner(&fakePoster{}, rc)
+			r.source = source
+
+			var b strings.Builder
+			if err := r.probe(&b, tc.n); err != nil {
+				t.Fatalf("probe() failed: %v", err)
+			}
+			for _, s := range tc.want {
+				if !strings.Contains(b.String(), s) {
+					t.Errorf("probe() printed no %q:\n%s", s, b.String())
+				}
+			}
+			for _, s := range tc.notWant {
+				if strings.Contains(b.String(), s) {
+					t.Errorf("probe() printed %q:\n%s", s, b.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "strings"
+
+// asciiPunctuation replaces typographic punctuation, as pasted from word
+// processors, with its ASCII equivalent.
+var asciiPunctuation = strings.NewReplacer(
+	"“", `"`, // “
+	"”", `"`, // ”
+	"„", `"`, // „
+	"‘", "'", // ‘
+	"’", "'", // ’
+	"‚", "'", // ‚
+	"–", "-", // –
+	"—", "-", // —
+	"…", "...", // …
+)
+
+// normalizePunctuation replaces smart quotes, en and em dashes and
+// ellipses in s with ASCII.
+func normalizePunctuation(s string) string {
+	return asciiPunctuation.Replace(s)
+}
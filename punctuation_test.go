@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestNormalizePunctuation(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "“quoted”", want: `"quoted"`},
+		{in: "„low”", want: `"low"`},
+		{in: "it’s ‘fine’", want: "it's 'fine'"},
+		{in: "‚low’", want: "'low'"},
+		{in: "1–2 — or so", want: "1-2 - or so"},
+		{in: "wait…", want: "wait..."},
+		{in: "café «guillemets»", want: "café «guillemets»"},
+	} {
+		if got := normalizePunctuation(tc.in); got != tc.want {
+			t.Errorf("normalizePunctuation(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// qrCodeSize is the width and height, in pixels, of the QR codes attached
+// with --qr_url_column.
+const qrCodeSize = 512
+
+// qrCodePNG renders a QR code of the URL u as a PNG image.
+func qrCodePNG(u string) ([]byte, error) {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%q is not an http or https URL", u)
+	}
+	return qrcode.Encode(u, qrcode.Medium, qrCodeSize)
+}
+
+// attachQRCode attaches a QR code of the URL u to p, as another image.
+// A QR code that can't be made or uploaded is handled as --on_media_error
+// says, like other media.
+func (r *runner) attachQRCode(ctx context.Context, num int, u string, p *post) error {
+	err := func() error {
+		if len(p.mediaIDs) >= maxImages {
+			return fmt.Errorf("the post already has %d media attachments", len(p.mediaIDs))
+		}
+		for _, m := range p.mediaURLs {
+			if k := mediaKind(m); k != mediaImage {
+				return fmt.Errorf("the %s %q can't be attached alongside other media", k, m)
+			}
+		}
+		uploader, ok := optional[mediaUploader](r.poster)
+		if !ok {
+			return errors.New("the backend does not support media")
+		}
+		png, err := qrCodePNG(u)
+		if err != nil {
+			return err
+		}
+		id, err := uploader.UploadMedia(ctx, png)
+		if err != nil {
+			return err
+		}
+		p.mediaIDs = append(p.mediaIDs, id)
+		return nil
+	}()
+
+	switch {
+	case err == nil:
+		return nil
+	case r.rc.onMediaError == mediaErrorSkip:
+		return fmt.Errorf("%w, as the QR code of %q failed to attach: %v", errSkipRow, u, err)
+	case r.rc.onMediaError == mediaErrorFail:
+		return fmt.Errorf("failed to attach the QR code of %q: %v", u, err)
+	default:
+		log.Printf("warning: row %d: failed to attach the QR code of %q, posting without it: %v", num, u, err)
+		return nil
+	}
+}
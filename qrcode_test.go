@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestQRCodePNGRejectsNonHTTP(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "https://example.com/event"},
+		{in: "http://example.com"},
+		{in: "ftp://example.com/file", wantErr: true},
+		{in: "example.com", wantErr: true},
+		{in: "https://", wantErr: true},
+		{in: "javascript:alert(1)", wantErr: true},
+	} {
+		if _, err := qrCodePNG(tc.in); (err != nil) != tc.wantErr {
+			t.Errorf("qrCodePNG(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+		}
+	}
+}
+
+func TestAttachQRCode(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		poster       Poster
+		url          string
+		mediaURLs    []string
+		mediaIDs     []string
+		onMediaError string
+		wantErr      bool
+		wantSkip     bool
+		wantIDs      int
+	}{
+		{name: "attached", poster: &fullPoster{name: "p"}, url: "https://example.com", wantIDs: 1},
+		{name: "alongside images", poster: &fullPoster{name: "p"}, url: "https://example.com", mediaURLs: []string{"a.png"}, mediaIDs: []string{"1"}, wantIDs: 2},
+		{name: "bad URL posts without", poster: &fullPoster{name: "p"}, url: "example.com", onMediaError: mediaErrorTextOnly},
+		{name: "bad URL skips", poster: &fullPoster{name: "p"}, url: "example.com", onMediaError: mediaErrorSkip, wantErr: true, wantSkip: true},
+		{name: "bad URL fails", poster: &fullPoster{name: "p"}, url: "example.com", onMediaError: mediaErrorFail, wantErr: true},
+		{name: "alongside a video", poster: &fullPoster{name: "p"}, url: "https://example.com", mediaURLs: []string{"v.mp4"}, mediaIDs: []string{"1"}, onMediaError: mediaErrorFail, wantErr: true, wantIDs: 1},
+		{name: "too many images", poster: &fullPoster{name: "p"}, url: "https://example.com", mediaIDs: []string{"1", "2", "3", "4"}, onMediaError: mediaErrorFail, wantErr: true, wantIDs: 4},
+		{name: "no media support", poster: &fakePoster{}, url: "https://example.com", onMediaError: mediaErrorFail, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.onMediaError = tc.onMediaError
+			r := newTestRunner(tc.poster, rc)
+			p := &post{status: "s", mediaURLs: tc.mediaURLs, mediaIDs: tc.mediaIDs}
+
+			err := r.attachQRCode(context.Background(), 2, tc.url, p)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("attachQRCode() = %v, want error: %t", err, tc.wantErr)
+			}
+			if errors.Is(err, errSkipRow) != tc.wantSkip {
+				t.Errorf("attachQRCode() = %v, want skip: %t", err, tc.wantSkip)
+			}
+			if len(p.mediaIDs) != tc.wantIDs {
+				t.Errorf("media IDs = %q, want %d", p.mediaIDs, tc.wantIDs)
+			}
+		})
+	}
+}
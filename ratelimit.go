@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitReporter is implemented by Posters that know how much of their
+// rate limit is left.
+type rateLimitReporter interface {
+	// RateLimit returns the number of requests left in the current window
+	// and when the window resets, as of the latest response that said, or
+	// false if none has.
+	RateLimit() (remaining int, reset time.Time, ok bool)
+}
+
+// rateLimitTransport records the rate limit headers of the responses it
+// carries.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	remaining, rErr := strconv.Atoi(resp.Header.Get("x-rate-limit-remaining"))
+	reset, sErr := strconv.ParseInt(resp.Header.Get("x-rate-limit-reset"), 10, 64)
+	if rErr == nil && sErr == nil {
+		t.mu.Lock()
+		t.known, t.remaining, t.reset = true, remaining, time.Unix(reset, 0)
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *rateLimitTransport) RateLimit() (int, time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining, t.reset, t.known
+}
+
+// logRateLimit logs how much of the backend's rate limit is left, if it's
+// known, to help tune how often and how much is posted.
+func (r *runner) logRateLimit() {
+	rl, ok := optional[rateLimitReporter](r.poster)
+	if !ok {
+		return
+	}
+	if remaining, reset, ok := rl.RateLimit(); ok {
+		log.Printf("rate limit: %d requests remaining until %s", remaining, reset.In(r.rc.location).Format(time.RFC3339))
+	}
+}
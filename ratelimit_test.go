@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	headers := map[string][2]string{
+		"/limited":   {"12", "1700000000"},
+		"/unlimited": {"", ""},
+		"/bad":       {"many", "1700000000"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if h := headers[req.URL.Path]; h[0] != "" {
+			w.Header().Set("x-rate-limit-remaining", h[0])
+			w.Header().Set("x-rate-limit-reset", h[1])
+		}
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name          string
+		paths         []string
+		wantRemaining int
+		wantKnown     bool
+	}{
+		{name: "unknown", paths: []string{"/unlimited"}},
+		{name: "known", paths: []string{"/limited"}, wantRemaining: 12, wantKnown: true},
+		{name: "kept without headers", paths: []string{"/limited", "/unlimited", "/bad"}, wantRemaining: 12, wantKnown: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := &rateLimitTransport{base: http.DefaultTransport}
+			client := &http.Client{Transport: limits}
+			for _, p := range tc.paths {
+				resp, err := client.Get(srv.URL + p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+
+			remaining, reset, known := limits.RateLimit()
+			if remaining != tc.wantRemaining || known != tc.wantKnown {
+				t.Errorf("RateLimit() = %d, %t, want %d, %t", remaining, known, tc.wantRemaining, tc.wantKnown)
+			}
+			if known && !reset.Equal(time.Unix(1700000000, 0)) {
+				t.Errorf("RateLimit() resets at %v, want %v", reset, time.Unix(1700000000, 0))
+			}
+		})
+	}
+}
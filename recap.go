@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// buildRecap returns the statuses of a thread recapping the last n of
+// entries, oldest first: a header, then a post for each entry of its title
+// and link. There are fewer if there are fewer entries, and none if there
+// are none.
+func buildRecap(entries []AuditEntry, n int) []string {
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	header := "Recap of the last post:"
+	if len(entries) > 1 {
+		header = fmt.Sprintf("Recap of the last %d posts:", len(entries))
+	}
+	recap := []string{header}
+	for i, e := range entries {
+		parts := []string{fmt.Sprintf("%d/%d", i+1, len(entries))}
+		if e.Title != "" {
+			parts = append(parts, e.Title)
+		}
+		if e.URL != "" {
+			parts = append(parts, e.URL)
+		}
+		recap = append(recap, strings.Join(parts, " "))
+	}
+	return recap
+}
+
+// postRecap posts, for --recap, a thread recapping the latest posts of the
+// audit log. Its own posts aren't added to the log.
+func (r *runner) postRecap(ctx context.Context) error {
+	entries, err := readAuditLog(r.rc.auditLogPath)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read audit log %q: %w", ErrConfig, r.rc.auditLogPath, err)
+	}
+	recap := buildRecap(entries, r.rc.recap)
+	if len(recap) == 0 {
+		log.Printf("the audit log has no posts to recap")
+		return nil
+	}
+
+	limit, length := statusLimit(r.bc), lengthFunc(r.bc)
+	for i := range recap {
+		recap[i] = truncate(recap[i], limit, length)
+	}
+
+	id, err := r.poster.Post(ctx, &post{status: recap[0]})
+	if err != nil {
+		return fmt.Errorf("%w: failed to post recap: %w", ErrPost, err)
+	}
+	for i, part := range recap[1:] {
+		if id, err = r.poster.Post(ctx, &post{status: part, replyTo: id}); err != nil {
+			return fmt.Errorf("%w: failed to post part %d of %d of the recap: %w", ErrPost, i+2, len(recap), err)
+		}
+	}
+	log.Printf("posted a recap of %d posts", len(recap)-1)
+	return nil
+}
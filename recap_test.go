@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildRecap(t *testing.T) {
+	entries := []AuditEntry{
+		{Row: 2, Title: "First", URL: "https://twitter.com/i/web/status/1"},
+		{Row: 3, URL: "https://twitter.com/i/web/status/2"},
+		{Row: 4, Title: "Third"},
+	}
+	for _, tc := range []struct {
+		name    string
+		entries []AuditEntry
+		n       int
+		want    []string
+	}{
+		{name: "none", n: 3},
+		{
+			name:    "one",
+			entries: entries,
+			n:       1,
+			want:    []string{"Recap of the last post:", "1/1 Third"},
+		},
+		{
+			name:    "latest",
+			entries: entries,
+			n:       2,
+			want:    []string{"Recap of the last 2 posts:", "1/2 https://twitter.com/i/web/status/2", "2/2 Third"},
+		},
+		{
+			name:    "fewer than asked",
+			entries: entries,
+			n:       5,
+			want: []string{
+				"Recap of the last 3 posts:",
+				"1/3 First https://twitter.com/i/web/status/1",
+				"2/3 https://twitter.com/i/web/status/2",
+				"3/3 Third",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := buildRecap(tc.entries, tc.n); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("buildRecap() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+// redacted is shown in place of the values of --redact_columns.
+const redacted = "***"
+
+// redactRow returns the values of r with those in the 0-based sheet
+// columns in redact replaced by redacted. It's only for display: the row
+// itself is left as is, for posting.
+func redactRow(r row, redact map[int]bool) []interface{} {
+	values := make([]interface{}, len(r.values))
+	for i, v := range r.values {
+		if redact[r.firstCol+i] {
+			v = redacted
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// displayStatus returns the status of rw for logs and previews, rendered
+// from its values with --redact_columns redacted.
+func (r *runner) displayStatus(rw row) string {
+	if len(r.rc.redactColumns) > 0 {
+		rw.values = redactRow(rw, r.rc.redactColumns)
+	}
+	status, err := composeStatus(rw, r.bc, r.rc)
+	if err != nil {
+		return "(" + err.Error() + ")"
+	}
+	return status
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactRow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		firstCol int
+		redact   map[int]bool
+		want     []interface{}
+	}{
+		{name: "none", want: []interface{}{"a", "b", "c"}},
+		{name: "second", redact: map[int]bool{1: true}, want: []interface{}{"a", redacted, "c"}},
+		{name: "offset range", firstCol: 2, redact: map[int]bool{1: true, 2: true}, want: []interface{}{redacted, "b", "c"}},
+		{name: "out of range", redact: map[int]bool{7: true}, want: []interface{}{"a", "b", "c"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := row{num: 2, firstCol: tc.firstCol, values: []interface{}{"a", "b", "c"}}
+			if got := redactRow(r, tc.redact); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("redactRow() = %v, want %v", got, tc.want)
+			}
+			if r.values[0] != "a" || r.values[1] != "b" {
+				t.Errorf("redactRow() changed the row to %v", r.values)
+			}
+		})
+	}
+}
+
+func TestDisplayStatus(t *testing.T) {
+	rc := testRunConfig()
+	rc.template = "{0} for {1}"
+	r := newTestRunner(&fakePoster{}, rc)
+	rw := row{num: 2, values: []interface{}{"hello", "alice@example.com"}}
+
+	if got, want := r.displayStatus(rw), "hello for alice@example.com"; got != want {
+		t.Errorf("displayStatus() = %q, want %q", got, want)
+	}
+
+	rc.redactColumns = map[int]bool{1: true}
+	if got, want := r.displayStatus(rw), "hello for "+redacted; got != want {
+		t.Errorf("displayStatus() with a redacted column = %q, want %q", got, want)
+	}
+	if rw.values[1] != "alice@example.com" {
+		t.Errorf("displayStatus() changed the row to %v", rw.values)
+	}
+}
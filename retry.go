@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chimeracoder/anaconda"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+const retryAttempts = 3
+
+// backoff computes the delays between retry attempts: base, growing by
+// factor each attempt, capped at max. With an rng, each delay is jittered
+// to somewhere between half and all of its value.
+type backoff struct {
+	base, max time.Duration
+	factor    float64
+	rng       *rand.Rand
+}
+
+// retryBackoff is the backoff used by retry. It is configured from flags.
+var retryBackoff = &backoff{
+	base:   time.Second,
+	max:    30 * time.Second,
+	factor: 2,
+	rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+}
+
+func (b *backoff) validate() error {
+	if b.base <= 0 {
+		return fmt.Errorf("the base delay must be positive, not %v", b.base)
+	}
+	if b.max < b.base {
+		return fmt.Errorf("the max delay %v is less than the base delay %v", b.max, b.base)
+	}
+	if b.factor < 1 {
+		return fmt.Errorf("the factor must be at least 1, not %v", b.factor)
+	}
+	return nil
+}
+
+// delay returns how long to wait after the given failed attempt, counting
+// from 1.
+func (b *backoff) delay(attempt int) time.Duration {
+	d := float64(b.base) * math.Pow(b.factor, float64(attempt-1))
+	if d > float64(b.max) {
+		d = float64(b.max)
+	}
+	if b.rng != nil {
+		d = d/2 + b.rng.Float64()*d/2
+	}
+	return time.Duration(d)
+}
+
+// retry calls fn until it succeeds or returns an error that shouldRetry
+// rejects, making at most retryAttempts attempts, with retryBackoff between
+// them.
+func retry(shouldRetry func(error) bool, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !shouldRetry(err) {
+			return err
+		}
+		if attempt == retryAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		d := retryBackoff.delay(attempt)
+		log.Printf("attempt %d failed, retrying in %v: %v", attempt, d, err)
+		time.Sleep(d)
+	}
+}
+
+// retryableStatusCodes are the HTTP statuses that isTransient treats as
+// temporary. They are configured from flags.
+var retryableStatusCodes = map[int]bool{429: true, 500: true, 502: true, 503: true, 504: true}
+
+// parseStatusCodes parses a comma-separated list of HTTP status codes.
+func parseStatusCodes(s string) (map[int]bool, error) {
+	codes := make(map[int]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		code, err := strconv.Atoi(f)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("%q is not an HTTP status code", f)
+		}
+		codes[code] = true
+	}
+	return codes, nil
+}
+
+// isTransient reports whether err looks like a temporary failure worth
+// retrying: a network error, or a response from a token endpoint, Sheets
+// or Twitter with one of retryableStatusCodes.
+func isTransient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return retryableStatusCodes[statusCode(err)]
+}
+
+// isUnprocessed reports whether err shows that a request wasn't acted on,
+// so that one that isn't idempotent, such as posting a tweet, can be
+// retried without doing it twice: the connection was never made, or the
+// server turned it away with 429 or 503, if those are among
+// retryableStatusCodes. Other failures, such as a timeout or a 500, may
+// come after the request took effect.
+func isUnprocessed(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	code := statusCode(err)
+	return (code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable) && retryableStatusCodes[code]
+}
+
+// statusCode returns the HTTP status of a failed response from a token
+// endpoint, Sheets or Twitter that err holds, or 0 if it holds none.
+func statusCode(err error) int {
+	var rErr *oauth2.RetrieveError
+	if errors.As(err, &rErr) && rErr.Response != nil {
+		return rErr.Response.StatusCode
+	}
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		return gErr.Code
+	}
+	var aErr *anaconda.ApiError
+	if errors.As(err, &aErr) {
+		return aErr.StatusCode
+	}
+	return 0
+}
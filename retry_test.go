@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/chimeracoder/anaconda"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// fastRetries makes retry wait only briefly between attempts for the rest
+// of the test.
+func fastRetries(t *testing.T) {
+	saved := retryBackoff
+	retryBackoff = &backoff{base: time.Millisecond, max: time.Millisecond, factor: 1}
+	t.Cleanup(func() { retryBackoff = saved })
+}
+
+func TestRetry(t *testing.T) {
+	fastRetries(t)
+	errTransient, errFatal := errors.New("transient"), errors.New("fatal")
+	for _, tc := range []struct {
+		name         string
+		errs         []error // returned by each attempt, then nil.
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "success", wantAttempts: 1},
+		{name: "retried", errs: []error{errTransient, errTransient}, wantAttempts: 3},
+		{name: "gives up", errs: []error{errTransient, errTransient, errTransient}, wantAttempts: 3, wantErr: errTransient},
+		{name: "not retried", errs: []error{errFatal}, wantAttempts: 1, wantErr: errFatal},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			err := retry(func(err error) bool { return err == errTransient }, func() error {
+				attempts++
+				if attempts <= len(tc.errs) {
+					return tc.errs[attempts-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.wantErr) || (err != nil) != (tc.wantErr != nil) {
+				t.Errorf("retry() = %v, want %v", err, tc.wantErr)
+			}
+			if attempts != tc.wantAttempts {
+				t.Errorf("made %d attempts, want %d", attempts, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := &backoff{base: time.Second, max: 5 * time.Second, factor: 2}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := b.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryPredicates(t *testing.T) {
+	saved := retryableStatusCodes
+	retryableStatusCodes = map[int]bool{429: true, 500: true, 503: true}
+	defer func() { retryableStatusCodes = saved }()
+
+	refused := &url.Error{Op: "Post", URL: "https://api.twitter.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	reset := &url.Error{Op: "Post", URL: "https://api.twitter.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}
+	for _, tc := range []struct {
+		name            string
+		err             error
+		wantTransient   bool
+		wantUnprocessed bool
+	}{
+		{name: "connection refused", err: refused, wantTransient: true, wantUnprocessed: true},
+		{name: "connection reset", err: reset, wantTransient: true},
+		{name: "Twitter 429", err: &anaconda.ApiError{StatusCode: 429}, wantTransient: true, wantUnprocessed: true},
+		{name: "Twitter 503", err: &anaconda.ApiError{StatusCode: 503}, wantTransient: true, wantUnprocessed: true},
+		{name: "Twitter 500", err: &anaconda.ApiError{StatusCode: 500}, wantTransient: true},
+		{name: "Twitter 502, not listed", err: &anaconda.ApiError{StatusCode: 502}},
+		{name: "Twitter 403", err: &anaconda.ApiError{StatusCode: 403}},
+		{name: "Sheets 503, wrapped", err: fmt.Errorf("reading: %w", &googleapi.Error{Code: 503}), wantTransient: true, wantUnprocessed: true},
+		{name: "Sheets 400", err: &googleapi.Error{Code: 400}},
+		{name: "token endpoint 429", err: &oauth2.RetrieveError{Response: &http.Response{StatusCode: 429}}, wantTransient: true, wantUnprocessed: true},
+		{name: "token endpoint without a response", err: &oauth2.RetrieveError{}},
+		{name: "other", err: errors.New("bad request")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isTransient(tc.err); got != tc.wantTransient {
+				t.Errorf("isTransient(%v) = %t, want %t", tc.err, got, tc.wantTransient)
+			}
+			if got := isUnprocessed(tc.err); got != tc.wantUnprocessed {
+				t.Errorf("isUnprocessed(%v) = %t, want %t", tc.err, got, tc.wantUnprocessed)
+			}
+		})
+	}
+
+	// 429 and 503 aren't retried when they're left out of the list.
+	retryableStatusCodes = map[int]bool{500: true}
+	if isUnprocessed(&anaconda.ApiError{StatusCode: 503}) {
+		t.Error("isUnprocessed() = true for a 503 that isn't in retryableStatusCodes")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// retryQueue records, across runs, the rows that failed and how many times
+// they've been attempted, so that they are retried first on the next run,
+// until --max_attempts.
+type retryQueue struct {
+	Rows []queuedRow `json:"rows"`
+}
+
+// queuedRow is a row in the retry queue.
+type queuedRow struct {
+	Row      int `json:"row"`
+	Attempts int `json:"attempts"`
+}
+
+// loadRetryQueue reads the queue at path. A missing file is an empty queue.
+func loadRetryQueue(path string) (*retryQueue, error) {
+	q := &retryQueue{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// saveRetryQueue writes q to path atomically.
+func saveRetryQueue(path string, q *retryQueue) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// bumpAttempt records a failed attempt of row num, queueing it if it isn't
+// already, and returns how many attempts it has had.
+func (q *retryQueue) bumpAttempt(num int) int {
+	for i := range q.Rows {
+		if q.Rows[i].Row == num {
+			q.Rows[i].Attempts++
+			return q.Rows[i].Attempts
+		}
+	}
+	q.Rows = append(q.Rows, queuedRow{Row: num, Attempts: 1})
+	return 1
+}
+
+// remove takes row num out of the queue, once it has succeeded.
+func (q *retryQueue) remove(num int) {
+	for i := range q.Rows {
+		if q.Rows[i].Row == num {
+			q.Rows = append(q.Rows[:i], q.Rows[i+1:]...)
+			return
+		}
+	}
+}
+
+// order puts the queued rows first, in the order they were queued, followed
+// by the rest. Rows already attempted maxAttempts times are given up on and
+// returned separately.
+func (q *retryQueue) order(rows []row, maxAttempts int) (ordered, givenUp []row) {
+	byNum := make(map[int]row)
+	for _, r := range rows {
+		byNum[r.num] = r
+	}
+
+	queued := make(map[int]bool)
+	for _, qr := range q.Rows {
+		r, ok := byNum[qr.Row]
+		if !ok {
+			continue
+		}
+		queued[qr.Row] = true
+		if qr.Attempts >= maxAttempts {
+			givenUp = append(givenUp, r)
+			continue
+		}
+		ordered = append(ordered, r)
+	}
+
+	for _, r := range rows {
+		if !queued[r.num] {
+			ordered = append(ordered, r)
+		}
+	}
+	return ordered, givenUp
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRetryQueueBumpAndRemove(t *testing.T) {
+	q := &retryQueue{}
+	for _, tc := range []struct {
+		num  int
+		want int
+	}{
+		{num: 5, want: 1},
+		{num: 3, want: 1},
+		{num: 5, want: 2},
+		{num: 5, want: 3},
+	} {
+		if got := q.bumpAttempt(tc.num); got != tc.want {
+			t.Errorf("bumpAttempt(%d) = %d, want %d", tc.num, got, tc.want)
+		}
+	}
+
+	q.remove(5)
+	q.remove(42)
+	if want := []queuedRow{{Row: 3, Attempts: 1}}; !reflect.DeepEqual(q.Rows, want) {
+		t.Errorf("queue = %+v, want %+v", q.Rows, want)
+	}
+}
+
+func TestRetryQueueOrder(t *testing.T) {
+	rows := testRows("a", "b", "c", "d", "e")
+	for _, tc := range []struct {
+		name        string
+		queued      []queuedRow
+		maxAttempts int
+		wantOrdered []int
+		wantGivenUp []int
+	}{
+		{name: "empty", maxAttempts: 3, wantOrdered: []int{2, 3, 4, 5, 6}},
+		{
+			name:        "queued first in queue order",
+			queued:      []queuedRow{{Row: 5, Attempts: 1}, {Row: 3, Attempts: 2}},
+			maxAttempts: 3,
+			wantOrdered: []int{5, 3, 2, 4, 6},
+		},
+		{
+			name:        "given up",
+			queued:      []queuedRow{{Row: 5, Attempts: 3}, {Row: 3, Attempts: 1}},
+			maxAttempts: 3,
+			wantOrdered: []int{3, 2, 4, 6},
+			wantGivenUp: []int{5},
+		},
+		{
+			name:        "queued row no longer present",
+			queued:      []queuedRow{{Row: 99, Attempts: 1}},
+			maxAttempts: 3,
+			wantOrdered: []int{2, 3, 4, 5, 6},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &retryQueue{Rows: tc.queued}
+			ordered, givenUp := q.order(rows, tc.maxAttempts)
+			if got := rowNums(ordered); !reflect.DeepEqual(got, tc.wantOrdered) {
+				t.Errorf("ordered = %v, want %v", got, tc.wantOrdered)
+			}
+			if got := rowNums(givenUp); !reflect.DeepEqual(got, tc.wantGivenUp) {
+				t.Errorf("given up = %v, want %v", got, tc.wantGivenUp)
+			}
+		})
+	}
+}
+
+func TestRetryQueueRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := loadRetryQueue(path)
+	if err != nil {
+		t.Fatalf("loadRetryQueue(missing) = %v", err)
+	}
+	q.bumpAttempt(4)
+	q.bumpAttempt(4)
+	if err := saveRetryQueue(path, q); err != nil {
+		t.Fatalf("saveRetryQueue() = %v", err)
+	}
+	loaded, err := loadRetryQueue(path)
+	if err != nil {
+		t.Fatalf("loadRetryQueue() = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Rows, q.Rows) {
+		t.Errorf("loaded queue = %+v, want %+v", loaded.Rows, q.Rows)
+	}
+}
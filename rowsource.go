@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// RowSource supplies the values of the rows to post, in place of the sheet.
+type RowSource interface {
+	// ReadRows returns the values of each row, in order.
+	ReadRows() ([][]interface{}, error)
+}
+
+// Input formats of --input_file.
+const (
+	formatCSV = "csv"
+	formatTSV = "tsv"
+)
+
+// fileSource reads rows of comma- or tab-separated values from a file, or
+// from stdin if its path is "-". Fields may be quoted, to hold the
+// separator, quotes or newlines.
+type fileSource struct {
+	path string
+	sep  rune
+	// lazyQuotes allows quotes in unquoted fields, and unescaped quotes in
+	// quoted ones, as messy files have.
+	lazyQuotes bool
+}
+
+// newFileSource returns a fileSource for the file at path in the given
+// format. If delimiter isn't empty, it's the separator instead of the
+// format's own.
+func newFileSource(path, format, delimiter string, lazyQuotes bool) (*fileSource, error) {
+	s := &fileSource{path: path, lazyQuotes: lazyQuotes}
+	switch format {
+	case formatCSV:
+		s.sep = ','
+	case formatTSV:
+		s.sep = '\t'
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+
+	if delimiter != "" {
+		r := []rune(delimiter)
+		if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' || r[0] == utf8.RuneError {
+			return nil, fmt.Errorf("bad delimiter %q: it must be a single character other than a quote or newline", delimiter)
+		}
+		s.sep = r[0]
+	}
+	return s, nil
+}
+
+func (s *fileSource) String() string {
+	if s.path == "-" {
+		return "stdin"
+	}
+	return fmt.Sprintf("input file %q", s.path)
+}
+
+func (s *fileSource) ReadRows() ([][]interface{}, error) {
+	if s.path == "-" {
+		return readSeparated(os.Stdin, s.sep, s.lazyQuotes)
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSeparated(f, s.sep, s.lazyQuotes)
+}
+
+// readSeparated reads rows of values separated by sep from r. Rows may
+// have different numbers of values, as a sheet's may.
+func readSeparated(r io.Reader, sep rune, lazyQuotes bool) ([][]interface{}, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = sep
+	cr.LazyQuotes = lazyQuotes
+	cr.FieldsPerRecord = -1
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]interface{}, len(records))
+	for i, rec := range records {
+		rows[i] = make([]interface{}, len(rec))
+		for j, v := range rec {
+			rows[i][j] = v
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewFileSource(t *testing.T) {
+	for _, tc := range []struct {
+		format, delimiter string
+		wantSep           rune
+		wantErr           bool
+	}{
+		{format: formatCSV, wantSep: ','},
+		{format: formatTSV, wantSep: '\t'},
+		{format: formatCSV, delimiter: ";", wantSep: ';'},
+		{format: formatTSV, delimiter: "|", wantSep: '|'},
+		{format: formatCSV, delimiter: "§", wantSep: '§'},
+		{format: formatCSV, delimiter: ";;", wantErr: true},
+		{format: formatCSV, delimiter: `"`, wantErr: true},
+		{format: formatCSV, delimiter: "\n", wantErr: true},
+		{format: "xlsx", wantErr: true},
+	} {
+		s, err := newFileSource("rows", tc.format, tc.delimiter, false)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("newFileSource(%q, %q) = %v, want error: %t", tc.format, tc.delimiter, err, tc.wantErr)
+			continue
+		}
+		if err == nil && s.sep != tc.wantSep {
+			t.Errorf("newFileSource(%q, %q) separates by %q, want %q", tc.format, tc.delimiter, s.sep, tc.wantSep)
+		}
+	}
+}
+
+func TestReadSeparated(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		in         string
+		sep        rune
+		lazyQuotes bool
+		want       [][]interface{}
+		wantErr    bool
+	}{
+		{
+			name: "ragged rows",
+			in:   "a,b,c\nd\n",
+			sep:  ',',
+			want: [][]interface{}{{"a", "b", "c"}, {"d"}},
+		},
+		{
+			name: "quoted fields",
+			in:   "\"a,b\",\"say \"\"hi\"\"\",\"two\nlines\"\n",
+			sep:  ',',
+			want: [][]interface{}{{"a,b", `say "hi"`, "two\nlines"}},
+		},
+		{
+			name: "semicolons",
+			in:   "a;b,c\n",
+			sep:  ';',
+			want: [][]interface{}{{"a", "b,c"}},
+		},
+		{
+			name:    "stray quote",
+			in:      "a,b \"c\" d\n",
+			sep:     ',',
+			wantErr: true,
+		},
+		{
+			name:       "stray quote with lazy quotes",
+			in:         "a,b \"c\" d\n",
+			sep:        ',',
+			lazyQuotes: true,
+			want:       [][]interface{}{{"a", `b "c" d`}},
+		},
+		{
+			name: "empty",
+			in:   "",
+			sep:  ',',
+			want: [][]interface{}{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readSeparated(strings.NewReader(tc.in), tc.sep, tc.lazyQuotes)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("readSeparated() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("readSeparated() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
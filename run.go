@@ -0,0 +1,1120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	calendar "google.golang.org/api/calendar/v3"
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// runner holds the configuration and clients of a run.
+type runner struct {
+	sc *sheetsConfig
+	bc *backendConfig
+	rc *runConfig
+
+	srv      *sheets.Service // nil if rows are read from source.
+	source   RowSource       // nil unless --input_file is set.
+	poster   Poster
+	audit    *auditLog         // nil unless --audit_log is set.
+	state    *postState        // nil unless --state_file is set.
+	calendar *calendar.Service // nil unless --calendar_id is set.
+	media    *mediaCache       // nil unless --media_download_concurrency is above 1.
+	now      func() time.Time
+
+	ranges       []*sheetRange       // the ranges rows are read from.
+	statusColumn string              // where rows are marked complete, if set.
+	exportRender func(string) string // nil unless --export_file is set.
+	// rowErrors holds why each row of the current run failed, by its
+	// number; nil unless --error_column is set.
+	rowErrors map[int]string
+	// budget is what's left of --daily_char_budget today; nil if it's
+	// unset.
+	budget *charBudget
+	// deferred is whether the run left rows for the next one, as it does
+	// when they'd exceed the budget.
+	deferred bool
+	// passedOver holds the numbers of the rows of the current run that
+	// were skipped for now rather than for good, such as those denied by
+	// moderation, so that the checkpoint doesn't move past them.
+	passedOver []int
+	// explain records the decisions of the current run about each row;
+	// nil unless --explain is set.
+	explain *explainer
+	// router picks the account that posts each row; nil unless
+	// --accounts_file is set.
+	router *accountRouter
+}
+
+// run tweets the pending rows and marks them complete.
+//
+// With --daily, it does nothing if there was already a successful run on
+// the current day in --timezone. Nor does it on --skip_days or holidays,
+// or outside of --active_hours.
+func (r *runner) run(ctx context.Context) error {
+	start := r.now()
+	if local := start.In(r.rc.location); !shouldRunOn(local, r.rc.skipDays, r.rc.holidays) {
+		log.Printf("not running on %s, %s, which is skipped", local.Weekday(), local.Format(dayFormat))
+		return nil
+	}
+	if local := start.In(r.rc.location); !withinActiveHours(local, r.rc.activeHours) {
+		log.Printf("not running at %s, outside of --active_hours", local.Format("15:04"))
+		return nil
+	}
+
+	var cp *checkpoint
+	if r.rc.checkpointFile != "" {
+		cp = loadCheckpoint(r.rc.checkpointFile)
+	}
+	today := start.In(r.rc.location).Format(dayFormat)
+	if r.rc.daily && cp.LastDay == today {
+		log.Printf("already ran today, %s", today)
+		return nil
+	}
+
+	r.explain = nil
+	if r.rc.explain {
+		r.explain = newExplainer()
+		defer r.explain.log()
+	}
+
+	rows, err := r.readRows()
+	if errors.Is(err, ErrNoData) {
+		r.reportEmpty(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	// With --modified_column, edited rows anywhere in the sheet are
+	// tweeted, so the checkpoint's time is used rather than its row. The
+	// row of --only_row is posted wherever it is.
+	if cp != nil && r.rc.onlyRow == 0 {
+		read := rows
+		if r.rc.modifiedColumn >= 0 {
+			rows = filterModifiedSince(rows, r.rc.modifiedColumn, cp.LastRun, r.rc.location)
+			r.explain.noteRows(dropped(read, rows), "left out, as it wasn't modified since the last run")
+		} else {
+			rows = cp.pending(rows)
+			r.explain.noteRows(dropped(read, rows), "left out, as it's before the checkpoint's row %d", cp.LastRow)
+		}
+	}
+	candidates := rows
+
+	var aged []row
+	if r.rc.maxAge > 0 {
+		rows, aged = filterByAge(rows, r.rc.dateColumn, r.rc.maxAge, start.In(r.rc.location))
+		log.Printf("skipping %d rows older than %v", len(aged), r.rc.maxAge)
+		r.explain.noteRows(aged, "skipped, as it's older than --max_age")
+	}
+
+	if len(rows) == 0 {
+		r.reportEmpty(ctx)
+	}
+
+	if r.rc.exportOnly {
+		return r.export(rows)
+	}
+	if r.rc.planOut != "" {
+		return r.makePlan(ctx, rows)
+	}
+
+	// Rows tweeted before a failure are still marked, so that they are not
+	// tweeted again on the next run.
+	var queue *retryQueue
+	if r.rc.retryQueueFile != "" {
+		if queue, err = loadRetryQueue(r.rc.retryQueueFile); err != nil {
+			return fmt.Errorf("failed to load retry queue %q: %v", r.rc.retryQueueFile, err)
+		}
+		var givenUp []row
+		rows, givenUp = queue.order(rows, r.rc.maxAttempts)
+		for _, rw := range givenUp {
+			log.Printf("row %d: skipping row, which failed %d times", rw.num, r.rc.maxAttempts)
+		}
+		r.explain.noteRows(givenUp, "skipped, as it failed %d times", r.rc.maxAttempts)
+	}
+
+	// Rows collapsed into another's post are marked complete along with
+	// it.
+	var collapsed map[int][]row
+	if r.rc.collapseDuplicates {
+		rows, collapsed = r.collapseDuplicates(rows)
+		for num, rs := range collapsed {
+			r.explain.noteRows(rs, "collapsed into row %d, as its status is the same", num)
+		}
+	}
+
+	tweet := r.tweet
+	if r.rc.digest {
+		tweet = r.tweetDigest
+	}
+	if r.sc.errorColumn != "" {
+		r.rowErrors = make(map[int]string)
+	}
+	r.deferred = false
+	r.passedOver = nil
+	if r.rc.dailyCharBudget > 0 {
+		r.budget = cp.budgetFor(today, r.rc.dailyCharBudget)
+	}
+	tweeted, failed, tweetErr := tweet(ctx, rows)
+	if len(tweeted) > 0 {
+		r.logRateLimit()
+	}
+	if r.sc.errorColumn != "" && !r.rc.markOnly {
+		if err := r.writeErrors(tweeted, failed); err != nil {
+			log.Printf("warning: failed to write errors to --error_column: %v", err)
+		}
+	}
+	for _, rw := range tweeted {
+		tweeted = append(tweeted, collapsed[rw.num]...)
+	}
+	if queue != nil && !r.rc.markOnly {
+		for _, rw := range tweeted {
+			queue.remove(rw.num)
+		}
+		for _, num := range failed {
+			if n := queue.bumpAttempt(num); n >= r.rc.maxAttempts {
+				log.Printf("row %d: giving up on row after %d attempts", num, n)
+			}
+		}
+		if err := saveRetryQueue(r.rc.retryQueueFile, queue); err != nil {
+			return fmt.Errorf("failed to save retry queue: %v", err)
+		}
+	}
+	toMark := tweeted
+	if r.rc.markAged {
+		toMark = append(aged, tweeted...)
+	}
+	marked, err := r.markComplete(toMark)
+	reportUnmarked(toMark, marked)
+	if err != nil {
+		return fmt.Errorf("%w: failed to mark Tweeted data as complete: %w", ErrSheetWrite, err)
+	}
+
+	// --mark_only doesn't really tweet, and --only_row posts a row out of
+	// order, so neither may move the checkpoint.
+	if cp != nil && !r.rc.markOnly && r.rc.onlyRow == 0 {
+		// A run that got through every row has dealt with each, one way or
+		// another, but for those passed over or failed, which are tried
+		// again.
+		processed := append(aged, tweeted...)
+		if tweetErr == nil && !r.deferred {
+			processed = candidates
+		}
+		cp.advance(candidates, processed, append(r.passedOver, failed...))
+		if tweetErr == nil {
+			cp.LastRun = start
+			cp.LastDay = today
+		}
+		if r.budget != nil {
+			cp.BudgetDay, cp.BudgetUsed = today, r.budget.used
+		}
+		if err := saveCheckpoint(r.rc.checkpointFile, cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %v", err)
+		}
+	}
+
+	if r.exportRender != nil && !r.rc.markOnly {
+		if err := r.export(tweeted); err != nil {
+			return err
+		}
+	}
+
+	if tweetErr != nil {
+		return fmt.Errorf("%w: %w", ErrPost, tweetErr)
+	}
+
+	// Rows only marked complete, such as empty or collapsed ones, weren't
+	// posted, so they don't count.
+	posted := 0
+	for _, rw := range tweeted {
+		if rw.postID != "" {
+			posted++
+		}
+	}
+	if posted < r.rc.expectMin {
+		return fmt.Errorf("%w: tweeted %d rows, but expected at least %d", ErrTooFew, posted, r.rc.expectMin)
+	}
+
+	return nil
+}
+
+// serve runs a previewServer for the pending rows on --serve's address.
+func (r *runner) serve() error {
+	token, err := newServeToken()
+	if err != nil {
+		return err
+	}
+	ps := &previewServer{
+		token: token,
+		pending: func() ([]row, error) {
+			rows, err := r.readRows()
+			if errors.Is(err, ErrNoData) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if r.rc.maxAge > 0 {
+				rows, _ = filterByAge(rows, r.rc.dateColumn, r.rc.maxAge, r.now().In(r.rc.location))
+			}
+			return rows, nil
+		},
+		render: r.displayStatus,
+		publish: func(ctx context.Context, rw row) error {
+			tweeted, _, err := r.tweet(ctx, []row{rw})
+			if _, err := r.markComplete(tweeted); err != nil {
+				return fmt.Errorf("%w: %w", ErrSheetWrite, err)
+			}
+			return err
+		},
+	}
+
+	addr := serveAddress(r.rc.serveAddr)
+	log.Printf("serving pending posts on http://%s/", addr)
+	return http.ListenAndServe(addr, ps.handler())
+}
+
+// export appends the full, untruncated statuses of rows to the export file.
+func (r *runner) export(rows []row) error {
+	var statuses []string
+	for _, rw := range rows {
+		status, err := renderStatus(rw, r.rc)
+		if err != nil {
+			log.Printf("warning: row %d: not exporting row: %v", rw.num, err)
+			continue
+		}
+		if strings.TrimSpace(status) == "" {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	if err := exportStatuses(r.rc.exportFile, r.exportRender, statuses); err != nil {
+		return fmt.Errorf("failed to export to %q: %v", r.rc.exportFile, err)
+	}
+	return nil
+}
+
+// readRows reads the rows in each of the ranges, in turn, leaving out those
+// already marked complete. With --input_file or --gviz_query, it reads all
+// of the file's or query's rows instead.
+func (r *runner) readRows() ([]row, error) {
+	if r.source != nil {
+		values, err := r.source.ReadRows()
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read %v: %w", ErrSheetRead, r.source, err)
+		}
+		if len(values) < 1 {
+			return nil, ErrNoData
+		}
+		// Rows are numbered by line, or by their order in the query's
+		// results, from column A.
+		rows := make([]row, len(values))
+		for i, v := range values {
+			rows[i] = row{num: i + 1, values: v}
+		}
+		r.explain.noteRows(rows, "read from %v", r.source)
+		if r.rc.onlyRow > 0 {
+			return selectRow(rows, r.rc.onlyRow)
+		}
+		return rows, nil
+	}
+
+	var rows []row
+	for _, sr := range r.ranges {
+		rs, err := r.readRange(sr)
+		if errors.Is(err, ErrNoData) && len(r.ranges) > 1 {
+			log.Printf("no data found in %s", sr)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, rs...)
+	}
+	if len(rows) == 0 && len(r.ranges) > 1 {
+		return nil, ErrNoData
+	}
+	return rows, nil
+}
+
+// readRange reads the rows of sr, widened to cover the columns used by the
+// template or --columns, leaving out those already marked complete.
+func (r *runner) readRange(sr *sheetRange) ([]row, error) {
+	cellRange := sr.cellRange
+	if r.rc.columns != nil {
+		max := -1
+		for _, col := range r.rc.columns {
+			if col > max {
+				max = col
+			}
+		}
+		if wider := ensureRangeCovers(cellRange, max-sr.rng.startCol); wider != cellRange {
+			log.Printf("warning: --columns includes column %s, which is outside of the read range %q; reading %q instead",
+				columnLetters(max), cellRange, wider)
+			cellRange = wider
+		}
+	} else if col := maxColumnReferenced(r.rc.template); col >= 0 {
+		if wider := ensureRangeCovers(cellRange, col); wider != cellRange {
+			log.Printf("warning: the template references column %s, which is outside of the read range %q; reading %q instead",
+				columnLetters(sr.rng.startCol+col), cellRange, wider)
+			cellRange = wider
+		}
+	}
+
+	rg := fmt.Sprintf("%s!%s", sr.name, cellRange)
+	var resp *sheets.ValueRange
+	err := retry(isTransient, func() error {
+		var err error
+		resp, err = r.srv.Spreadsheets.Values.Get(sr.id, rg).MajorDimension(r.sc.majorDimension).Do()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w with id=%q and range=%q: %w", ErrSheetRead, sr.id, rg, err)
+	}
+
+	if len(resp.Values) < 1 {
+		return nil, ErrNoData
+	}
+
+	// With --major_dimension=COLUMNS, each of the range's columns is a
+	// row, numbered by its column, counting from 1, whose values are read
+	// down the column.
+	rows := make([]row, len(resp.Values))
+	for i, values := range resp.Values {
+		if r.sc.majorDimension == dimensionColumns {
+			rows[i] = row{num: sr.rng.startCol + i + 1, values: values, sheet: sr}
+			continue
+		}
+		rows[i] = row{num: sr.rng.startRow + i, firstCol: sr.rng.startCol, values: values, sheet: sr}
+	}
+	r.explain.noteRows(rows, "read from %s", sr)
+
+	if r.sc.overridesRange != "" {
+		rg := r.sc.overridesRange
+		if !strings.Contains(rg, "!") {
+			rg = fmt.Sprintf("%s!%s", sr.name, rg)
+		}
+		resp, err := r.srv.Spreadsheets.Values.Get(sr.id, rg).Do()
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read overrides range %q: %w", ErrSheetRead, rg, err)
+		}
+		mergeOverrides(rows, resp.Values)
+	}
+
+	if r.rc.onlyRow > 0 {
+		if rows, err = selectRow(rows, r.rc.onlyRow); err != nil {
+			return nil, err
+		}
+	}
+
+	if sr.view != nil {
+		read := rows
+		rows = sr.view.filter(rows)
+		r.explain.noteRows(dropped(read, rows), "left out by the filter view")
+	}
+
+	if r.statusColumn != "" && !r.rc.force {
+		read := rows
+		rows, err = r.pendingRows(sr, rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read status column %q: %w", ErrSheetRead, r.statusColumn, err)
+		}
+		r.explain.noteRows(dropped(read, rows), "left out, as it's already marked complete in column %s", r.statusColumn)
+		if r.rc.onlyRow > 0 && len(read) > 0 && len(rows) == 0 {
+			log.Printf("row %d is already marked complete; use --force to post it again", read[0].num)
+		}
+	}
+
+	return rows, nil
+}
+
+// selectRow returns just the nth of rows, counting from 1, for --only_row.
+func selectRow(rows []row, n int) ([]row, error) {
+	if n > len(rows) {
+		return nil, fmt.Errorf("%w: --only_row=%d, but only %d rows were read", ErrConfig, n, len(rows))
+	}
+	return rows[n-1 : n], nil
+}
+
+// pendingRows returns the rows of sr whose cell in the status column is
+// empty.
+func (r *runner) pendingRows(sr *sheetRange, rows []row) ([]row, error) {
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	first, last := rows[0].num, rows[len(rows)-1].num
+	rg := fmt.Sprintf("%s!%s%d:%s%d", sr.name, r.statusColumn, first, r.statusColumn, last)
+	resp, err := r.srv.Spreadsheets.Values.Get(sr.id, rg).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []row
+	for _, rw := range rows {
+		i := rw.num - first
+		if i < len(resp.Values) && len(resp.Values[i]) > 0 && fmt.Sprint(resp.Values[i][0]) != "" {
+			continue
+		}
+		pending = append(pending, rw)
+	}
+	return pending, nil
+}
+
+// errEmptyStatus is returned for a row whose status is empty, or only
+// whitespace, such as one whose template refers only to empty cells.
+var errEmptyStatus = errors.New("the status is empty")
+
+// composeStatus renders the status for r, with its hashtags, truncated to
+// fit the backend. With --thread, it's left whole, to be split into a
+// thread instead.
+func composeStatus(r row, bc *backendConfig, rc *runConfig) (string, error) {
+	status, err := renderStatus(r, rc)
+	if err != nil {
+		return "", err
+	}
+	if r.edited != "" {
+		status = r.edited
+	}
+	if strings.TrimSpace(status) == "" {
+		return "", errEmptyStatus
+	}
+	if rc.asciiPunctuation {
+		status = normalizePunctuation(status)
+	}
+
+	// A link's URL is appended to the status, tagged with any UTM
+	// parameters; Twitter shows a card for it. Like any URL, it counts as
+	// tweetURLWeight, however long it is.
+	var suffix string
+	if link := r.cell(rc.cardURLColumn); link != "" {
+		if tagged, err := tagURL(link, rc.utm); err != nil {
+			log.Printf("warning: row %d: not linking %q: %v", r.num, link, err)
+		} else {
+			suffix = " " + tagged
+		}
+	}
+
+	// A quoted tweet's URL is appended to the status, which Twitter turns
+	// into a quote tweet.
+	if quote := r.cell(rc.quoteColumn); quote != "" {
+		if _, err := tweetIDFromURL(quote); err != nil {
+			log.Printf("warning: row %d: not quoting %q: %v", r.num, quote, err)
+		} else {
+			suffix += " " + quote
+		}
+	}
+
+	// Hashtags go between the status and any link's or quoted tweet's URL. They may
+	// take up to half of what's left, so the status itself isn't crowded out.
+	length := lengthFunc(bc)
+	budget := statusLimit(bc) - length(suffix)
+	suffix = fitHashtags(rowHashtags(r, rc), budget/2, length) + suffix
+
+	if rc.thread {
+		return status + suffix, nil
+	}
+	return truncate(status, statusLimit(bc)-length(suffix), length) + suffix, nil
+}
+
+// tweet posts a status, composed by composeStatus, for each row and returns
+// the rows that were tweeted. With --mark_only nothing is posted, but every
+// row is reported as tweeted. Each post is recorded in the audit log.
+//
+// With --spread_window, posts are spread across the window, as given by
+// computeSpreadTimes, and rows left when it has elapsed are not posted.
+//
+// A row denied by the moderation hook is skipped, as is one whose status
+// was already posted within --dedupe_window, according to the state file,
+// and one whose status is empty, which with --mark_empty is reported as
+// tweeted so that it's marked complete.
+// A row whose media fails to upload is handled as --on_media_error says:
+// it's skipped, posted without the media, or reported as failed once the
+// other rows have been tweeted, as is a row that fails to render, to
+// transform with --transform_cmd, to validate with --validate or to be
+// moderated, or to be posted within --per_tweet_timeout, though a row whose
+// transform command exits with an error is just skipped. The numbers of
+// the failed rows, including one that failed to post, are returned too.
+func (r *runner) tweet(ctx context.Context, rows []row) ([]row, []int, error) {
+	var tweeted []row
+	var failed []int
+
+	// With --continue_thread_for, each post replies to the one before it,
+	// and the first to the account's latest tweet.
+	var parent string
+	if r.rc.continueThreadFor != "" && !r.rc.markOnly {
+		tr, ok := optional[timelineReader](r.poster)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: the %s backend can't continue a thread", ErrConfig, r.bc.name)
+		}
+		var err error
+		if parent, err = tr.LatestPostID(ctx, r.rc.continueThreadFor); err != nil {
+			return nil, nil, fmt.Errorf("failed to find the latest tweet of %q: %w", r.rc.continueThreadFor, err)
+		}
+		if parent == "" {
+			log.Printf("%q has no tweets, so starting a new thread", r.rc.continueThreadFor)
+		}
+	}
+
+	var spread []time.Duration
+	start := r.now()
+	if r.rc.spreadWindow > 0 && !r.rc.markOnly {
+		spread = computeSpreadTimes(len(rows), r.rc.spreadWindow, r.rc.spreadSeed)
+	}
+
+	// With --media_download_concurrency, the media of the next rows is
+	// downloaded while each is posted, a row entering the window as one
+	// leaves it.
+	prefetch := func(i int) {
+		if r.media != nil && !r.rc.markOnly && i < len(rows) {
+			r.media.prefetch(ctx, rowMedia(rows[i], r.rc))
+		}
+	}
+	if r.media != nil {
+		for i := 0; i < r.media.lookahead; i++ {
+			prefetch(i)
+		}
+	}
+
+	for i, rw := range rows {
+		if r.media != nil {
+			prefetch(i + r.media.lookahead)
+		}
+		if rw.overrides.Skip {
+			log.Printf("row %d: skipping row, as its overrides say to", rw.num)
+			r.explain.note(rw.num, "skipped, as its overrides say to")
+			continue
+		}
+
+		if blankRow(rw) {
+			log.Printf("row %d: skipping empty row", rw.num)
+			r.explain.note(rw.num, "skipped, as it's empty")
+			continue
+		}
+
+		// With --accounts_file, the row, along with its media and replies,
+		// is posted from the account chosen for it, which postCtx carries.
+		postCtx := ctx
+		if r.router != nil {
+			name, err := r.router.choose(rw.cell(r.rc.accountColumn))
+			if err != nil {
+				log.Printf("row %d: skipping row: %v", rw.num, err)
+				failed = append(failed, r.failRow(rw.num, err))
+				continue
+			}
+			log.Printf("row %d: posting from account %q", rw.num, name)
+			postCtx = withAccount(ctx, name)
+		}
+
+		if target := rw.cell(r.rc.retweetColumn); target != "" {
+			rtID, err := r.retweet(postCtx, rw, target)
+			if err != nil {
+				log.Printf("row %d: skipping row: %v", rw.num, err)
+				failed = append(failed, r.failRow(rw.num, err))
+				continue
+			}
+			r.explain.note(rw.num, "retweeted %s", target)
+			rw.postID = rtID
+			tweeted = append(tweeted, rw)
+			continue
+		}
+
+		status, err := composeStatus(rw, r.bc, r.rc)
+		if err == nil && r.rc.transformCmd != "" {
+			status, err = r.transformStatus(ctx, status)
+		}
+		if errors.Is(err, errEmptyStatus) || errors.Is(err, errTransformRejected) {
+			log.Printf("warning: row %d: skipping row: %v", rw.num, err)
+			r.explain.note(rw.num, "skipped: %v", err)
+			if errors.Is(err, errEmptyStatus) && r.rc.markEmpty {
+				tweeted = append(tweeted, rw)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("row %d: skipping row: %v", rw.num, err)
+			failed = append(failed, r.failRow(rw.num, err))
+			continue
+		}
+
+		// The first part of a thread, or its header, is posted for the
+		// row, and the rest as replies once it has been.
+		var rest []string
+		if r.rc.thread {
+			parts := composeThread(rw, status, r.bc, r.rc)
+			status, rest = parts[0], parts[1:]
+		}
+
+		if r.rc.validate && r.bc.name == backendTwitter {
+			if err := validateTweet(status, statusLimit(r.bc), lengthFunc(r.bc)); err != nil {
+				log.Printf("row %d: skipping invalid tweet: %v", rw.num, err)
+				failed = append(failed, r.failRow(rw.num, err))
+				continue
+			}
+		}
+		p := &post{status: status, contentWarning: rw.cell(r.rc.cwColumn), replySettings: r.rc.replySettings}
+		replyTo := rw.overrides.ReplyTo
+		if replyTo == "" {
+			replyTo = r.bc.replyTo
+		}
+		if replyTo != "" {
+			if r.bc.name == backendBluesky {
+				log.Printf("warning: row %d: not replying, as the %s backend doesn't support it", rw.num, r.bc.name)
+			} else if p.replyTo, err = replyToID(replyTo); err != nil {
+				log.Printf("row %d: skipping row with a bad reply-to %q: %v", rw.num, replyTo, err)
+				failed = append(failed, r.failRow(rw.num, fmt.Errorf("bad reply-to %q: %v", replyTo, err)))
+				continue
+			}
+		}
+		if r.rc.latColumn >= 0 && r.rc.longColumn >= 0 {
+			if p.geo, err = parseGeo(rw.cell(r.rc.latColumn), rw.cell(r.rc.longColumn)); err != nil {
+				log.Printf("warning: row %d: posting without a location: %v", rw.num, err)
+			}
+		}
+
+		if r.rc.markOnly {
+			log.Printf("mark_only: not tweeting row %d: %q", rw.num, r.displayStatus(rw))
+			r.explain.note(rw.num, "marked complete without posting, with --mark_only")
+			tweeted = append(tweeted, rw)
+			continue
+		}
+
+		if r.state != nil && !r.rc.force && r.state.recentlyPosted(p.status, r.rc.dedupeWindow, r.now()) {
+			log.Printf("row %d: skipping row, already posted: %q", rw.num, r.displayStatus(rw))
+			r.explain.note(rw.num, "skipped, as its status is a duplicate of one already posted")
+			r.passedOver = append(r.passedOver, rw.num)
+			continue
+		}
+
+		if r.budget != nil && !r.budget.fits(runeLength(p.status)) {
+			log.Printf("row %d: only %d characters of --daily_char_budget are left today, leaving %d rows for the next run", rw.num, r.budget.limit-r.budget.used, len(rows)-i)
+			r.explain.noteRows(rows[i:], "left for the next run, as it's over --daily_char_budget")
+			r.deferred = true
+			break
+		}
+
+		if r.rc.moderationURL != "" {
+			allow, reason, err := moderate(ctx, r.rc.moderationURL, p.status)
+			if err != nil {
+				log.Printf("row %d: skipping row, failed to moderate: %v", rw.num, err)
+				failed = append(failed, r.failRow(rw.num, fmt.Errorf("failed to moderate: %v", err)))
+				continue
+			}
+			if !allow {
+				log.Printf("row %d: skipping row, denied by moderation: %s", rw.num, reason)
+				r.explain.note(rw.num, "skipped, as moderation denied it: %s", reason)
+				r.passedOver = append(r.passedOver, rw.num)
+				continue
+			}
+		}
+
+		if spread != nil {
+			if r.now().Sub(start) >= r.rc.spreadWindow {
+				log.Printf("--spread_window of %v has elapsed, leaving %d rows for the next run", r.rc.spreadWindow, len(rows)-i)
+				r.explain.noteRows(rows[i:], "left for the next run, as --spread_window elapsed")
+				r.deferred = true
+				break
+			}
+			if err := sleepUntil(ctx, start.Add(spread[i])); err != nil {
+				return tweeted, failed, err
+			}
+		}
+
+		if r.rc.continueThreadFor != "" && p.replyTo == "" {
+			p.replyTo = parent
+		}
+
+		// Attaching the media and posting are abandoned, and the row
+		// failed, if they take longer than --per_tweet_timeout.
+		var id string
+		rowCtx, cancel := r.rowContext(postCtx)
+		mediaErr := within(rowCtx, func() error {
+			return r.attachMedia(rowCtx, rw, p)
+		})
+		var postErr error
+		if mediaErr == nil {
+			postErr = within(rowCtx, func() error {
+				var err error
+				id, err = r.poster.Post(rowCtx, p)
+				return err
+			})
+		}
+		timedOut := ctx.Err() == nil &&
+			(errors.Is(mediaErr, context.DeadlineExceeded) || errors.Is(postErr, context.DeadlineExceeded))
+		cancel()
+
+		switch {
+		case timedOut:
+			log.Printf("row %d: giving up on row after --per_tweet_timeout of %v", rw.num, r.rc.perTweetTimeout)
+			failed = append(failed, r.failRow(rw.num, fmt.Errorf("timed out after %v", r.rc.perTweetTimeout)))
+			continue
+		case errors.Is(mediaErr, errSkipRow):
+			log.Printf("row %d: %v", rw.num, mediaErr)
+			r.explain.note(rw.num, "skipped: %v", mediaErr)
+			r.passedOver = append(r.passedOver, rw.num)
+			continue
+		case mediaErr != nil:
+			log.Printf("row %d: skipping row: %v", rw.num, mediaErr)
+			failed = append(failed, r.failRow(rw.num, mediaErr))
+			continue
+		case postErr != nil:
+			return tweeted, append(failed, r.failRow(rw.num, postErr)), fmt.Errorf("row %d: %w", rw.num, postErr)
+		}
+		rw.postID = id
+		tweeted = append(tweeted, rw)
+		r.explain.note(rw.num, "posted as %s", id)
+		parent = id
+		if r.budget != nil {
+			r.budget.used += runeLength(p.status)
+		}
+		if len(rest) > 0 {
+			parent = r.postThread(postCtx, rw, id, rest)
+		}
+
+		if r.rc.describeMedia {
+			r.describeMedia(postCtx, rw, id, p)
+		}
+
+		if r.audit != nil {
+			if err := r.audit.record(rw.num, id, p.status); err != nil {
+				return tweeted, failed, fmt.Errorf("row %d was posted as %s, but failed to write audit log: %v", rw.num, id, err)
+			}
+		}
+
+		if r.rc.feedFile != "" {
+			item := newFeedItem(p.status, postURL(r.bc, id), r.now())
+			if err := writeFeed(r.rc.feedFile, []feedItem{item}, r.rc.feedMax); err != nil {
+				log.Printf("warning: row %d: failed to add the post to the feed: %v", rw.num, err)
+			}
+		}
+
+		// The state is saved after every post, so that a later failure
+		// doesn't lose it.
+		if r.state != nil {
+			now := r.now()
+			r.state.recordPost(p.status, now)
+			if err := saveState(r.rc.stateFile, r.state, r.rc.dedupeWindow, now); err != nil {
+				return tweeted, failed, fmt.Errorf("row %d was posted as %s, but failed to save state: %v", rw.num, id, err)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return tweeted, failed, fmt.Errorf("failed to render, validate, moderate or attach media for rows %v", failed)
+	}
+	return tweeted, nil, nil
+}
+
+// retweet reposts the tweet whose ID or URL is target, for the row, instead
+// of posting a status, and returns the ID of the retweet. A tweet that was
+// already retweeted is taken as done, without an ID, as is any with
+// --mark_only.
+func (r *runner) retweet(ctx context.Context, rw row, target string) (string, error) {
+	id, err := replyToID(target)
+	if err != nil {
+		return "", fmt.Errorf("bad tweet to retweet %q: %v", target, err)
+	}
+	if r.rc.markOnly {
+		log.Printf("mark_only: not retweeting row %d: %s", rw.num, id)
+		return "", nil
+	}
+
+	rp, ok := optional[reposter](r.poster)
+	if !ok {
+		return "", fmt.Errorf("the %s backend can't retweet", r.bc.name)
+	}
+	rtID, err := rp.Repost(ctx, id)
+	switch {
+	case errors.Is(err, errAlreadyReposted):
+		log.Printf("row %d: tweet %s was already retweeted", rw.num, id)
+		return "", nil
+	case errors.Is(err, errPostNotFound):
+		return "", fmt.Errorf("tweet %s doesn't exist, or was deleted", id)
+	case err != nil:
+		return "", fmt.Errorf("failed to retweet %s: %w", id, err)
+	}
+
+	if r.audit != nil {
+		if err := r.audit.record(rw.num, rtID, "RT "+id); err != nil {
+			log.Printf("warning: row %d was retweeted as %s, but failed to write audit log: %v", rw.num, rtID, err)
+		}
+	}
+	return rtID, nil
+}
+
+// attachMedia uploads the media in the row's media columns, and the QR
+// code of its --qr_url_column URL, and attaches them to p. Media that
+// fails to upload is left out with --on_media_error's text-only policy;
+// otherwise an error is returned, wrapping errSkipRow for the skip policy.
+// An error is also returned for media that can't be attached together.
+func (r *runner) attachMedia(ctx context.Context, rw row, p *post) error {
+	if err := r.attachMediaURLs(ctx, rw.num, rowMedia(rw, r.rc), p); err != nil {
+		return err
+	}
+	if u := rw.cell(r.rc.qrURLColumn); u != "" {
+		return r.attachQRCode(ctx, rw.num, u, p)
+	}
+	return nil
+}
+
+// rowMedia returns the URLs of the media in the row's media columns and
+// overrides.
+func rowMedia(rw row, rc *runConfig) []string {
+	var urls []string
+	for _, col := range rc.mediaColumns {
+		if u := rw.cell(col); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if u := templatedMediaURL(rw, rc); u != "" {
+		urls = append(urls, u)
+	}
+	if rw.overrides.Media != "" {
+		urls = append(urls, rw.overrides.Media)
+	}
+	return urls
+}
+
+// templatedMediaURL renders --media_url for the row, returning "" if it's
+// unset or doesn't render to an absolute URL, as when the cells it refers
+// to are empty.
+func templatedMediaURL(rw row, rc *runConfig) string {
+	if rc.mediaURLTemplate == "" {
+		return ""
+	}
+	u := strings.TrimSpace(renderTemplate(rc.mediaURLTemplate, rw.values))
+	if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	return u
+}
+
+// attachMediaURLs uploads the media at urls, for row num, and attaches it to
+// p, as described by attachMedia.
+func (r *runner) attachMediaURLs(ctx context.Context, num int, urls []string, p *post) error {
+	if err := validateMedia(urls); err != nil {
+		return err
+	}
+
+	for _, u := range urls {
+		id, err := uploadMedia(ctx, r.poster, r.media, u)
+		switch {
+		case err == nil:
+			p.mediaIDs = append(p.mediaIDs, id)
+			p.mediaURLs = append(p.mediaURLs, u)
+		case r.rc.onMediaError == mediaErrorSkip:
+			return fmt.Errorf("%w, as media %q failed to upload: %v", errSkipRow, u, err)
+		case r.rc.onMediaError == mediaErrorFail:
+			return fmt.Errorf("failed to upload media %q: %v", u, err)
+		default:
+			log.Printf("warning: row %d: failed to upload media %q, posting without it: %v", num, u, err)
+		}
+	}
+	return nil
+}
+
+// markComplete writes the --complete_value marker into the status column of
+// each of the rows, in a single batch update per spreadsheet they were read
+// from, and returns the rows it marked, which are all of them without a
+// status column. A spreadsheet that fails to update doesn't stop the others
+// from being updated, but its rows aren't returned.
+//
+// Another run, or someone editing the sheet, may have written to a row's
+// cell since it was read, so the cells are read again first and only those
+// still empty are written. If the write fails, that's retried once.
+func (r *runner) markComplete(rows []row) ([]row, error) {
+	if r.statusColumn == "" || len(rows) == 0 {
+		return rows, nil
+	}
+
+	// Rows without a range, such as those posted from a plan, are in the
+	// first.
+	now, username := r.now(), currentUsername()
+	markers := make(map[*sheetRange]map[int]string)
+	var order []*sheetRange
+	bySheet := make(map[*sheetRange][]int)
+	for _, rw := range rows {
+		sr := rw.sheet
+		if sr == nil {
+			sr = r.ranges[0]
+		}
+		if _, ok := bySheet[sr]; !ok {
+			order = append(order, sr)
+			markers[sr] = make(map[int]string)
+		}
+		bySheet[sr] = append(bySheet[sr], rw.num)
+		markers[sr][rw.num] = renderCompleteValue(r.rc.completeValue, rw.postID, username, now)
+	}
+
+	// Each range is marked in a single batch update, which is written
+	// whole or not at all, so a range that fails doesn't stop the others.
+	failed := make(map[*sheetRange]bool)
+	var errs []error
+	for _, sr := range order {
+		if err := r.markRange(sr, bySheet[sr], markers[sr]); err != nil {
+			failed[sr] = true
+			if len(order) > 1 {
+				err = fmt.Errorf("%s: %w", sr, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	var marked []row
+	for _, rw := range rows {
+		sr := rw.sheet
+		if sr == nil {
+			sr = r.ranges[0]
+		}
+		if !failed[sr] {
+			marked = append(marked, rw)
+		}
+	}
+	return marked, errors.Join(errs...)
+}
+
+// reportUnmarked logs each of the posted rows that isn't among those marked
+// complete, as it would be posted again by the next run.
+func reportUnmarked(posted, marked []row) {
+	done := make(map[*sheetRange]map[int]bool)
+	for _, rw := range marked {
+		if done[rw.sheet] == nil {
+			done[rw.sheet] = make(map[int]bool)
+		}
+		done[rw.sheet][rw.num] = true
+	}
+	for _, rw := range posted {
+		if done[rw.sheet][rw.num] {
+			continue
+		}
+		if rw.postID != "" {
+			log.Printf("warning: row %d was posted as %s, but not marked complete, so it will be posted again unless it's marked by hand", rw.num, rw.postID)
+		} else {
+			log.Printf("warning: row %d was not marked complete", rw.num)
+		}
+	}
+}
+
+// markRange marks the rows of sr numbered nums complete with their
+// markers, as described by markComplete. With --force, the row was posted
+// again, so its marker is overwritten rather than checked.
+func (r *runner) markRange(sr *sheetRange, nums []int, markers map[int]string) error {
+	if r.rc.force {
+		return r.writeStatus(sr, nums, markers)
+	}
+
+	var err error
+	for attempt := 1; attempt <= 2; attempt++ {
+		if nums, err = r.emptyStatusCells(sr, nums, markers); err != nil {
+			return fmt.Errorf("failed to read column %s before marking rows: %w", r.statusColumn, err)
+		}
+		if len(nums) == 0 {
+			return nil
+		}
+		if err = r.writeStatus(sr, nums, markers); err == nil {
+			return nil
+		}
+		if attempt == 1 {
+			log.Printf("failed to mark rows complete, checking them again: %v", err)
+		}
+	}
+	return err
+}
+
+// emptyStatusCells returns those of the rows of sr numbered nums whose cell
+// in the status column is empty, logging those that aren't, unless they
+// already hold their markers.
+func (r *runner) emptyStatusCells(sr *sheetRange, nums []int, markers map[int]string) ([]int, error) {
+	runs := rowRuns(nums)
+	ranges := make([]string, len(runs))
+	for i, run := range runs {
+		ranges[i] = fmt.Sprintf("%s!%s%d:%s%d", sr.name, r.statusColumn, run[0], r.statusColumn, run[1])
+	}
+
+	resp, err := r.srv.Spreadsheets.Values.BatchGet(sr.id).Ranges(ranges...).Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.ValueRanges) != len(runs) {
+		return nil, fmt.Errorf("asked for %d ranges, but got %d", len(runs), len(resp.ValueRanges))
+	}
+
+	var empty []int
+	for i, run := range runs {
+		got := resp.ValueRanges[i].Values
+		for num := run[0]; num <= run[1]; num++ {
+			j := num - run[0]
+			if j < len(got) && len(got[j]) > 0 {
+				if v := fmt.Sprint(got[j][0]); v != "" {
+					if v != markers[num] {
+						log.Printf("warning: row %d: not marking row complete, as its cell in column %s changed to %q", num, r.statusColumn, v)
+					}
+					continue
+				}
+			}
+			empty = append(empty, num)
+		}
+	}
+	return empty, nil
+}
+
+// writeStatus writes their markers into the status column of the rows of
+// sr numbered nums.
+func (r *runner) writeStatus(sr *sheetRange, nums []int, markers map[int]string) error {
+	req := &sheets.BatchUpdateValuesRequest{
+		Data: columnRanges(sr.name, r.statusColumn, nums, func(num int) interface{} {
+			return markers[num]
+		}),
+		ValueInputOption: "RAW",
+	}
+	if _, err := r.srv.Spreadsheets.Values.BatchUpdate(sr.id, req).Do(); err != nil {
+		return fmt.Errorf("failed to update %d cells in column %s: %w", len(nums), r.statusColumn, err)
+	}
+	return nil
+}
+
+// columnRanges returns the value ranges that set the cell of each of the
+// given rows in column to its value. Runs of consecutive rows share a range.
+func columnRanges(sheet, column string, nums []int, value func(num int) interface{}) []*sheets.ValueRange {
+	var vrs []*sheets.ValueRange
+	for _, run := range rowRuns(nums) {
+		first, last := run[0], run[1]
+		values := make([][]interface{}, last-first+1)
+		for k := range values {
+			values[k] = []interface{}{value(first + k)}
+		}
+		vrs = append(vrs, &sheets.ValueRange{
+			Range:  fmt.Sprintf("%s!%s%d:%s%d", sheet, column, first, column, last),
+			Values: values,
+		})
+	}
+	return vrs
+}
+
+// rowRuns returns the first and last row numbers of each run of
+// consecutive row numbers in nums, in order.
+func rowRuns(nums []int) [][2]int {
+	nums = append([]int(nil), nums...)
+	sort.Ints(nums)
+
+	var runs [][2]int
+	for i := 0; i < len(nums); {
+		// Extend the run over consecutive (and duplicate) row numbers.
+		j := i + 1
+		for j < len(nums) && nums[j] <= nums[j-1]+1 {
+			j++
+		}
+		runs = append(runs, [2]int{nums[i], nums[j-1]})
+		i = j
+	}
+	return runs
+}
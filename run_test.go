@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// testRunConfig returns a runConfig with its optional columns unset, as
+// flags leave them.
+func testRunConfig() *runConfig {
+	return &runConfig{
+		template:       "{0}",
+		location:       time.UTC,
+		latColumn:      -1,
+		longColumn:     -1,
+		accountColumn:  -1,
+		quoteColumn:    -1,
+		cardURLColumn:  -1,
+		retweetColumn:  -1,
+		qrURLColumn:    -1,
+		cwColumn:       -1,
+		hashtagColumn:  -1,
+		modifiedColumn: -1,
+		dateColumn:     -1,
+	}
+}
+
+// newTestRunner returns a runner posting to poster on Twitter, with rc.
+func newTestRunner(poster Poster, rc *runConfig) *runner {
+	return &runner{
+		sc:     &sheetsConfig{},
+		bc:     &backendConfig{name: backendTwitter},
+		rc:     rc,
+		poster: poster,
+		now:    time.Now,
+	}
+}
+
+// testRows returns rows numbered from 2, with a value each.
+func testRows(values ...string) []row {
+	rows := make([]row, len(values))
+	for i, v := range values {
+		rows[i] = row{num: i + 2, values: []interface{}{v}}
+	}
+	return rows
+}
+
+func rowNums(rows []row) []int {
+	var nums []int
+	for _, r := range rows {
+		nums = append(nums, r.num)
+	}
+	return nums
+}
+
+func TestSelectRow(t *testing.T) {
+	rows := testRows("a", "b", "c")
+	for _, tc := range []struct {
+		n       int
+		want    []int
+		wantErr bool
+	}{
+		{n: 1, want: []int{2}},
+		{n: 3, want: []int{4}},
+		{n: 4, wantErr: true},
+	} {
+		got, err := selectRow(rows, tc.n)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("selectRow(%d) = %v, want error: %t", tc.n, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, ErrConfig) {
+				t.Errorf("selectRow(%d) = %v, want an ErrConfig", tc.n, err)
+			}
+			continue
+		}
+		if nums := rowNums(got); !reflect.DeepEqual(nums, tc.want) {
+			t.Errorf("selectRow(%d) = rows %v, want %v", tc.n, nums, tc.want)
+		}
+	}
+}
+
+func TestTweetDefers(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// configure sets up the runner's deferral.
+		configure    func(r *runner)
+		wantStatuses []string
+		wantDeferred bool
+	}{
+		{
+			name:         "nothing to defer",
+			configure:    func(r *runner) {},
+			wantStatuses: []string{"one", "two", "three"},
+		},
+		{
+			name: "over the daily budget",
+			configure: func(r *runner) {
+				r.budget = &charBudget{limit: 7}
+			},
+			wantStatuses: []string{"one", "two"},
+			wantDeferred: true,
+		},
+		{
+			name: "spread window elapsed",
+			configure: func(r *runner) {
+				r.rc.spreadWindow = time.Hour
+				// The run starts a day ago, and the window has elapsed
+				// by the second row.
+				start := time.Now().Add(-24 * time.Hour)
+				calls := 0
+				r.now = func() time.Time {
+					calls++
+					if calls <= 2 {
+						return start
+					}
+					return start.Add(2 * time.Hour)
+				}
+			},
+			wantStatuses: []string{"one"},
+			wantDeferred: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &fakePoster{}
+			r := newTestRunner(p, testRunConfig())
+			tc.configure(r)
+
+			tweeted, failed, err := r.tweet(context.Background(), testRows("one", "two", "three"))
+			if err != nil || len(failed) > 0 {
+				t.Fatalf("tweet() = %v, failed rows %v", err, failed)
+			}
+			if got := p.statuses(); !reflect.DeepEqual(got, tc.wantStatuses) {
+				t.Errorf("posted %q, want %q", got, tc.wantStatuses)
+			}
+			if len(tweeted) != len(tc.wantStatuses) {
+				t.Errorf("tweeted %d rows, want %d", len(tweeted), len(tc.wantStatuses))
+			}
+			if r.deferred != tc.wantDeferred {
+				t.Errorf("deferred = %t, want %t", r.deferred, tc.wantDeferred)
+			}
+		})
+	}
+}
+
+func TestRowRuns(t *testing.T) {
+	for _, tc := range []struct {
+		in   []int
+		want [][2]int
+	}{
+		{in: nil, want: nil},
+		{in: []int{5}, want: [][2]int{{5, 5}}},
+		{in: []int{2, 3, 4, 7, 8, 10}, want: [][2]int{{2, 4}, {7, 8}, {10, 10}}},
+		{in: []int{8, 3, 2, 7}, want: [][2]int{{2, 3}, {7, 8}}},
+		{in: []int{2, 2, 3}, want: [][2]int{{2, 3}}},
+	} {
+		in := append([]int(nil), tc.in...)
+		if got := rowRuns(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("rowRuns(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+		if !reflect.DeepEqual(in, tc.in) {
+			t.Errorf("rowRuns() reordered its argument to %v", tc.in)
+		}
+	}
+}
+
+func TestColumnRanges(t *testing.T) {
+	vrs := columnRanges("Sheet1", "F", []int{4, 2, 3, 9}, func(num int) interface{} {
+		return strconv.Itoa(num)
+	})
+	var got []string
+	for _, vr := range vrs {
+		got = append(got, fmt.Sprintf("%s=%v", vr.Range, vr.Values))
+	}
+	want := []string{"Sheet1!F2:F4=[[2] [3] [4]]", "Sheet1!F9:F9=[[9]]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columnRanges() = %q, want %q", got, want)
+	}
+}
+
+// Marking rows complete writes them all in a single batch update, with a
+// range for each run of consecutive rows.
+func TestMarkComplete(t *testing.T) {
+	f, srv := newFakeSheet(t)
+	rc := testRunConfig()
+	rc.completeValue = "{tweet_id}"
+	r := newSheetRunner(&fakePoster{}, rc, srv)
+	var rows []row
+	for _, num := range []int{2, 3, 4, 6, 7} {
+		rows = append(rows, row{num: num, postID: "p" + strconv.Itoa(num)})
+	}
+
+	marked, err := r.markComplete(rows)
+	if err != nil {
+		t.Fatalf("markComplete() = %v", err)
+	}
+	if got := rowNums(marked); !reflect.DeepEqual(got, []int{2, 3, 4, 6, 7}) {
+		t.Errorf("markComplete() marked rows %v, want all", got)
+	}
+	if want := [][]string{{"Posts!D2:D4", "Posts!D6:D7"}}; !reflect.DeepEqual(f.updates, want) {
+		t.Errorf("batch updates = %q, want %q", f.updates, want)
+	}
+	for _, rw := range rows {
+		if got := f.cell("D", rw.num); got != rw.postID {
+			t.Errorf("status of row %d = %q, want %q", rw.num, got, rw.postID)
+		}
+	}
+}
+
+// A row whose status cell is filled in by someone else while the rows are
+// being marked isn't overwritten when the write is retried.
+func TestMarkCompleteConflict(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	f, srv := newFakeSheet(t, []string{"Word"}, []string{"a"}, []string{"b"}, []string{"c"}, []string{"d", "", "", "done"})
+	// The first write fails, after row 3 is marked by another run.
+	f.beforeUpdate = func(f *fakeSheet) {
+		if len(f.updates) == 1 {
+			f.set("D", 3, "other")
+		}
+	}
+	f.failUpdate = func([]string) bool { return len(f.updates) == 1 }
+	rc := testRunConfig()
+	rc.completeValue = "{tweet_id}"
+	r := newSheetRunner(&fakePoster{}, rc, srv)
+	rows := []row{{num: 2, postID: "p2"}, {num: 3, postID: "p3"}, {num: 4, postID: "p4"}, {num: 5, postID: "p5"}}
+
+	if _, err := r.markComplete(rows); err != nil {
+		t.Fatalf("markComplete() = %v", err)
+	}
+	// Row 5 is already marked when first read, and row 3 when read again.
+	want := [][]string{{"Posts!D2:D4"}, {"Posts!D2:D2", "Posts!D4:D4"}}
+	if !reflect.DeepEqual(f.updates, want) {
+		t.Errorf("batch updates = %q, want %q", f.updates, want)
+	}
+	for num, want := range map[int]string{2: "p2", 3: "other", 4: "p4", 5: "done"} {
+		if got := f.cell("D", num); got != want {
+			t.Errorf("status of row %d = %q, want %q", num, got, want)
+		}
+	}
+	for _, num := range []string{"row 3", "row 5"} {
+		if !strings.Contains(logs.String(), num+": not marking row complete") {
+			t.Errorf("logged %q, want a warning about %s", logs.String(), num)
+		}
+	}
+}
+
+// When some ranges can't be marked complete, the rows of the others still
+// are, and the posted rows left unmarked are reported.
+func TestRunPartialMark(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	f, srv := newFakeSheet(t, []string{"Word"}, []string{"a"}, []string{"b"}, []string{"c"}, []string{"d"})
+	f.failUpdate = func(ranges []string) bool { return reflect.DeepEqual(ranges, []string{"Posts!D4:D5"}) }
+	rc := testRunConfig()
+	rc.completeValue = "{tweet_id}"
+	p := &fakePoster{}
+	r := newSheetRunner(p, rc, srv)
+	r.ranges = []*sheetRange{
+		{id: "sheet-id", name: "Posts", cellRange: "A2:C3", rng: a1Range{startCol: 0, startRow: 2, endCol: 2, endRow: 3}},
+		{id: "sheet-id", name: "Posts", cellRange: "A4:C", rng: a1Range{startCol: 0, startRow: 4, endCol: 2}},
+	}
+
+	err := r.run(context.Background())
+	if got := exitCode(err); got != 5 {
+		t.Errorf("exitCode(%v) = %d, want 5", err, got)
+	}
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(p.statuses(), want) {
+		t.Errorf("posted %q, want %q", p.statuses(), want)
+	}
+	for num, want := range map[int]string{2: "1", 3: "2", 4: "", 5: ""} {
+		if got := f.cell("D", num); got != want {
+			t.Errorf("status of row %d = %q, want %q", num, got, want)
+		}
+	}
+	for _, want := range []string{"row 4 was posted as 3, but not marked complete", "row 5 was posted as 4, but not marked complete"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logged %q, want %q", logs.String(), want)
+		}
+	}
+	if strings.Contains(logs.String(), "row 2 was posted") || strings.Contains(logs.String(), "row 3 was posted") {
+		t.Errorf("logged %q, want no warning about the rows marked", logs.String())
+	}
+}
+
+// --mark_only marks rows complete in --mark_only_column without posting
+// them.
+func TestRunMarkOnly(t *testing.T) {
+	f, srv := newFakeSheet(t, []string{"Word"}, []string{"a"}, []string{"b"})
+	rc := testRunConfig()
+	rc.markOnly = true
+	rc.completeValue = "marked"
+	p := &fakePoster{}
+	r := newSheetRunner(p, rc, srv)
+	// doMain marks rows in --mark_only_column instead of --status_column.
+	r.statusColumn = "E"
+
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("run() = %v", err)
+	}
+	if s := p.statuses(); len(s) != 0 {
+		t.Errorf("posted %q, want nothing", s)
+	}
+	for _, num := range []int{2, 3} {
+		if got := f.cell("E", num); got != "marked" {
+			t.Errorf("column E of row %d = %q, want %q", num, got, "marked")
+		}
+		if got := f.cell("D", num); got != "" {
+			t.Errorf("column D of row %d = %q, want it left empty", num, got)
+		}
+	}
+}
+
+// --force posts a row again, though it's marked complete or its status is
+// in the state file, and marks it anew.
+func TestRunForce(t *testing.T) {
+	posted := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := posted.Add(24 * time.Hour)
+	for _, tc := range []struct {
+		name       string
+		marked     string
+		force      bool
+		wantPosted bool
+	}{
+		{name: "marked", marked: "2024-06-01"},
+		{name: "in the state file"},
+		{name: "marked with force", marked: "2024-06-01", force: true, wantPosted: true},
+		{name: "in the state file with force", force: true, wantPosted: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, srv := newFakeSheet(t, []string{"Word"}, []string{"hello", "", "", tc.marked})
+			rc := testRunConfig()
+			rc.onlyRow = 1
+			rc.force = tc.force
+			rc.completeValue = "{date}"
+			rc.stateFile = filepath.Join(t.TempDir(), "state.json")
+			p := &fakePoster{}
+			r := newSheetRunner(p, rc, srv)
+			r.now = func() time.Time { return now }
+			r.state = &postState{Posted: map[string]time.Time{statusHash("hello"): posted}}
+
+			if err := r.run(context.Background()); err != nil {
+				t.Fatalf("run() = %v", err)
+			}
+			var wantPosts []string
+			wantStatus, wantTime := tc.marked, posted
+			if tc.wantPosted {
+				wantPosts, wantStatus, wantTime = []string{"hello"}, "2024-06-02", now
+			}
+			if !reflect.DeepEqual(p.statuses(), wantPosts) {
+				t.Errorf("posted %q, want %q", p.statuses(), wantPosts)
+			}
+			if got := f.cell("D", 2); got != wantStatus {
+				t.Errorf("status of row 2 = %q, want %q", got, wantStatus)
+			}
+			if got := r.state.Posted[statusHash("hello")]; !got.Equal(wantTime) {
+				t.Errorf("state records the status as posted at %v, want %v", got, wantTime)
+			}
+		})
+	}
+}
+
+// Empty cells, and those past the end of the row, are posted as
+// --empty_placeholder, but rows with nothing in them still aren't posted.
+func TestRunEmptyPlaceholder(t *testing.T) {
+	for _, tc := range []struct {
+		placeholder string
+		want        []string
+	}{
+		{want: []string{"a, , c, ", "e, f, g, h"}},
+		{placeholder: "N/A", want: []string{"a, N/A, c, N/A", "e, f, g, h"}},
+	} {
+		t.Run(fmt.Sprintf("placeholder=%q", tc.placeholder), func(t *testing.T) {
+			rc := testRunConfig()
+			rc.template = "{0}, {1}, {2}, {3}"
+			rc.emptyPlaceholder = tc.placeholder
+			p := &fakePoster{}
+			r := newTestRunner(p, rc)
+			r.source = staticSource{{"a", "", "c"}, {"", "", ""}, {"e", "f", "g", "h"}}
+
+			if err := r.run(context.Background()); err != nil {
+				t.Fatalf("run() = %v", err)
+			}
+			if !reflect.DeepEqual(p.statuses(), tc.want) {
+				t.Errorf("posted %q, want %q", p.statuses(), tc.want)
+			}
+		})
+	}
+}
+
+// Only rows that were posted count towards --expect_min, not those only
+// marked complete.
+func TestRunExpectMin(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		expectMin int
+		markEmpty bool
+		wantErr   error
+	}{
+		{name: "enough", expectMin: 1},
+		{name: "too few", expectMin: 2, wantErr: ErrTooFew},
+		{name: "empty rows don't count", expectMin: 2, markEmpty: true, wantErr: ErrTooFew},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.template = "{1}"
+			rc.expectMin = tc.expectMin
+			rc.markEmpty = tc.markEmpty
+			r := newTestRunner(&fakePoster{}, rc)
+			r.source = staticSource{{"a", "one"}, {"b", ""}}
+
+			if err := r.run(context.Background()); !errors.Is(err, tc.wantErr) {
+				t.Errorf("run() = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// --max_age is measured from the runner's clock.
+func TestRunMaxAge(t *testing.T) {
+	rc := testRunConfig()
+	rc.dateColumn = 1
+	rc.maxAge = 48 * time.Hour
+	p := &fakePoster{}
+	r := newTestRunner(p, rc)
+	r.now = func() time.Time { return time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC) }
+	r.source = staticSource{{"old", "2024-06-01"}, {"fresh", "2024-06-09"}, {"older", "2024-06-08 11:00"}}
+
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("run() = %v", err)
+	}
+	if want := []string{"fresh"}; !reflect.DeepEqual(p.statuses(), want) {
+		t.Errorf("posted %q, want %q", p.statuses(), want)
+	}
+}
+
+// fakeSheet is a Sheets API server for a spreadsheet, with the ID
+// "sheet-id", of a single sheet named Posts. It records the ranges of each
+// batch update it's sent.
+type fakeSheet struct {
+	mu sync.Mutex
+	// cells holds the sheet's values, by row and then column, starting
+	// from A1.
+	cells   [][]string
+	updates [][]string
+	// beforeUpdate, if set, is called, with f locked, before each batch
+	// update is applied, as if someone else edited the sheet in between.
+	beforeUpdate func(f *fakeSheet)
+	// failUpdate, if set, fails the batch updates it returns true for.
+	failUpdate func(ranges []string) bool
+}
+
+// newFakeSheet starts a fakeSheet with cells, returning it and a service
+// for it.
+func newFakeSheet(t *testing.T, cells ...[]string) (*fakeSheet, *sheets.Service) {
+	f := &fakeSheet{cells: cells}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	s, err := sheets.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, s
+}
+
+// newSheetRunner returns a runner reading the range A2:C of f's sheet,
+// through srv, and marking rows complete in its column D.
+func newSheetRunner(poster Poster, rc *runConfig, srv *sheets.Service) *runner {
+	r := newTestRunner(poster, rc)
+	r.srv = srv
+	r.ranges = []*sheetRange{{id: "sheet-id", name: "Posts", cellRange: "A2:C", rng: a1Range{startCol: 0, startRow: 2, endCol: 2}}}
+	r.statusColumn = "D"
+	return r
+}
+
+// cell returns the value of the cell in column col of row num.
+func (f *fakeSheet) cell(col string, num int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, _ := columnIndex(col)
+	if num-1 >= len(f.cells) || c >= len(f.cells[num-1]) {
+		return ""
+	}
+	return f.cells[num-1][c]
+}
+
+func (f *fakeSheet) set(col string, num int, v string) {
+	c, _ := columnIndex(col)
+	for len(f.cells) < num {
+		f.cells = append(f.cells, nil)
+	}
+	for len(f.cells[num-1]) <= c {
+		f.cells[num-1] = append(f.cells[num-1], "")
+	}
+	f.cells[num-1][c] = v
+}
+
+// values returns the values of the range rg, leaving out trailing empty
+// cells and rows, as Sheets does.
+func (f *fakeSheet) values(rg string) ([][]interface{}, error) {
+	name, cells, ok := strings.Cut(rg, "!")
+	if !ok || strings.Trim(name, "'") != "Posts" {
+		return nil, fmt.Errorf("unknown range %q", rg)
+	}
+	a, err := parseA1Range(cells)
+	if err != nil {
+		return nil, err
+	}
+	end := a.endRow
+	if end == 0 || end > len(f.cells) {
+		end = len(f.cells)
+	}
+	var values [][]interface{}
+	for num := a.startRow; num <= end; num++ {
+		var rv []interface{}
+		for c := a.startCol; c <= a.endCol && c < len(f.cells[num-1]); c++ {
+			rv = append(rv, f.cells[num-1][c])
+		}
+		for len(rv) > 0 && rv[len(rv)-1] == "" {
+			rv = rv[:len(rv)-1]
+		}
+		values = append(values, rv)
+	}
+	for len(values) > 0 && len(values[len(values)-1]) == 0 {
+		values = values[:len(values)-1]
+	}
+	return values, nil
+}
+
+func (f *fakeSheet) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const prefix = "/v4/spreadsheets/sheet-id"
+	path := req.URL.Path
+	switch {
+	case path == prefix:
+		json.NewEncoder(w).Encode(map[string]interface{}{"properties": map[string]string{"title": "Test"}})
+	case path == prefix+"/values:batchGet":
+		var vrs []*sheets.ValueRange
+		for _, rg := range req.URL.Query()["ranges"] {
+			values, err := f.values(rg)
+			if err != nil {
+				http.Error(w, `{"error": {"message": "bad range"}}`, http.StatusBadRequest)
+				return
+			}
+			vrs = append(vrs, &sheets.ValueRange{Range: rg, Values: values})
+		}
+		json.NewEncoder(w).Encode(&sheets.BatchGetValuesResponse{ValueRanges: vrs})
+	case path == prefix+"/values:batchUpdate" && req.Method == http.MethodPost:
+		var in sheets.BatchUpdateValuesRequest
+		json.NewDecoder(req.Body).Decode(&in)
+		var ranges []string
+		for _, vr := range in.Data {
+			ranges = append(ranges, vr.Range)
+		}
+		f.updates = append(f.updates, ranges)
+		if f.beforeUpdate != nil {
+			f.beforeUpdate(f)
+		}
+		if f.failUpdate != nil && f.failUpdate(ranges) {
+			http.Error(w, `{"error": {"code": 403, "message": "The caller does not have permission"}}`, http.StatusForbidden)
+			return
+		}
+		for _, vr := range in.Data {
+			_, cells, _ := strings.Cut(vr.Range, "!")
+			a, err := parseA1Range(cells)
+			if err != nil {
+				http.Error(w, `{"error": {"message": "bad range"}}`, http.StatusBadRequest)
+				return
+			}
+			for i, rv := range vr.Values {
+				for j, v := range rv {
+					f.set(columnLetters(a.startCol+j), a.startRow+i, fmt.Sprint(v))
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(&sheets.BatchUpdateValuesResponse{})
+	case strings.HasPrefix(path, prefix+"/values/"):
+		values, err := f.values(strings.TrimPrefix(path, prefix+"/values/"))
+		if err != nil {
+			http.Error(w, `{"error": {"message": "bad range"}}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(&sheets.ValueRange{Values: values})
+	default:
+		http.NotFound(w, req)
+	}
+}
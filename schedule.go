@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// pauseState is whether the scheduler is paused, which can be toggled at
+// any time.
+type pauseState struct {
+	mu     sync.Mutex
+	paused bool
+}
+
+// toggle pauses a running scheduler or resumes a paused one, returning
+// whether it's now paused.
+func (s *pauseState) toggle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = !s.paused
+	return s.paused
+}
+
+func (s *pauseState) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// runEvery calls run now and then every interval, until ctx is done or the
+// process is interrupted. A run that fails is logged, and the next one goes
+// ahead as scheduled.
+//
+// While paused, which it starts as with startPaused, runs are skipped. Any
+// of pauseSignals toggles between paused and running.
+//
+// Signals are handled as they arrive, even during a run: an interrupt
+// cancels the context of the run in progress, so that it stops early.
+func runEvery(ctx context.Context, interval time.Duration, startPaused bool, run func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	toggle := make(chan os.Signal, 1)
+	if len(pauseSignals) > 0 {
+		signal.Notify(toggle, pauseSignals...)
+		defer signal.Stop(toggle)
+	}
+
+	state := &pauseState{paused: startPaused}
+	if startPaused {
+		log.Printf("starting paused; send %v to resume", pauseSignals)
+	}
+
+	// stopped is closed once the process is interrupted, which also
+	// cancels ctx.
+	stopped := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-toggle:
+				if state.toggle() {
+					log.Printf("paused")
+				} else {
+					log.Printf("resumed")
+				}
+			case sig := <-stop:
+				log.Printf("stopping on %v", sig)
+				close(stopped)
+				cancel()
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	trigger := func() {
+		if ctx.Err() != nil {
+			return
+		}
+		if state.isPaused() {
+			log.Printf("paused, so skipping the scheduled run")
+			return
+		}
+		if err := run(ctx); err != nil {
+			log.Printf("scheduled run failed: %v", err)
+		}
+	}
+
+	trigger()
+	for {
+		select {
+		case <-ticker.C:
+			trigger()
+		case <-ctx.Done():
+			select {
+			case <-stopped:
+				return nil
+			default:
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// shouldRunOn reports whether a run at t, in the timezone it's in, goes
+// ahead: it doesn't on any of skipDays, or on holidays, which are dates in
+// dayFormat.
+func shouldRunOn(t time.Time, skipDays map[time.Weekday]bool, holidays map[string]bool) bool {
+	return !skipDays[t.Weekday()] && !holidays[t.Format(dayFormat)]
+}
+
+// parseSkipDays parses --skip_days, a comma-separated list of days of the
+// week, such as "Sat,Sun", named in full or by their first three letters.
+func parseSkipDays(s string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			full := strings.ToLower(d.String())
+			if name == full || name == full[:3] {
+				days[d], found = true, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown day %q", name)
+		}
+	}
+	return days, nil
+}
+
+// loadHolidays reads --holidays_file, which has a date, in dayFormat, on
+// each line. Blank lines and those starting with '#' are ignored.
+func loadHolidays(path string) (map[string]bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make(map[string]bool)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := time.Parse(dayFormat, line); err != nil {
+			return nil, fmt.Errorf("line %d: bad date %q, which should be like %s", i+1, line, dayFormat)
+		}
+		holidays[line] = true
+	}
+	return holidays, nil
+}
+
+// hoursWindow is a daily window of --active_hours, as times since
+// midnight. It crosses midnight if end is before start.
+type hoursWindow struct {
+	start, end time.Duration
+}
+
+// parseActiveHours parses --active_hours, such as "09:00-17:00" or
+// "22:00-02:00".
+func parseActiveHours(s string) (*hoursWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%q is not of the form HH:MM-HH:MM", s)
+	}
+
+	var w hoursWindow
+	for i, bound := range []*time.Duration{&w.start, &w.end} {
+		t, err := time.Parse("15:04", strings.TrimSpace(parts[i]))
+		if err != nil {
+			return nil, fmt.Errorf("bad time %q, which should be like 09:00", parts[i])
+		}
+		*bound = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if w.start == w.end {
+		return nil, fmt.Errorf("the window %q is empty", s)
+	}
+	return &w, nil
+}
+
+// withinActiveHours reports whether t, in the timezone it's in, falls in
+// window, which includes its start but not its end. Any time is within a
+// nil window.
+func withinActiveHours(t time.Time, window *hoursWindow) bool {
+	if window == nil {
+		return true
+	}
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if window.start < window.end {
+		return d >= window.start && d < window.end
+	}
+	return d >= window.start || d < window.end
+}
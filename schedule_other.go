@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// pauseSignals are the signals that pause and resume the scheduler. There
+// are none where SIGUSR1 doesn't exist.
+var pauseSignals []os.Signal
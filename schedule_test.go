@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSkipDays(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    map[time.Weekday]bool
+		wantErr bool
+	}{
+		{in: "", want: map[time.Weekday]bool{}},
+		{in: "Sat,Sun", want: map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}},
+		{in: " monday , FRI ", want: map[time.Weekday]bool{time.Monday: true, time.Friday: true}},
+		{in: "Sat,,Sat", want: map[time.Weekday]bool{time.Saturday: true}},
+		{in: "Caturday", wantErr: true},
+		{in: "Sa", wantErr: true},
+	} {
+		got, err := parseSkipDays(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseSkipDays(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseSkipDays(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestShouldRunOn(t *testing.T) {
+	skipDays := map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}
+	holidays := map[string]bool{"2024-12-25": true}
+	for _, tc := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{at: time.Date(2024, 12, 23, 9, 0, 0, 0, time.UTC), want: true},   // Monday.
+		{at: time.Date(2024, 12, 25, 9, 0, 0, 0, time.UTC), want: false},  // Christmas.
+		{at: time.Date(2024, 12, 28, 9, 0, 0, 0, time.UTC), want: false},  // Saturday.
+		{at: time.Date(2024, 12, 29, 23, 0, 0, 0, time.UTC), want: false}, // Sunday.
+		// The day is that of the time's own timezone: late on Friday in New
+		// York is already Saturday in UTC.
+		{at: time.Date(2024, 12, 27, 22, 0, 0, 0, time.FixedZone("EST", -5*60*60)), want: true},
+	} {
+		if got := shouldRunOn(tc.at, skipDays, holidays); got != tc.want {
+			t.Errorf("shouldRunOn(%v) = %t, want %t", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestLoadHolidays(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{name: "empty", content: "", want: map[string]bool{}},
+		{
+			name:    "comments and blank lines",
+			content: "# Holidays\n2024-12-25\n\n  2025-01-01  \n",
+			want:    map[string]bool{"2024-12-25": true, "2025-01-01": true},
+		},
+		{name: "bad date", content: "2024-12-25\n25/12/2024\n", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "holidays")
+			if err := ioutil.WriteFile(path, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadHolidays(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("loadHolidays() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("loadHolidays() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestActiveHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2024, 6, 3, h, m, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		window  string
+		at      time.Time
+		want    bool
+		wantErr bool
+	}{
+		{window: "09:00-17:00", at: at(9, 0), want: true},
+		{window: "09:00-17:00", at: at(16, 59), want: true},
+		{window: "09:00-17:00", at: at(17, 0), want: false},
+		{window: "09:00-17:00", at: at(8, 59), want: false},
+		{window: "22:00-02:00", at: at(23, 30), want: true},
+		{window: "22:00-02:00", at: at(1, 59), want: true},
+		{window: "22:00-02:00", at: at(2, 0), want: false},
+		{window: "22:00-02:00", at: at(12, 0), want: false},
+		{window: " 09:00 - 17:00 ", at: at(12, 0), want: true},
+		{window: "09:00", wantErr: true},
+		{window: "9am-5pm", wantErr: true},
+		{window: "09:00-09:00", wantErr: true},
+	} {
+		w, err := parseActiveHours(tc.window)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseActiveHours(%q) = %v, want error: %t", tc.window, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := withinActiveHours(tc.at, w); got != tc.want {
+			t.Errorf("withinActiveHours(%v, %q) = %t, want %t", tc.at.Format("15:04"), tc.window, got, tc.want)
+		}
+	}
+
+	if !withinActiveHours(at(3, 0), nil) {
+		t.Error("withinActiveHours(nil window) = false, want true")
+	}
+}
@@ -0,0 +1,74 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// An interrupt during a run cancels it, rather than waiting for it to end.
+func TestRunEveryCancelsRunOnSignal(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	run := func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+		case <-time.After(10 * time.Second):
+		}
+		return ctx.Err()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- runEvery(context.Background(), time.Hour, false, run) }()
+
+	<-started
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run wasn't cancelled by SIGTERM")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runEvery() = %v, want nil after an interrupt", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runEvery didn't return after SIGTERM")
+	}
+}
+
+// The pause signal is handled during a run, so the next run is skipped.
+func TestRunEveryPausesDuringRun(t *testing.T) {
+	runs := 0
+	paused := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	run := func(ctx context.Context) error {
+		runs++
+		if runs == 1 {
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+			// Give the signal time to be handled.
+			time.Sleep(100 * time.Millisecond)
+			close(paused)
+		}
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- runEvery(ctx, 50*time.Millisecond, false, run) }()
+	<-paused
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+	if runs != 1 {
+		t.Errorf("ran %d times, want 1, as it was paused during the first run", runs)
+	}
+}
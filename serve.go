@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// previewServer serves a page listing the pending rows and their statuses,
+// with a button to post each one.
+type previewServer struct {
+	// pending reads the rows that have yet to be posted.
+	pending func() ([]row, error)
+	// render returns the status that would be posted for a row.
+	render func(r row) string
+	// publish posts a row and marks it complete. An error wrapping
+	// ErrSheetWrite means the row was posted but not marked.
+	publish func(ctx context.Context, r row) error
+	// token must be sent with each post, so that other sites can't post
+	// rows by having a browser submit the form.
+	token string
+
+	// mu keeps rows from being posted concurrently, so that a double
+	// click can't post a row twice.
+	mu sync.Mutex
+	// posted holds the numbers of the rows posted, which are no longer
+	// listed even if they couldn't be marked complete, as without
+	// --status_column they never are.
+	posted map[int]bool
+}
+
+func (s *previewServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleList)
+	mux.HandleFunc("/post", s.handlePost)
+	return mux
+}
+
+var listTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html>
+<head><title>hitlist: pending posts</title></head>
+<body>
+<h1>Pending posts</h1>
+{{range .Items}}
+<form method="post" action="/post">
+<p>Row {{.Num}}: {{.Status}}</p>
+<input type="hidden" name="row" value="{{.Num}}">
+<input type="hidden" name="token" value="{{$.Token}}">
+<input type="submit" value="Post">
+</form>
+{{else}}
+<p>Nothing to post.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (s *previewServer) handleList(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+
+	rows, err := s.unposted()
+	if err != nil {
+		log.Printf("failed to read pending rows: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type item struct {
+		Num    int
+		Status string
+	}
+	items := make([]item, len(rows))
+	for i, r := range rows {
+		items[i] = item{Num: r.num, Status: s.render(r)}
+	}
+
+	page := struct {
+		Token string
+		Items []item
+	}{s.token, items}
+	if err := listTemplate.Execute(w, page); err != nil {
+		log.Printf("failed to render the list of pending rows: %v", err)
+	}
+}
+
+func (s *previewServer) handlePost(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.FormValue("token")), []byte(s.token)) != 1 {
+		http.Error(w, "bad token", http.StatusForbidden)
+		return
+	}
+
+	num, err := strconv.Atoi(req.FormValue("row"))
+	if err != nil {
+		http.Error(w, "bad row number", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Re-read the rows, since the one to post may have been posted or
+	// changed since the list was served.
+	rows, err := s.unpostedLocked()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r, err := findRow(rows, num)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	err = s.publish(req.Context(), r)
+	if err == nil || errors.Is(err, ErrSheetWrite) {
+		if s.posted == nil {
+			s.posted = make(map[int]bool)
+		}
+		s.posted[num] = true
+	}
+	if err != nil {
+		log.Printf("failed to post row %d: %v", num, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("posted row %d", num)
+	http.Redirect(w, req, "/", http.StatusSeeOther)
+}
+
+// unposted returns the pending rows, less those posted by s.
+func (s *previewServer) unposted() ([]row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unpostedLocked()
+}
+
+// unpostedLocked is unposted, for callers holding s.mu.
+func (s *previewServer) unpostedLocked() ([]row, error) {
+	rows, err := s.pending()
+	if err != nil {
+		return nil, err
+	}
+	var unposted []row
+	for _, r := range rows {
+		if !s.posted[r.num] {
+			unposted = append(unposted, r)
+		}
+	}
+	return unposted, nil
+}
+
+// newServeToken returns a random token for a previewServer, new to each
+// process.
+func newServeToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// serveAddress returns the address on which to listen for addr, which
+// listens only on localhost if it names no host, as in ":8080".
+func serveAddress(addr string) string {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return addr
+}
+
+// findRow returns the row numbered num.
+func findRow(rows []row, num int) (row, error) {
+	for _, r := range rows {
+		if r.num == num {
+			return r, nil
+		}
+	}
+	return row{}, errors.New("row " + strconv.Itoa(num) + " is not pending")
+}
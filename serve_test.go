@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreviewServerPost(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		method     string
+		form       url.Values
+		wantStatus int
+		wantPosted []int
+	}{
+		{
+			name:       "posts",
+			method:     http.MethodPost,
+			form:       url.Values{"row": {"3"}, "token": {"secret"}},
+			wantStatus: http.StatusSeeOther,
+			wantPosted: []int{3},
+		},
+		{
+			name:       "no token",
+			method:     http.MethodPost,
+			form:       url.Values{"row": {"3"}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wrong token",
+			method:     http.MethodPost,
+			form:       url.Values{"row": {"3"}, "token": {"guess"}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "not pending",
+			method:     http.MethodPost,
+			form:       url.Values{"row": {"9"}, "token": {"secret"}},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "bad row",
+			method:     http.MethodPost,
+			form:       url.Values{"row": {"three"}, "token": {"secret"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not a post",
+			method:     http.MethodGet,
+			form:       url.Values{"row": {"3"}, "token": {"secret"}},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var posted []int
+			s := &previewServer{
+				pending: func() ([]row, error) { return testRows("a", "b"), nil },
+				render:  func(r row) string { return r.cell(0) },
+				publish: func(ctx context.Context, r row) error {
+					posted = append(posted, r.num)
+					return nil
+				},
+				token: "secret",
+			}
+			req := httptest.NewRequest(tc.method, "/post", strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.handler().ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tc.wantStatus)
+			}
+			if !reflect.DeepEqual(posted, tc.wantPosted) {
+				t.Errorf("posted rows %v, want %v", posted, tc.wantPosted)
+			}
+		})
+	}
+}
+
+// Rows posted are no longer listed or posted again, even though, without a
+// status column or when marking fails, they're still read as pending.
+func TestPreviewServerHidesPosted(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		publishErr error
+		wantHidden bool
+	}{
+		{name: "posted", wantHidden: true},
+		{name: "posted but not marked", publishErr: ErrSheetWrite, wantHidden: true},
+		{name: "not posted", publishErr: ErrPost},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var posted []int
+			s := &previewServer{
+				pending: func() ([]row, error) { return testRows("a", "b"), nil },
+				render:  func(r row) string { return r.cell(0) },
+				publish: func(ctx context.Context, r row) error {
+					posted = append(posted, r.num)
+					return tc.publishErr
+				},
+				token: "secret",
+			}
+			post := func() int {
+				form := url.Values{"row": {"2"}, "token": {"secret"}}
+				req := httptest.NewRequest(http.MethodPost, "/post", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				w := httptest.NewRecorder()
+				s.handler().ServeHTTP(w, req)
+				return w.Code
+			}
+
+			post()
+			w := httptest.NewRecorder()
+			s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			body, _ := ioutil.ReadAll(w.Body)
+			if listed := strings.Contains(string(body), "Row 2:"); listed == tc.wantHidden {
+				t.Errorf("row 2 listed: %t, want %t:\n%s", listed, !tc.wantHidden, body)
+			}
+			if !strings.Contains(string(body), "Row 3:") {
+				t.Errorf("row 3 isn't listed:\n%s", body)
+			}
+
+			code := post()
+			if tc.wantHidden && (code != http.StatusConflict || len(posted) != 1) {
+				t.Errorf("posting row 2 again = %d, with %d posts, want %d, with 1", code, len(posted), http.StatusConflict)
+			}
+			if !tc.wantHidden && len(posted) != 2 {
+				t.Errorf("made %d posts, want row 2 tried again", len(posted))
+			}
+		})
+	}
+}
+
+func TestPreviewServerListHasToken(t *testing.T) {
+	s := &previewServer{
+		pending: func() ([]row, error) { return testRows("<b>hi</b>"), nil },
+		render:  func(r row) string { return r.cell(0) },
+		token:   "secret",
+	}
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+	if !strings.Contains(string(body), `name="token" value="secret"`) {
+		t.Errorf("the list has no token field:\n%s", body)
+	}
+	if strings.Contains(string(body), "<b>hi</b>") {
+		t.Errorf("the list doesn't escape statuses:\n%s", body)
+	}
+}
+
+func TestServeAddress(t *testing.T) {
+	for in, want := range map[string]string{
+		":8080":          "127.0.0.1:8080",
+		"127.0.0.1:8080": "127.0.0.1:8080",
+		"0.0.0.0:8080":   "0.0.0.0:8080",
+		"[::1]:8080":     "[::1]:8080",
+		"localhost:80":   "localhost:80",
+	} {
+		if got := serveAddress(in); got != want {
+			t.Errorf("serveAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewServeToken(t *testing.T) {
+	a, err := newServeToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newServeToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 32 || a == b {
+		t.Errorf("newServeToken() = %q, then %q, want distinct 32-character tokens", a, b)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sheetTimeLayouts are the layouts parseSheetTime accepts, covering the
+// common ways Sheets displays dates and times.
+var sheetTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"1/2/2006",
+}
+
+// sheetsEpoch is day 0 of the serial numbers Sheets uses for dates.
+var sheetsEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// parseSheetTime parses a date or time from a cell, either in one of
+// sheetTimeLayouts or as a serial number of days since sheetsEpoch.
+// Times without a zone are taken to be in loc.
+func parseSheetTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range sheetTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	if days, err := strconv.ParseFloat(s, 64); err == nil {
+		t := sheetsEpoch.Add(time.Duration(days * float64(24*time.Hour)))
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date or time %q", s)
+}
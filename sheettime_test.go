@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSheetTime(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	for _, tc := range []struct {
+		in      string
+		loc     *time.Location
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2024-06-03", loc: time.UTC, want: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{in: "2024-06-03 14:05", loc: ny, want: time.Date(2024, 6, 3, 14, 5, 0, 0, ny)},
+		{in: " 6/3/2024 14:05:06 ", loc: time.UTC, want: time.Date(2024, 6, 3, 14, 5, 6, 0, time.UTC)},
+		{in: "2024-06-03T14:05:00Z", loc: ny, want: time.Date(2024, 6, 3, 14, 5, 0, 0, time.UTC)},
+		{in: "45446", loc: time.UTC, want: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{in: "45446.5", loc: ny, want: time.Date(2024, 6, 3, 12, 0, 0, 0, ny)},
+		{in: "next Tuesday", loc: time.UTC, wantErr: true},
+		{in: "", loc: time.UTC, wantErr: true},
+	} {
+		got, err := parseSheetTime(tc.in, tc.loc)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseSheetTime(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && !got.Equal(tc.want) {
+			t.Errorf("parseSheetTime(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sheetRange is a range of a sheet that rows are read from, and marked
+// complete in.
+type sheetRange struct {
+	id, name, cellRange string
+	rng                 a1Range    // the parsed cellRange.
+	view                viewFilter // nil unless --filter_view_id is set.
+}
+
+// The major dimensions of --major_dimension, along which the sheet is read
+// into rows.
+const (
+	dimensionRows    = "ROWS"
+	dimensionColumns = "COLUMNS"
+)
+
+func (s *sheetRange) String() string {
+	return fmt.Sprintf("%s:%s!%s", s.id, s.name, s.cellRange)
+}
+
+// parseSheetSource parses a --source of the form "spreadsheetID:sheetName!range".
+func parseSheetSource(s string) (*sheetRange, error) {
+	i := strings.Index(s, ":")
+	j := strings.LastIndex(s, "!")
+	if i <= 0 || j < i+2 || j == len(s)-1 {
+		return nil, fmt.Errorf("source %q is not of the form SPREADSHEET_ID:SHEET_NAME!RANGE", s)
+	}
+
+	sr := &sheetRange{id: s[:i], name: s[i+1 : j], cellRange: s[j+1:]}
+	rng, err := parseA1Range(sr.cellRange)
+	if err != nil {
+		return nil, fmt.Errorf("bad range in source %q: %v", s, err)
+	}
+	sr.rng = rng
+	return sr, nil
+}
+
+// sourcesFlag is a repeatable flag of sources, each parsed by
+// parseSheetSource.
+type sourcesFlag []*sheetRange
+
+func (f *sourcesFlag) String() string {
+	var ss []string
+	for _, s := range *f {
+		ss = append(ss, s.String())
+	}
+	return strings.Join(ss, ",")
+}
+
+func (f *sourcesFlag) Set(s string) error {
+	sr, err := parseSheetSource(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, sr)
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestParseSheetSource(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "abc123:Sheet1!A2:E", want: "abc123:Sheet1!A2:E"},
+		{in: "abc123:My Sheet!B3:F10", want: "abc123:My Sheet!B3:F10"},
+		{in: "abc123:Odd!Name!A2:E", want: "abc123:Odd!Name!A2:E"},
+		{in: "abc123:Sheet1", wantErr: true},
+		{in: ":Sheet1!A2:E", wantErr: true},
+		{in: "abc123:!A2:E", wantErr: true},
+		{in: "abc123:Sheet1!", wantErr: true},
+		{in: "abc123:Sheet1!bad", wantErr: true},
+	} {
+		got, err := parseSheetSource(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseSheetSource(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tc.want {
+			t.Errorf("parseSheetSource(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSourcesFlag(t *testing.T) {
+	var sources sourcesFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&sources, "source", "")
+	if err := fs.Parse([]string{"-source", "a:One!A2:E", "-source", "b:Two!B2:C"}); err != nil {
+		t.Fatalf("Parse() = %v", err)
+	}
+	if got, want := sources.String(), "a:One!A2:E,b:Two!B2:C"; got != want {
+		t.Errorf("sources = %q, want %q", got, want)
+	}
+	if sources[1].rng.startCol != 1 {
+		t.Errorf("second source's range starts at column %d, want 1", sources[1].rng.startCol)
+	}
+
+	if err := sources.Set("not a source"); err == nil {
+		t.Error("Set() accepted a bad source")
+	}
+	if len(sources) != 2 {
+		t.Errorf("a bad source was added: %v", sources.String())
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// computeSpreadTimes returns when, as offsets from the start of window, to
+// make each of count posts. The window is split into count equal slots and
+// each post is made at a random point in its own slot, so the posts are
+// roughly evenly spaced but not mechanically so. The same seed always
+// gives the same times.
+func computeSpreadTimes(count int, window time.Duration, seed int64) []time.Duration {
+	if count <= 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	slot := window / time.Duration(count)
+	times := make([]time.Duration, count)
+	for i := range times {
+		times[i] = time.Duration(i)*slot + time.Duration(rng.Int63n(int64(slot)+1))
+	}
+	return times
+}
+
+// sleepUntil waits until t, returning early with the context's error if it
+// is done first.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComputeSpreadTimes(t *testing.T) {
+	for _, tc := range []struct {
+		count  int
+		window time.Duration
+	}{
+		{count: 1, window: time.Hour},
+		{count: 3, window: time.Hour},
+		{count: 10, window: 24 * time.Hour},
+		{count: 4, window: 0},
+	} {
+		times := computeSpreadTimes(tc.count, tc.window, 42)
+		if len(times) != tc.count {
+			t.Fatalf("computeSpreadTimes(%d, %v) returned %d times", tc.count, tc.window, len(times))
+		}
+		slot := tc.window / time.Duration(tc.count)
+		for i, d := range times {
+			if lo, hi := time.Duration(i)*slot, time.Duration(i+1)*slot; d < lo || d > hi {
+				t.Errorf("computeSpreadTimes(%d, %v)[%d] = %v, want within [%v, %v]", tc.count, tc.window, i, d, lo, hi)
+			}
+		}
+		if again := computeSpreadTimes(tc.count, tc.window, 42); !reflect.DeepEqual(again, times) {
+			t.Errorf("computeSpreadTimes(%d, %v) isn't repeatable: %v, then %v", tc.count, tc.window, times, again)
+		}
+	}
+}
+
+func TestComputeSpreadTimesNone(t *testing.T) {
+	for _, count := range []int{0, -1} {
+		if got := computeSpreadTimes(count, time.Hour, 1); got != nil {
+			t.Errorf("computeSpreadTimes(%d) = %v, want nil", count, got)
+		}
+	}
+}
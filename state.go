@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// postState records, across runs, when each status was last posted, so
+// that identical statuses aren't posted again too soon.
+type postState struct {
+	// Posted maps the SHA-256 of each posted status to when it was last
+	// posted.
+	Posted map[string]time.Time `json:"posted"`
+}
+
+// loadState reads the state at path. A missing file is an empty state.
+// Unlike a checkpoint, a corrupt state is an error, since ignoring it could
+// repost everything it records.
+func loadState(path string) (*postState, error) {
+	s := &postState{Posted: make(map[string]time.Time)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Posted == nil {
+		s.Posted = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// saveState writes s to path atomically. With a window, statuses posted
+// longer ago than it are forgotten, since they can be posted again anyway.
+func saveState(path string, s *postState, window time.Duration, now time.Time) error {
+	if window > 0 {
+		for h, t := range s.Posted {
+			if now.Sub(t) >= window {
+				delete(s.Posted, h)
+			}
+		}
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func statusHash(status string) string {
+	sum := sha256.Sum256([]byte(status))
+	return hex.EncodeToString(sum[:])
+}
+
+// recentlyPosted reports whether status was posted within window of now.
+// Without a window, any earlier post of it counts.
+func (s *postState) recentlyPosted(status string, window time.Duration, now time.Time) bool {
+	t, ok := s.Posted[statusHash(status)]
+	if !ok {
+		return false
+	}
+	return window <= 0 || now.Sub(t) < window
+}
+
+// recordPost records that status was posted at now.
+func (s *postState) recordPost(status string, now time.Time) {
+	s.Posted[statusHash(status)] = now
+}
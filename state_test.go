@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecentlyPosted(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	s := &postState{Posted: make(map[string]time.Time)}
+	s.recordPost("hello", now.Add(-2*time.Hour))
+
+	for _, tc := range []struct {
+		status string
+		window time.Duration
+		want   bool
+	}{
+		{status: "hello", window: 0, want: true},
+		{status: "hello", window: 3 * time.Hour, want: true},
+		{status: "hello", window: 2 * time.Hour, want: false},
+		{status: "hello", window: time.Hour, want: false},
+		{status: "goodbye", window: 0, want: false},
+		{status: "Hello", window: 0, want: false},
+	} {
+		if got := s.recentlyPosted(tc.status, tc.window, now); got != tc.want {
+			t.Errorf("recentlyPosted(%q, %v) = %t, want %t", tc.status, tc.window, got, tc.want)
+		}
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	s, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState(missing) = %v", err)
+	}
+	if len(s.Posted) != 0 {
+		t.Fatalf("loadState(missing) = %v, want empty", s.Posted)
+	}
+
+	s.recordPost("old", now.Add(-48*time.Hour))
+	s.recordPost("new", now.Add(-time.Hour))
+	if err := saveState(path, s, 24*time.Hour, now); err != nil {
+		t.Fatalf("saveState() = %v", err)
+	}
+
+	loaded, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState() = %v", err)
+	}
+	if loaded.recentlyPosted("old", 0, now) {
+		t.Error("a post older than the window was kept")
+	}
+	if !loaded.recentlyPosted("new", 0, now) {
+		t.Error("a post within the window was forgotten")
+	}
+}
+
+func TestSaveStateWithoutWindowKeepsAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	s := &postState{Posted: make(map[string]time.Time)}
+	s.recordPost("ancient", now.AddDate(-1, 0, 0))
+	if err := saveState(path, s, 0, now); err != nil {
+		t.Fatalf("saveState() = %v", err)
+	}
+	loaded, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState() = %v", err)
+	}
+	if !loaded.recentlyPosted("ancient", 0, now) {
+		t.Error("saveState() without a window forgot a post")
+	}
+}
+
+func TestLoadStateCorrupt(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "corrupt", data: "{not json", wantErr: true},
+		{name: "null posted", data: `{"posted": null}`},
+		{name: "empty object", data: `{}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if err := ioutil.WriteFile(path, []byte(tc.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			s, err := loadState(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("loadState() = %v, want error: %t", err, tc.wantErr)
+			}
+			if err == nil && s.Posted == nil {
+				t.Error("loadState() left Posted nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Templates are rendered by one of these engines.
+const (
+	// engineSimple replaces placeholders such as "{2}" with the value of
+	// the row's third column.
+	engineSimple = "simple"
+	// engineGo renders text/template templates, with the row's values as
+	// dot (so "{{index . 2}}" is the third column) and templateFuncs.
+	// With --json_columns, dot is a map of the values by both index and
+	// name, so that "{{.col2}}" is also the third column.
+	// Both engines give the name of the sheet the row came from, and the
+	// ID of its spreadsheet, as "{sheet}" and "{spreadsheet}", or
+	// "{{sheet}}" and "{{spreadsheet}}".
+	engineGo = "go"
+)
+
+// placeholderRE matches a simple template placeholder such as "{2}".
+var placeholderRE = regexp.MustCompile(`\{(\d+)\}`)
+
+// goIndexRE matches a Go template's indexing of a row, such as "index . 2".
+var goIndexRE = regexp.MustCompile(`index\s+\.\s+(\d+)`)
+
+// goNameRE matches a Go template's reference to a column by name, such as
+// ".col2".
+var goNameRE = regexp.MustCompile(`(?:^|[^\w.])\.col(\d+)\b`)
+
+// templateFuncs are the functions available to Go templates.
+var templateFuncs = template.FuncMap{
+	"upper": func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+	"lower": func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+	"trim":  func(v interface{}) string { return strings.TrimSpace(fmt.Sprint(v)) },
+	// truncate shortens a value to at most n runes.
+	"truncate": func(v interface{}, n int) string {
+		r := []rune(fmt.Sprint(v))
+		if len(r) <= n {
+			return string(r)
+		}
+		return string(r[:n])
+	},
+	// sheet and spreadsheet are replaced for each row by sourceFuncs.
+	"sheet":       func() string { return "" },
+	"spreadsheet": func() string { return "" },
+	// default returns def if a value is empty.
+	"default": func(def string, v interface{}) string {
+		if s := fmt.Sprint(v); s != "" && v != nil {
+			return s
+		}
+		return def
+	},
+}
+
+// rowSource returns the name of the sheet r was read from and its
+// spreadsheet's ID, which are empty for --input_file.
+func rowSource(r row) (sheet, spreadsheet string) {
+	if r.sheet == nil {
+		return "", ""
+	}
+	return r.sheet.name, r.sheet.id
+}
+
+// sourceFuncs returns the template functions giving where r was read from.
+func sourceFuncs(r row) template.FuncMap {
+	sheet, spreadsheet := rowSource(r)
+	return template.FuncMap{
+		"sheet":       func() string { return sheet },
+		"spreadsheet": func() string { return spreadsheet },
+	}
+}
+
+// renderSource replaces the "{sheet}" and "{spreadsheet}" placeholders of
+// a simple template with where r was read from.
+func renderSource(tmpl string, r row) string {
+	if !strings.Contains(tmpl, "{sheet}") && !strings.Contains(tmpl, "{spreadsheet}") {
+		return tmpl
+	}
+	sheet, spreadsheet := rowSource(r)
+	return strings.NewReplacer("{sheet}", sheet, "{spreadsheet}", spreadsheet).Replace(tmpl)
+}
+
+// parseGoTemplate compiles tmpl for the Go engine.
+func parseGoTemplate(tmpl string) (*template.Template, error) {
+	return template.New("status").Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+}
+
+// unescapeTemplate interprets the escapes `\n` and `\t` in s as a newline
+// and a tab, and `\\` as a single backslash, so that `\\n` stays a literal
+// backslash followed by n. Any other backslash, including a trailing one,
+// is kept as is.
+func unescapeTemplate(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// renderTemplate replaces each placeholder in tmpl with the corresponding
+// value from values. Placeholders past the end of values render as "".
+func renderTemplate(tmpl string, values []interface{}) string {
+	return placeholderRE.ReplaceAllStringFunc(tmpl, func(m string) string {
+		i, _ := strconv.Atoi(m[1 : len(m)-1])
+		if i >= len(values) {
+			return ""
+		}
+		return fmt.Sprint(values[i])
+	})
+}
+
+// maxColumnReferenced returns the largest column index referenced by tmpl,
+// with either a simple placeholder or a Go template's index or name, or -1
+// if there are none.
+func maxColumnReferenced(tmpl string) int {
+	max := -1
+	for _, re := range []*regexp.Regexp{placeholderRE, goIndexRE, goNameRE} {
+		for _, m := range re.FindAllStringSubmatch(tmpl, -1) {
+			if i, err := strconv.Atoi(m[1]); err == nil && i > max {
+				max = i
+			}
+		}
+	}
+	return max
+}
+
+// joinRow joins the non-empty values of row with sep.
+func joinRow(row []interface{}, sep string) string {
+	var parts []string
+	for _, v := range row {
+		if s := fmt.Sprint(v); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// renderStatus renders the status for r with the configured template or,
+// failing that, by joining its values with the configured separator.
+// Without either, it falls back to a dump of the row's values.
+//
+// With --columns, only the values of those columns, in that order, are used.
+// Values are formatted as --column_formats gives, and for the go engine,
+// those of --json_columns are parsed as JSON.
+// Empty values, including those past the end of the row, render as
+// --empty_placeholder.
+func renderStatus(r row, rc *runConfig) (string, error) {
+	values := r.values
+	if rc.columns != nil {
+		var err error
+		if values, err = projectRow(r, rc.columns); err != nil {
+			return "", err
+		}
+	}
+	if len(rc.columnFormats) > 0 {
+		formatted := append([]interface{}(nil), values...)
+		for i, spec := range rc.columnFormats {
+			if i >= len(formatted) {
+				continue
+			}
+			s, err := formatCell(formatted[i], spec)
+			if err != nil {
+				return "", fmt.Errorf("failed to format value %d: %v", i, err)
+			}
+			formatted[i] = s
+		}
+		values = formatted
+	}
+	if rc.goTemplate != nil && len(rc.jsonColumns) > 0 {
+		values = parseJSONColumns(r, values, rc)
+	}
+	// Sheets leaves out a row's trailing empty cells, so for the go engine,
+	// the row is padded to the width of its range, and to cover the
+	// template, so that indexing them renders empty rather than failing.
+	if rc.goTemplate != nil {
+		width := maxColumnReferenced(rc.template) + 1
+		if w := rangeWidth(r); rc.columns == nil && w > width {
+			width = w
+		}
+		values = padRow(values, width)
+	}
+	if rc.emptyPlaceholder != "" {
+		values = fillEmpty(values, maxColumnReferenced(rc.template)+1, rc.emptyPlaceholder)
+	}
+
+	switch {
+	case rc.goTemplate != nil:
+		tmpl, err := rc.goTemplate.Clone()
+		if err != nil {
+			return "", fmt.Errorf("failed to render template: %v", err)
+		}
+		var dot interface{} = values
+		if len(rc.jsonColumns) > 0 {
+			dot = namedValues(values)
+		}
+		var b strings.Builder
+		if err := tmpl.Funcs(sourceFuncs(r)).Execute(&b, dot); err != nil {
+			return "", fmt.Errorf("failed to render template: %v", err)
+		}
+		return b.String(), nil
+	case rc.template != "":
+		return renderTemplate(renderSource(rc.template, r), values), nil
+	case rc.join != "":
+		return joinRow(values, rc.join), nil
+	default:
+		return fmt.Sprintf("some cool data: %v", values), nil
+	}
+}
+
+// rangeWidth returns the number of columns of the range r was read from,
+// or 0 if it's not known, as for --input_file.
+func rangeWidth(r row) int {
+	if r.sheet == nil || r.sheet.rng.endCol < r.sheet.rng.startCol {
+		return 0
+	}
+	return r.sheet.rng.endCol - r.sheet.rng.startCol + 1
+}
+
+// padRow returns values, padded with empty strings to at least n values.
+func padRow(values []interface{}, n int) []interface{} {
+	if len(values) >= n {
+		return values
+	}
+	padded := make([]interface{}, n)
+	copy(padded, values)
+	for i := len(values); i < n; i++ {
+		padded[i] = ""
+	}
+	return padded
+}
+
+// fillEmpty returns values, padded to at least n values, with each empty
+// value replaced by placeholder.
+func fillEmpty(values []interface{}, n int, placeholder string) []interface{} {
+	if n < len(values) {
+		n = len(values)
+	}
+	filled := make([]interface{}, n)
+	for i := range filled {
+		filled[i] = placeholder
+		if i < len(values) && values[i] != nil && fmt.Sprint(values[i]) != "" {
+			filled[i] = values[i]
+		}
+	}
+	return filled
+}
+
+// blankRow reports whether all of r's values are empty, so that it isn't
+// posted, whatever --empty_placeholder would fill it with.
+func blankRow(r row) bool {
+	for _, v := range r.values {
+		if v != nil && strings.TrimSpace(fmt.Sprint(v)) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseJSONColumns replaces the values of --json_columns with the maps,
+// slices and scalars their JSON decodes to, so that Go templates can index
+// into them, as in "{{.col3.name}}". values are the row's values, as
+// projected by --columns. A value that isn't valid JSON is left as it is,
+// with a warning.
+func parseJSONColumns(r row, values []interface{}, rc *runConfig) []interface{} {
+	parsed := append([]interface{}(nil), values...)
+	for _, col := range rc.jsonColumns {
+		i := col - r.firstCol
+		if rc.columns != nil {
+			i = -1
+			for j, c := range rc.columns {
+				if c == col {
+					i = j
+					break
+				}
+			}
+		}
+		if i < 0 || i >= len(parsed) {
+			continue
+		}
+
+		s := strings.TrimSpace(fmt.Sprint(parsed[i]))
+		if s == "" {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			log.Printf("warning: row %d: column %s is not valid JSON, so using it as text: %v", r.num, columnLetters(col), err)
+			continue
+		}
+		parsed[i] = v
+	}
+	return parsed
+}
+
+// namedValues returns a map of values by both their index and their name,
+// "col" followed by the index, so that a Go template can reach a value as
+// "{{index . 3}}" or "{{.col3}}".
+func namedValues(values []interface{}) map[interface{}]interface{} {
+	named := make(map[interface{}]interface{}, 2*len(values))
+	for i, v := range values {
+		named[i] = v
+		named["col"+strconv.Itoa(i)] = v
+	}
+	return named
+}
+
+// projectRow returns the values of r in the 0-based sheet columns cols, in
+// that order. A column may appear more than once. Columns past the end of
+// the row's values are empty, as Sheets leaves out trailing empty cells,
+// but a column before the row's first is an error.
+func projectRow(r row, cols []int) ([]interface{}, error) {
+	values := make([]interface{}, len(cols))
+	for i, col := range cols {
+		if col < r.firstCol {
+			return nil, fmt.Errorf("column %s is outside of the read range", columnLetters(col))
+		}
+		values[i] = r.cell(col)
+	}
+	return values, nil
+}
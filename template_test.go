@@ -0,0 +1,264 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectRow(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		row     row
+		cols    []int
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			name: "reordered",
+			row:  row{values: []interface{}{"a", "b", "c", "d", "e"}},
+			cols: []int{2, 0, 4},
+			want: []interface{}{"c", "a", "e"},
+		},
+		{
+			name: "duplicated",
+			row:  row{values: []interface{}{"a", "b"}},
+			cols: []int{1, 1, 0},
+			want: []interface{}{"b", "b", "a"},
+		},
+		{
+			name: "past the end of the row",
+			row:  row{values: []interface{}{"a", "b"}},
+			cols: []int{0, 5},
+			want: []interface{}{"a", ""},
+		},
+		{
+			name: "read from column C",
+			row:  row{firstCol: 2, values: []interface{}{"c", "d"}},
+			cols: []int{3, 2},
+			want: []interface{}{"d", "c"},
+		},
+		{
+			name:    "before the read range",
+			row:     row{firstCol: 2, values: []interface{}{"c", "d"}},
+			cols:    []int{2, 0},
+			wantErr: true,
+		},
+		{
+			name: "no columns",
+			row:  row{values: []interface{}{"a"}},
+			cols: []int{},
+			want: []interface{}{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := projectRow(tc.row, tc.cols)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("projectRow() = %v, want an error: %t", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("projectRow() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnescapeTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: `{0}\n{1}`, want: "{0}\n{1}"},
+		{in: `a\nb`, want: "a\nb"},
+		{in: `a\n\nb`, want: "a\n\nb"},
+		{in: `a\tb`, want: "a\tb"},
+		{in: `a\\b`, want: `a\b`},
+		{in: `a\\nb`, want: `a\nb`},
+		{in: `a\\\nb`, want: "a\\\nb"},
+		{in: `a\xb`, want: `a\xb`},
+		{in: `trailing\`, want: `trailing\`},
+		{in: "no escapes", want: "no escapes"},
+		{in: "", want: ""},
+	} {
+		if got := unescapeTemplate(tc.in); got != tc.want {
+			t.Errorf("unescapeTemplate(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMaxColumnReferenced(t *testing.T) {
+	for _, tc := range []struct {
+		tmpl string
+		want int
+	}{
+		{tmpl: "{0}", want: 0},
+		{tmpl: "{2} and {10}, then {4}", want: 10},
+		{tmpl: "{3} {3}", want: 3},
+		{tmpl: "no placeholders", want: -1},
+		{tmpl: "", want: -1},
+		{tmpl: "{name} and {}", want: -1},
+		// Doubled braces still hold a placeholder, rendered in braces.
+		{tmpl: "{{1}}", want: 1},
+		// Escaped braces don't make a placeholder.
+		{tmpl: `\{5\}`, want: -1},
+		{tmpl: "{{index . 0}} {{index . 7}}", want: 7},
+		{tmpl: "{{.col4}} {{.col12.name}}", want: 12},
+		{tmpl: "{{.Col9}} {{$.col2x}}", want: -1},
+	} {
+		if got := maxColumnReferenced(tc.tmpl); got != tc.want {
+			t.Errorf("maxColumnReferenced(%q) = %d, want %d", tc.tmpl, got, tc.want)
+		}
+	}
+}
+
+func TestJoinRow(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		row  []interface{}
+		sep  string
+		want string
+	}{
+		{name: "all filled", row: []interface{}{"a", "b", "c"}, sep: " - ", want: "a - b - c"},
+		{name: "empty in the middle", row: []interface{}{"a", "", "c"}, sep: " - ", want: "a - c"},
+		{name: "trailing empties", row: []interface{}{"a", "b", "", ""}, sep: " - ", want: "a - b"},
+		{name: "leading empty", row: []interface{}{"", "b"}, sep: ", ", want: "b"},
+		{name: "all empty", row: []interface{}{"", ""}, sep: " - ", want: ""},
+		{name: "no cells", sep: " - ", want: ""},
+		{name: "empty separator", row: []interface{}{"a", "b"}, want: "ab"},
+		{name: "non-string values", row: []interface{}{1, 2.5, true}, sep: "/", want: "1/2.5/true"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := joinRow(tc.row, tc.sep); got != tc.want {
+				t.Errorf("joinRow(%q, %q) = %q, want %q", tc.row, tc.sep, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderStatusJSONColumns(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		template string
+		values   []interface{}
+		columns  []int
+		want     string
+	}{
+		{
+			name:     "nested field",
+			template: `{{index . 0}} by {{(index . 1).author.name}}`,
+			values:   []interface{}{"Post", `{"author": {"name": "Ada"}}`},
+			want:     "Post by Ada",
+		},
+		{
+			name:     "nested field by name",
+			template: `{{ .col0 }} by {{ .col1.author.name }}`,
+			values:   []interface{}{"Post", `{"author": {"name": "Ada"}}`},
+			want:     "Post by Ada",
+		},
+		{
+			name:     "empty cell by name",
+			template: `[{{.col3}}]`,
+			values:   []interface{}{"Post", `{}`},
+			want:     "[]",
+		},
+		{
+			name:     "list element",
+			template: `{{index (index . 1) 1}}`,
+			values:   []interface{}{"Post", `["a", "b"]`},
+			want:     "b",
+		},
+		{
+			name:     "invalid JSON is text",
+			template: `{{index . 1}}`,
+			values:   []interface{}{"Post", `{"author": `},
+			want:     `{"author": `,
+		},
+		{
+			name:     "empty cell",
+			template: `[{{index . 1}}]`,
+			values:   []interface{}{"Post"},
+			want:     "[]",
+		},
+		{
+			name:     "projected by --columns",
+			template: `{{(index . 0).n}}`,
+			values:   []interface{}{"Post", `{"n": 7}`},
+			columns:  []int{1},
+			want:     "7",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := parseGoTemplate(tc.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc := &runConfig{template: tc.template, goTemplate: tmpl, jsonColumns: []int{1}, columns: tc.columns}
+			got, err := renderStatus(row{num: 2, values: tc.values}, rc)
+			if err != nil {
+				t.Fatalf("renderStatus() failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("renderStatus() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderStatusPadsRows(t *testing.T) {
+	sr := &sheetRange{id: "sheet-id", name: "Posts", cellRange: "A2:E", rng: a1Range{startCol: 0, startRow: 2, endCol: 4}}
+	for _, tc := range []struct {
+		name     string
+		template string
+		row      row
+		want     string
+	}{
+		{
+			name:     "past the referenced columns",
+			template: `{{index . 0}}{{if gt (len .) 4}} [{{index . 4}}]{{end}}`,
+			row:      row{num: 2, values: []interface{}{"a"}, sheet: sr},
+			want:     "a []",
+		},
+		{
+			name:     "referenced column",
+			template: `{{index . 0}}-{{index . 6}}`,
+			row:      row{num: 2, values: []interface{}{"a"}},
+			want:     "a-",
+		},
+		{
+			name:     "source",
+			template: `{{index . 0}} from {{sheet}} of {{spreadsheet}}`,
+			row:      row{num: 2, values: []interface{}{"a"}, sheet: sr},
+			want:     "a from Posts of sheet-id",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := parseGoTemplate(tc.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderStatus(tc.row, &runConfig{template: tc.template, goTemplate: tmpl})
+			if err != nil {
+				t.Fatalf("renderStatus() failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("renderStatus() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderSource(t *testing.T) {
+	sr := &sheetRange{id: "sheet-id", name: "Posts"}
+	for _, tc := range []struct {
+		tmpl string
+		row  row
+		want string
+	}{
+		{tmpl: "{0} in {sheet}", row: row{sheet: sr}, want: "{0} in Posts"},
+		{tmpl: "{spreadsheet}/{sheet}", row: row{sheet: sr}, want: "sheet-id/Posts"},
+		{tmpl: "{0} in {sheet}", row: row{}, want: "{0} in "},
+		{tmpl: "{0}", row: row{sheet: sr}, want: "{0}"},
+	} {
+		if got := renderSource(tc.tmpl, tc.row); got != tc.want {
+			t.Errorf("renderSource(%q) = %q, want %q", tc.tmpl, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// splitIntoThread splits s into parts no longer than max, as measured by
+// length, to be posted as a thread. Parts end at word boundaries where they
+// can; only a word longer than max, such as a long URL, is split within it.
+func splitIntoThread(s string, max int, length func(string) int) []string {
+	if length(s) <= max {
+		return []string{s}
+	}
+
+	var parts []string
+	var cur string
+	for _, w := range splitWords(s) {
+		word := strings.TrimLeftFunc(w, unicode.IsSpace)
+		if cur != "" && length(cur+w) <= max {
+			cur += w
+			continue
+		}
+		if cur != "" {
+			parts = append(parts, cur)
+			cur = ""
+		}
+
+		// As a last resort, a word too long for a part of its own is
+		// split at the limit.
+		for length(word) > max {
+			head := truncate(word, max, length)
+			if head == "" {
+				// Even a single character doesn't fit, so take
+				// one anyway rather than loop forever.
+				head = string([]rune(word)[:1])
+			}
+			parts = append(parts, head)
+			word = word[len(head):]
+		}
+		cur = word
+	}
+	if strings.TrimSpace(cur) != "" {
+		parts = append(parts, cur)
+	}
+	return parts
+}
+
+// threadNumberRoom is the room left in each part of a thread for its
+// number, as added by numberThread.
+const threadNumberRoom = len(" (99/99)")
+
+// composeThread splits status into the parts of a thread, each no longer
+// than the backend allows, after a header rendered from --thread_header if
+// it's set. With --number_thread, each part ends with its number in the
+// thread, as "(n/m)", though the header is only counted with
+// --number_header.
+func composeThread(rw row, status string, bc *backendConfig, rc *runConfig) []string {
+	max, length := statusLimit(bc), lengthFunc(bc)
+	if rc.numberThread {
+		max -= threadNumberRoom
+	}
+	parts := splitIntoThread(status, max, length)
+
+	var header []string
+	if rc.threadHeader != "" {
+		header = []string{truncate(renderTemplate(rc.threadHeader, rw.values), max, length)}
+	}
+	if !rc.numberThread {
+		return append(header, parts...)
+	}
+	if rc.numberHeader {
+		return numberThread(append(header, parts...))
+	}
+	return append(header, numberThread(parts)...)
+}
+
+// numberThread appends to each of parts its number in the thread, as
+// "(n/m)", unless there's only one.
+func numberThread(parts []string) []string {
+	if len(parts) < 2 {
+		return parts
+	}
+	numbered := make([]string, len(parts))
+	for i, part := range parts {
+		numbered[i] = fmt.Sprintf("%s (%d/%d)", strings.TrimRightFunc(part, unicode.IsSpace), i+1, len(parts))
+	}
+	return numbered
+}
+
+// splitWords splits s into words, each with the whitespace before it, so
+// that joining them gives back s.
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	inSpace := true
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if space && !inSpace {
+			words = append(words, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// postThread posts each of parts as a reply to the one before, the first
+// replying to the post whose ID is id, and returns the ID of the last post
+// made. A part that fails to post is only a warning, as the row itself has
+// been posted, and ends the thread.
+func (r *runner) postThread(ctx context.Context, rw row, id string, parts []string) string {
+	for i, part := range parts {
+		replyID, err := r.poster.Post(ctx, &post{status: part, replyTo: id})
+		if err != nil {
+			log.Printf("warning: row %d: failed to post part %d of %d of the thread: %v", rw.num, i+2, len(parts)+1, err)
+			break
+		}
+		id = replyID
+	}
+	return id
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitIntoThread(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		max  int
+		want []string
+	}{
+		{name: "fits", in: "hello", max: 10, want: []string{"hello"}},
+		{name: "exactly max", in: "hello worl", max: 10, want: []string{"hello worl"}},
+		{name: "word boundaries", in: "hello world foo bar", max: 10, want: []string{"hello", "world foo", "bar"}},
+		{name: "long word", in: "abcdefghijklmnopqrstuvwxy", max: 10, want: []string{"abcdefghij", "klmnopqrst", "uvwxy"}},
+		{name: "long word after text", in: "hi abcdefghijkl", max: 10, want: []string{"hi", "abcdefghij", "kl"}},
+		{name: "trailing space", in: "hello world   ", max: 8, want: []string{"hello", "world   "}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := splitIntoThread(tc.in, tc.max, runeLength)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("splitIntoThread(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+			}
+			for _, part := range got {
+				if runeLength(part) > tc.max {
+					t.Errorf("part %q is longer than %d", part, tc.max)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitIntoThreadWideRunes(t *testing.T) {
+	// Each of these counts as two, so a limit of one can't fit any of
+	// them, but they must still be split rather than looping forever.
+	got := splitIntoThread("日本語", 1, weightedLength)
+	if want := []string{"日", "本", "語"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitIntoThread() = %q, want %q", got, want)
+	}
+}
+
+func TestNumberThread(t *testing.T) {
+	for _, tc := range []struct {
+		in   []string
+		want []string
+	}{
+		{in: nil, want: nil},
+		{in: []string{"only"}, want: []string{"only"}},
+		{in: []string{"one ", "two"}, want: []string{"one (1/2)", "two (2/2)"}},
+	} {
+		if got := numberThread(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("numberThread(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	for _, in := range []string{"", "one", "one two", "  lead", "a  b\nc ", "tail  "} {
+		if got := strings.Join(splitWords(in), ""); got != in {
+			t.Errorf("joining splitWords(%q) = %q", in, got)
+		}
+	}
+	if got, want := splitWords("a  b\nc"), []string{"a", "  b", "\nc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitWords() = %q, want %q", got, want)
+	}
+}
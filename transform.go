@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errTransformRejected is returned when the transform command exits with a
+// non-zero status, which means the row should be skipped.
+var errTransformRejected = errors.New("the transform command rejected the status")
+
+// runTransform runs cmd with the shell, passing input on stdin, and returns
+// its stdout, without a trailing newline, as the transformed status. The
+// command is killed if ctx is done first.
+func runTransform(ctx context.Context, cmd, input string) (string, error) {
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	c.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	err := c.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("the transform command timed out: %v", ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", fmt.Errorf("%w with %v: %s", errTransformRejected, err, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run the transform command: %v", err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// transformStatus runs --transform_cmd on status, within
+// --transform_timeout, truncating its output to fit the backend unless it's
+// to be split into a thread.
+func (r *runner) transformStatus(ctx context.Context, status string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.rc.transformTimeout)
+	defer cancel()
+
+	out, err := runTransform(ctx, r.rc.transformCmd, status)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", errEmptyStatus
+	}
+	if r.rc.thread {
+		return out, nil
+	}
+	return truncate(out, statusLimit(r.bc), lengthFunc(r.bc)), nil
+}
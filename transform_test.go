@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunTransform(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cmd     string
+		want    string
+		wantErr error
+	}{
+		{name: "identity", cmd: "cat", want: "hello"},
+		{name: "trailing newlines trimmed", cmd: "tr a-z A-Z; echo; echo", want: "HELLO"},
+		{name: "rejected", cmd: "echo nope >&2; exit 1", wantErr: errTransformRejected},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := runTransform(context.Background(), tc.cmd, "hello")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("runTransform() = %v, want %v", err, tc.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "nope") {
+				t.Errorf("runTransform() = %v, want stderr included", err)
+			}
+			if got != tc.want {
+				t.Errorf("runTransform() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunTransformTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := runTransform(ctx, "exec sleep 5", "")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("runTransform() = %v, want a timeout", err)
+	}
+}
+
+func TestTransformStatus(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	for _, tc := range []struct {
+		name    string
+		cmd     string
+		thread  bool
+		wantLen int
+		wantErr error
+	}{
+		{name: "truncated", cmd: "echo " + long, wantLen: 280},
+		{name: "kept whole for a thread", cmd: "echo " + long, thread: true, wantLen: 300},
+		{name: "empty output", cmd: "echo '  '", wantErr: errEmptyStatus},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := testRunConfig()
+			rc.transformCmd = tc.cmd
+			rc.transformTimeout = 10 * time.Second
+			rc.thread = tc.thread
+			r := newTestRunner(&fakePoster{}, rc)
+
+			got, err := r.transformStatus(context.Background(), "ignored")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("transformStatus() = %v, want %v", err, tc.wantErr)
+			}
+			if len(got) != tc.wantLen {
+				t.Errorf("transformStatus() is %d long, want %d", len(got), tc.wantLen)
+			}
+		})
+	}
+}
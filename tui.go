@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiModel is the state of --tui's full-screen review of pending rows,
+// which lists them and steps through them one at a time. It's a bubbletea
+// model, so its transitions are plain calls to Update, without a terminal.
+type tuiModel struct {
+	rows     []row
+	statuses []string // the status each row would be posted with.
+	cur      int      // the index of the row under review.
+
+	// editing is whether keys edit the current row's status, in edit.
+	editing bool
+	edit    []rune
+	// posting is whether the current row is being posted, during which
+	// keys, even ctrl+c, are ignored, so that it's not left half done.
+	posting bool
+	// render returns the status a row would be posted with, for display.
+	render func(row) string
+	// draft returns the text an edit of a row starts from: its rendered
+	// template, unredacted and without the hashtags and URLs that are
+	// appended to it, and to any edit, when it's posted.
+	draft func(row) string
+	// publish returns a command that posts a row and marks it complete.
+	publish func(row) tea.Cmd
+	// message is the outcome of the last post, if it failed.
+	message string
+	// markErr is the error marking a posted row complete, which ends the
+	// review.
+	markErr error
+
+	posted, skipped, failed []int
+}
+
+// postedMsg is the outcome of posting a row and marking it complete.
+type postedMsg struct {
+	num          int
+	err, markErr error
+}
+
+func newTUIModel(rows []row, render, draft func(row) string, publish func(row) tea.Cmd) *tuiModel {
+	m := &tuiModel{rows: rows, statuses: make([]string, len(rows)), render: render, draft: draft, publish: publish}
+	for i, rw := range rows {
+		m.statuses[i] = render(rw)
+	}
+	return m
+}
+
+// done reports whether every row has been reviewed.
+func (m *tuiModel) done() bool {
+	return m.cur >= len(m.rows)
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	if m.done() {
+		return tea.Quit
+	}
+	return nil
+}
+
+// Update handles a key or the outcome of a post. While reviewing, "p"
+// posts the current row, "s" skips it, "e" edits its status, and "q" quits,
+// skipping the rest. While editing, enter keeps the edit and esc drops it.
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case postedMsg:
+		m.posting = false
+		if msg.err != nil {
+			m.failed = append(m.failed, msg.num)
+			m.message = fmt.Sprintf("Failed to post row %d: %v", msg.num, msg.err)
+		} else {
+			m.posted = append(m.posted, msg.num)
+			m.message = ""
+		}
+		m.cur++
+		if msg.markErr != nil {
+			m.markErr = msg.markErr
+			return m, m.quit()
+		}
+		return m, m.quitIfDone()
+	case tea.KeyMsg:
+		if m.posting {
+			return m, nil
+		}
+		if msg.Type == tea.KeyCtrlC {
+			return m, m.quit()
+		}
+		if m.done() {
+			return m, nil
+		}
+		if m.editing {
+			m.editKey(msg)
+			return m, nil
+		}
+		if msg.Type != tea.KeyRunes {
+			return m, nil
+		}
+		switch string(msg.Runes) {
+		case "p":
+			m.posting = true
+			return m, m.publish(m.rows[m.cur])
+		case "s":
+			m.skipped = append(m.skipped, m.rows[m.cur].num)
+			m.cur++
+			return m, m.quitIfDone()
+		case "e":
+			m.editing = true
+			text := m.rows[m.cur].edited
+			if text == "" {
+				text = m.draft(m.rows[m.cur])
+			}
+			m.edit = []rune(text)
+		case "q":
+			return m, m.quit()
+		}
+	}
+	return m, nil
+}
+
+// editKey applies a key to the edit of the current row's status. Edits
+// only change what's posted, not the sheet.
+func (m *tuiModel) editKey(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.editing = false
+		if s := strings.TrimSpace(string(m.edit)); s != "" {
+			m.rows[m.cur].edited = s
+			m.statuses[m.cur] = m.render(m.rows[m.cur])
+		}
+	case tea.KeyEsc:
+		m.editing = false
+	case tea.KeyBackspace:
+		if len(m.edit) > 0 {
+			m.edit = m.edit[:len(m.edit)-1]
+		}
+	case tea.KeySpace:
+		m.edit = append(m.edit, ' ')
+	case tea.KeyRunes:
+		m.edit = append(m.edit, msg.Runes...)
+	}
+}
+
+// quit skips the rows yet to be reviewed and quits.
+func (m *tuiModel) quit() tea.Cmd {
+	for ; m.cur < len(m.rows); m.cur++ {
+		m.skipped = append(m.skipped, m.rows[m.cur].num)
+	}
+	return tea.Quit
+}
+
+func (m *tuiModel) quitIfDone() tea.Cmd {
+	if m.done() {
+		return tea.Quit
+	}
+	return nil
+}
+
+// View lists the rows, marking the one under review, above the keys that
+// apply to it.
+func (m *tuiModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pending posts (%d/%d)\n\n", m.cur, len(m.rows))
+	for i, rw := range m.rows {
+		marker := "  "
+		if i == m.cur {
+			marker = "> "
+		}
+		status := m.statuses[i]
+		if i == m.cur && m.editing {
+			status = string(m.edit) + "_"
+		}
+		fmt.Fprintf(&b, "%sRow %d: %s\n", marker, rw.num, strings.Replace(status, "\n", " ", -1))
+	}
+	b.WriteString("\n")
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+	switch {
+	case m.done():
+		b.WriteString(m.summary())
+	case m.posting:
+		b.WriteString("Posting...\n")
+	case m.editing:
+		b.WriteString("[enter] keep edit, [esc] cancel\n")
+	default:
+		b.WriteString("[p]ost, [s]kip, [e]dit or [q]uit\n")
+	}
+	return b.String()
+}
+
+func (m *tuiModel) summary() string {
+	return fmt.Sprintf("Done: posted %d, skipped %d, failed %d.\n", len(m.posted), len(m.skipped), len(m.failed))
+}
+
+// tui runs --tui's full-screen review of the pending rows on in and out,
+// posting and marking complete those approved.
+func (r *runner) tui(ctx context.Context, in io.Reader, out io.Writer) error {
+	rows, err := r.readRows()
+	if err != nil {
+		return err
+	}
+
+	m := r.tuiModel(ctx, rows)
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithInput(in), tea.WithOutput(out), tea.WithContext(ctx))
+	if _, err := p.Run(); err != nil {
+		return err
+	}
+	// The alternate screen is gone, so print how it went.
+	fmt.Fprint(out, m.summary())
+	return m.markErr
+}
+
+// tuiModel returns the model of --tui's review of rows, which posts each
+// row approved and marks it complete.
+func (r *runner) tuiModel(ctx context.Context, rows []row) *tuiModel {
+	draft := func(rw row) string {
+		status, err := renderStatus(rw, r.rc)
+		if err != nil {
+			return ""
+		}
+		return status
+	}
+	publish := func(rw row) tea.Cmd {
+		return func() tea.Msg {
+			msg := postedMsg{num: rw.num}
+			var tweeted []row
+			tweeted, _, msg.err = r.tweet(ctx, []row{rw})
+			if _, err := r.markComplete(tweeted); err != nil {
+				msg.markErr = fmt.Errorf("%w: %w", ErrSheetWrite, err)
+			}
+			return msg
+		}
+	}
+
+	return newTUIModel(rows, r.displayStatus, draft, publish)
+}
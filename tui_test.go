@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestTUIModelUpdate(t *testing.T) {
+	rows := func() []row {
+		return []row{{num: 2, values: []interface{}{"a"}}, {num: 3, values: []interface{}{"b"}}, {num: 4, values: []interface{}{"c"}}}
+	}
+	render := func(rw row) string {
+		if rw.edited != "" {
+			return rw.edited
+		}
+		return rw.cell(0)
+	}
+	posted := func(num int) tea.Msg { return postedMsg{num: num} }
+
+	for _, tc := range []struct {
+		name        string
+		msgs        []tea.Msg
+		wantCur     int
+		wantPosted  []int
+		wantSkipped []int
+		wantFailed  []int
+		wantEdited  map[int]string
+	}{
+		{
+			name:    "nothing",
+			wantCur: 0,
+		},
+		{
+			name:        "skip",
+			msgs:        []tea.Msg{key("s")},
+			wantCur:     1,
+			wantSkipped: []int{2},
+		},
+		{
+			name:       "post",
+			msgs:       []tea.Msg{key("p"), posted(2)},
+			wantCur:    1,
+			wantPosted: []int{2},
+		},
+		{
+			name:    "keys are ignored while posting",
+			msgs:    []tea.Msg{key("p"), key("s"), key("q")},
+			wantCur: 0,
+		},
+		{
+			name:       "failed post moves on",
+			msgs:       []tea.Msg{key("p"), postedMsg{num: 2, err: errors.New("boom")}},
+			wantCur:    1,
+			wantFailed: []int{2},
+		},
+		{
+			name:        "quit skips the rest",
+			msgs:        []tea.Msg{key("s"), key("q")},
+			wantCur:     3,
+			wantSkipped: []int{2, 3, 4},
+		},
+		{
+			name:        "ctrl+c skips the rest",
+			msgs:        []tea.Msg{tea.KeyMsg{Type: tea.KeyCtrlC}},
+			wantCur:     3,
+			wantSkipped: []int{2, 3, 4},
+		},
+		{
+			name: "edit",
+			msgs: []tea.Msg{key("e"), tea.KeyMsg{Type: tea.KeyBackspace}, key("x"), tea.KeyMsg{Type: tea.KeySpace},
+				key("y"), tea.KeyMsg{Type: tea.KeyEnter}},
+			wantCur:    0,
+			wantEdited: map[int]string{0: "x y"},
+		},
+		{
+			name:       "cancelled edit",
+			msgs:       []tea.Msg{key("e"), key("x"), tea.KeyMsg{Type: tea.KeyEsc}},
+			wantCur:    0,
+			wantEdited: map[int]string{},
+		},
+		{
+			name:    "keys during an edit are text",
+			msgs:    []tea.Msg{key("e"), key("s"), key("q")},
+			wantCur: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []int
+			m := newTUIModel(rows(), render, render, func(rw row) tea.Cmd {
+				published = append(published, rw.num)
+				return func() tea.Msg { return posted(rw.num) }
+			})
+			for _, msg := range tc.msgs {
+				m.Update(msg)
+			}
+
+			if m.cur != tc.wantCur {
+				t.Errorf("cur = %d, want %d", m.cur, tc.wantCur)
+			}
+			if !reflect.DeepEqual(m.posted, tc.wantPosted) {
+				t.Errorf("posted = %v, want %v", m.posted, tc.wantPosted)
+			}
+			if !reflect.DeepEqual(m.skipped, tc.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", m.skipped, tc.wantSkipped)
+			}
+			if !reflect.DeepEqual(m.failed, tc.wantFailed) {
+				t.Errorf("failed = %v, want %v", m.failed, tc.wantFailed)
+			}
+			for i, want := range tc.wantEdited {
+				if m.rows[i].edited != want || m.statuses[i] != want {
+					t.Errorf("row %d: edited = %q, status = %q, want %q", i, m.rows[i].edited, m.statuses[i], want)
+				}
+			}
+			if tc.wantEdited != nil && len(tc.wantEdited) == 0 && m.rows[0].edited != "" {
+				t.Errorf("row 0: edited = %q, want no edit", m.rows[0].edited)
+			}
+		})
+	}
+}
+
+func TestTUIModelQuitsWhenDone(t *testing.T) {
+	none := func(row) string { return "" }
+	m := newTUIModel([]row{{num: 2}}, none, none, nil)
+	if _, cmd := m.Update(key("s")); cmd == nil {
+		t.Fatal("Update returned no command after the last row, want tea.Quit")
+	}
+	if !m.done() {
+		t.Error("done() = false after the last row")
+	}
+}
+
+// An edit starts from the row's unredacted template, and is posted with
+// the row's hashtags appended once.
+func TestTUIPostsEdit(t *testing.T) {
+	rc := testRunConfig()
+	rc.template = "{0} by {1}"
+	rc.redactColumns = map[int]bool{1: true}
+	rc.hashtags = []string{"#go"}
+	poster := &fakePoster{}
+	r := newTestRunner(poster, rc)
+
+	m := r.tuiModel(context.Background(), []row{{num: 2, values: []interface{}{"hello", "ann"}}})
+	if want := "hello by *** #go"; m.statuses[0] != want {
+		t.Errorf("status = %q, want %q", m.statuses[0], want)
+	}
+	m.Update(key("e"))
+	if want := "hello by ann"; string(m.edit) != want {
+		t.Errorf("edit = %q, want %q", string(m.edit), want)
+	}
+	m.Update(key("!"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd := m.Update(key("p"))
+	m.Update(cmd())
+
+	if want := []string{"hello by ann! #go"}; !reflect.DeepEqual(poster.statuses(), want) {
+		t.Errorf("posted %q, want %q", poster.statuses(), want)
+	}
+	if !reflect.DeepEqual(m.posted, []int{2}) {
+		t.Errorf("posted = %v, want [2]", m.posted)
+	}
+}
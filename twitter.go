@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chimeracoder/anaconda"
+)
+
+const maxTweetSize = 280 // wowee!
+
+// twitterPoster posts tweets through the Twitter REST API.
+type twitterPoster struct {
+	api *anaconda.TwitterApi
+	tc  *twitterConfig
+	// limits records the rate limit of the API's responses.
+	limits *rateLimitTransport
+}
+
+func newTwitterPoster(tc *twitterConfig) *twitterPoster {
+	anaconda.SetConsumerKey(tc.consumerKey)
+	anaconda.SetConsumerSecret(tc.consumerSecret)
+	api := anaconda.NewTwitterApi(tc.accessToken, tc.accessSecret)
+	if tc.apiBase != "" {
+		// anaconda joins the base URL and each endpoint's path directly.
+		api.SetBaseUrl(strings.TrimSuffix(tc.apiBase, "/"))
+	}
+	limits := &rateLimitTransport{base: http.DefaultTransport}
+	api.HttpClient = &http.Client{Transport: limits}
+	return &twitterPoster{api: api, tc: tc, limits: limits}
+}
+
+func (t *twitterPoster) Post(ctx context.Context, p *post) (string, error) {
+	v := url.Values{}
+	if len(p.mediaIDs) > 0 {
+		v.Set("media_ids", strings.Join(p.mediaIDs, ","))
+	}
+	if p.replyTo != "" {
+		v.Set("in_reply_to_status_id", p.replyTo)
+		v.Set("auto_populate_reply_metadata", "true")
+	}
+	if p.replySettings != "" && p.replySettings != repliesEveryone {
+		// Only v2 of the API can limit replies.
+		return t.postV2(ctx, p)
+	}
+	if p.geo != nil {
+		v.Set("lat", strconv.FormatFloat(p.geo.lat, 'f', -1, 64))
+		v.Set("long", strconv.FormatFloat(p.geo.long, 'f', -1, 64))
+		v.Set("display_coordinates", "true")
+	}
+
+	// A tweet is retried only if it wasn't posted, not to post it twice.
+	var tw anaconda.Tweet
+	err := retry(isUnprocessed, func() error {
+		var err error
+		tw, err = t.api.PostTweet(p.status, v)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return tw.IdStr, nil
+}
+
+func (t *twitterPoster) RateLimit() (int, time.Time, bool) {
+	return t.limits.RateLimit()
+}
+
+func (t *twitterPoster) Verify(ctx context.Context) error {
+	ok, err := t.api.VerifyCredentials()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the credentials were not accepted")
+	}
+	return nil
+}
+
+func (t *twitterPoster) UploadMedia(ctx context.Context, data []byte) (string, error) {
+	var m anaconda.Media
+	err := retry(isTransient, func() error {
+		var err error
+		m, err = t.api.UploadMedia(base64.StdEncoding.EncodeToString(data))
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return m.MediaIDString, nil
+}
+
+// Twitter's error codes for a tweet that doesn't exist, and for one that
+// was already retweeted.
+const (
+	twitterNoStatus         = 144
+	twitterAlreadyRetweeted = 327
+)
+
+func (t *twitterPoster) Repost(ctx context.Context, id string) (string, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad tweet ID %q", id)
+	}
+
+	rt, err := t.api.Retweet(n, true)
+	var aErr *anaconda.ApiError
+	if errors.As(err, &aErr) {
+		for _, e := range aErr.Decoded.Errors {
+			switch e.Code {
+			case twitterNoStatus:
+				return "", errPostNotFound
+			case twitterAlreadyRetweeted:
+				return "", errAlreadyReposted
+			}
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return rt.IdStr, nil
+}
+
+// timelineCount is how many of an account's latest tweets LatestPostID
+// asks for. Twitter counts retweets before leaving them out, so asking for
+// one would find none if the latest were a retweet.
+const timelineCount = 20
+
+// LatestPostID returns the ID of the latest tweet of screenName that isn't
+// a retweet, or "" if there's none among its latest timelineCount.
+func (t *twitterPoster) LatestPostID(ctx context.Context, screenName string) (string, error) {
+	v := url.Values{}
+	v.Set("screen_name", screenName)
+	v.Set("count", strconv.Itoa(timelineCount))
+	v.Set("exclude_replies", "false")
+	v.Set("include_rts", "false")
+
+	timeline, err := t.api.GetUserTimeline(v)
+	if err != nil {
+		return "", err
+	}
+	for _, tw := range timeline {
+		if tw.RetweetedStatus == nil {
+			return tw.IdStr, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestRunPostsToTwitterAPIBase runs against a fake Twitter API, as
+// --twitter_api_base allows, checking the statuses it's sent.
+func TestRunPostsToTwitterAPIBase(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []url.Values
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.URL.Path != "/statuses/update.json" {
+			http.NotFound(w, req)
+			return
+		}
+		if err := req.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, req.PostForm)
+		fmt.Fprintf(w, `{"id_str": "%d"}`, 100+len(bodies))
+	}))
+	defer api.Close()
+
+	f, srv := newFakeSheet(t,
+		[]string{"Word", "Number"},
+		[]string{"a", "one"},
+		[]string{"b", "two"},
+	)
+	bc := &backendConfig{name: backendTwitter, twitter: &twitterConfig{apiBase: api.URL + "/"}}
+	poster, err := newPoster(bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := testRunConfig()
+	rc.template = "{0} is {1}"
+	rc.completeValue = "{tweet_id}"
+	r := newSheetRunner(poster, rc, srv)
+	r.bc = bc
+	if err := r.run(context.Background()); err != nil {
+		t.Fatalf("run() = %v", err)
+	}
+
+	var statuses []string
+	for _, b := range bodies {
+		statuses = append(statuses, b.Get("status"))
+	}
+	if want := []string{"a is one", "b is two"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("posted %q, want %q", statuses, want)
+	}
+	for num, want := range map[int]string{2: "101", 3: "102"} {
+		if got := f.cell("D", num); got != want {
+			t.Errorf("status of row %d = %q, want %q", num, got, want)
+		}
+	}
+}
+
+// A tweet is retried only if the API shows it wasn't posted.
+func TestTwitterPosterPostRetries(t *testing.T) {
+	fastRetries(t)
+	for _, tc := range []struct {
+		name         string
+		status       int // of the first attempt.
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, wantAttempts: 2},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantAttempts: 2},
+		{name: "internal error", status: http.StatusInternalServerError, wantAttempts: 1, wantErr: true},
+		{name: "gateway timeout", status: http.StatusGatewayTimeout, wantAttempts: 1, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				attempts++
+				if attempts == 1 {
+					http.Error(w, `{"errors": [{"code": 131, "message": "Internal error"}]}`, tc.status)
+					return
+				}
+				fmt.Fprint(w, `{"id_str": "7"}`)
+			}))
+			defer srv.Close()
+
+			tp := newTwitterPoster(&twitterConfig{apiBase: srv.URL})
+			_, err := tp.Post(context.Background(), &post{status: "hi"})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Post() = %v, want an error: %t", err, tc.wantErr)
+			}
+			if attempts != tc.wantAttempts {
+				t.Errorf("made %d attempts, want %d", attempts, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestTwitterPosterLatestPostID(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		timeline string
+		want     string
+	}{
+		{name: "latest tweet", timeline: `[{"id_str": "3"}, {"id_str": "2"}]`, want: "3"},
+		{
+			name:     "retweet first",
+			timeline: `[{"id_str": "3", "retweeted_status": {"id_str": "1"}}, {"id_str": "2"}]`,
+			want:     "2",
+		},
+		{name: "only retweets", timeline: `[{"id_str": "3", "retweeted_status": {"id_str": "1"}}]`},
+		{name: "no tweets", timeline: `[]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/statuses/user_timeline.json" {
+					http.NotFound(w, req)
+					return
+				}
+				if got := req.FormValue("count"); got != "20" {
+					t.Errorf("count = %q, want 20", got)
+				}
+				fmt.Fprint(w, tc.timeline)
+			}))
+			defer srv.Close()
+
+			tp := newTwitterPoster(&twitterConfig{apiBase: srv.URL})
+			got, err := tp.LatestPostID(context.Background(), "me")
+			if err != nil {
+				t.Fatalf("LatestPostID() = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("LatestPostID() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Twitter counts every URL as this many characters, whatever its length.
+const tweetURLWeight = 23
+
+// lightRanges are the code point ranges that Twitter counts as a single
+// character. All others count as two.
+var lightRanges = [][2]rune{
+	{0, 4351},
+	{8192, 8205},
+	{8208, 8223},
+	{8242, 8247},
+}
+
+// weightedLength returns the length of s as Twitter counts it.
+func weightedLength(s string) int {
+	n := 0
+	forEachLink(s, func(text string) {
+		for _, r := range text {
+			n += runeWeight(r)
+		}
+	}, func(string) {
+		n += tweetURLWeight
+	})
+	return n
+}
+
+// leadingMentionsRE matches the @mentions at the start of a reply.
+var leadingMentionsRE = regexp.MustCompile(`^(?:[@＠]\w{1,15}\s+)+`)
+
+// weightedReplyLength is like weightedLength, but for a reply: its leading
+// @mentions become reply metadata, so they don't count toward its length.
+func weightedReplyLength(s string) int {
+	return weightedLength(leadingMentionsRE.ReplaceAllString(s, ""))
+}
+
+func runeWeight(r rune) int {
+	for _, lr := range lightRanges {
+		if r >= lr[0] && r <= lr[1] {
+			return 1
+		}
+	}
+	return 2
+}
+
+// tweetPathRE matches the path of a tweet's permalink, capturing its ID.
+var tweetPathRE = regexp.MustCompile(`^/(?:\w+|i/web)/status/(\d+)/?$`)
+
+// tweetIDFromURL returns the ID of the tweet whose permalink is u.
+func tweetIDFromURL(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.TrimPrefix(strings.ToLower(parsed.Host), "www.") {
+	case "twitter.com", "mobile.twitter.com", "x.com":
+	default:
+		return "", errors.New("not a Twitter URL")
+	}
+
+	m := tweetPathRE.FindStringSubmatch(parsed.Path)
+	if m == nil {
+		return "", errors.New("not a link to a tweet")
+	}
+	return m[1], nil
+}
+
+// runeLength is the length of s in runes.
+func runeLength(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// hitlist's own limits on the hashtags and mentions in a tweet, as tweets
+// with more tend to be treated as spam.
+const (
+	maxTweetHashtags = 10
+	maxTweetMentions = 10
+)
+
+// invalidTweetRunes are the characters that twitter-text rejects in tweets.
+var invalidTweetRunes = []rune{'\uFFFE', '\uFEFF', '\uFFFF', '\u202A', '\u202B', '\u202C', '\u202D', '\u202E'}
+
+var (
+	tweetHashtagRE = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])[#＃][\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*`)
+	tweetMentionRE = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_!#$%&*@＠])[@＠]\w{1,15}`)
+)
+
+// validateTweet checks s against Twitter's rules, as twitter-text does,
+// so that a tweet that would be rejected isn't attempted: it must not be
+// empty or longer than max, as measured by length, may only contain valid
+// characters, and mustn't have more than maxTweetHashtags hashtags or
+// maxTweetMentions mentions.
+func validateTweet(s string, max int, length func(string) int) error {
+	if strings.TrimSpace(s) == "" {
+		return errors.New("the tweet is empty")
+	}
+	if !utf8.ValidString(s) {
+		return errors.New("the tweet is not valid UTF-8")
+	}
+	for _, r := range invalidTweetRunes {
+		if strings.ContainsRune(s, r) {
+			return fmt.Errorf("the tweet contains the invalid character %U", r)
+		}
+	}
+	if n := length(s); n > max {
+		return fmt.Errorf("the tweet is %d characters long, more than %d", n, max)
+	}
+	if n := len(tweetHashtagRE.FindAllString(s, -1)); n > maxTweetHashtags {
+		return fmt.Errorf("the tweet has %d hashtags, more than %d", n, maxTweetHashtags)
+	}
+	if n := len(tweetMentionRE.FindAllString(s, -1)); n > maxTweetMentions {
+		return fmt.Errorf("the tweet has %d mentions, more than %d", n, maxTweetMentions)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeightedLength(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want int
+	}{
+		{in: "", want: 0},
+		{in: "hello", want: 5},
+		{in: "café", want: 4},
+		{in: "日本", want: 4},
+		{in: "👍", want: 2},
+		{in: "see https://example.com/a/very/long/path/indeed", want: 4 + tweetURLWeight},
+		{in: "http://a.co and http://b.co", want: 2*tweetURLWeight + 5},
+	} {
+		if got := weightedLength(tc.in); got != tc.want {
+			t.Errorf("weightedLength(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestWeightedReplyLength(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want int
+	}{
+		{in: "@alice hi", want: 2},
+		{in: "@alice @bob hi", want: 2},
+		{in: "hi @alice", want: 9},
+		{in: "@alice", want: 6},
+	} {
+		if got := weightedReplyLength(tc.in); got != tc.want {
+			t.Errorf("weightedReplyLength(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTweetIDFromURL(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "https://twitter.com/jack/status/20", want: "20"},
+		{in: "https://www.twitter.com/jack/status/20/", want: "20"},
+		{in: "https://mobile.twitter.com/jack/status/20", want: "20"},
+		{in: "https://x.com/jack/status/1234567890", want: "1234567890"},
+		{in: "https://twitter.com/i/web/status/20", want: "20"},
+		{in: "https://twitter.com/jack/status/20?s=20", want: "20"},
+		{in: "https://example.com/jack/status/20", wantErr: true},
+		{in: "https://twitter.com/jack", wantErr: true},
+		{in: "https://twitter.com/jack/status/abc", wantErr: true},
+		{in: "https://twitter.com/jack/status/20/photo/1", wantErr: true},
+		{in: "://bad", wantErr: true},
+	} {
+		got, err := tweetIDFromURL(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("tweetIDFromURL(%q) = %v, want error: %t", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("tweetIDFromURL(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestValidateTweet(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      string
+		max     int
+		wantErr string
+	}{
+		{name: "ok", in: "hello #go @golang", max: 280},
+		{name: "empty", in: "  ", max: 280, wantErr: "empty"},
+		{name: "invalid UTF-8", in: "a\xffb", max: 280, wantErr: "UTF-8"},
+		{name: "invalid character", in: "a\uFEFFb", max: 280, wantErr: "U+FEFF"},
+		{name: "too long", in: strings.Repeat("a", 11), max: 10, wantErr: "11 characters"},
+		{name: "exactly max", in: strings.Repeat("a", 10), max: 10},
+		{name: "too many hashtags", in: strings.Repeat("#tag ", maxTweetHashtags+1), max: 280, wantErr: "hashtags"},
+		{name: "max hashtags", in: strings.Repeat("#tag ", maxTweetHashtags), max: 280},
+		{name: "numeric hashtags don't count", in: strings.Repeat("#1 ", maxTweetHashtags+1), max: 280},
+		{name: "too many mentions", in: strings.Repeat("@user ", maxTweetMentions+1), max: 280, wantErr: "mentions"},
+		{name: "emails aren't mentions", in: strings.Repeat("a@b.co ", maxTweetMentions+1), max: 280},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTweet(tc.in, tc.max, runeLength)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateTweet() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("validateTweet() = %v, want an error mentioning %q", err, tc.wantErr)
+			}
+		})
+	}
+}
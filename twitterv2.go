@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+const twitterV2Base = "https://api.twitter.com/2"
+
+// twitterV2ReplySettings maps --reply_settings to the values of v2 of the
+// API.
+var twitterV2ReplySettings = map[string]string{
+	repliesFollowing: "following",
+	repliesMentioned: "mentionedUsers",
+}
+
+// twitterV2Tweet is the body of a request to v2 of the API to post a tweet.
+type twitterV2Tweet struct {
+	Text          string `json:"text"`
+	ReplySettings string `json:"reply_settings,omitempty"`
+	Reply         *struct {
+		InReplyToTweetID string `json:"in_reply_to_tweet_id"`
+	} `json:"reply,omitempty"`
+	Media *struct {
+		MediaIDs []string `json:"media_ids"`
+	} `json:"media,omitempty"`
+}
+
+// oauthClient returns the client and token that sign the poster's requests
+// made without anaconda.
+func (t *twitterPoster) oauthClient() (*oauth.Client, *oauth.Credentials) {
+	client := &oauth.Client{Credentials: oauth.Credentials{Token: t.tc.consumerKey, Secret: t.tc.consumerSecret}}
+	return client, &oauth.Credentials{Token: t.tc.accessToken, Secret: t.tc.accessSecret}
+}
+
+// postV2 posts p through v2 of the API, which, unlike v1.1, can limit who
+// replies. It can't tag a post's location, so doMain doesn't allow both.
+func (t *twitterPoster) postV2(ctx context.Context, p *post) (string, error) {
+	tw := &twitterV2Tweet{Text: p.status, ReplySettings: twitterV2ReplySettings[p.replySettings]}
+	if p.replyTo != "" {
+		tw.Reply = &struct {
+			InReplyToTweetID string `json:"in_reply_to_tweet_id"`
+		}{p.replyTo}
+	}
+	if len(p.mediaIDs) > 0 {
+		tw.Media = &struct {
+			MediaIDs []string `json:"media_ids"`
+		}{p.mediaIDs}
+	}
+	body, err := json.Marshal(tw)
+	if err != nil {
+		return "", err
+	}
+
+	base := twitterV2Base
+	if t.tc.apiBase != "" {
+		base = strings.TrimSuffix(strings.TrimSuffix(t.tc.apiBase, "/"), "/1.1") + "/2"
+	}
+	u, err := url.Parse(base + "/tweets")
+	if err != nil {
+		return "", err
+	}
+
+	client, token := t.oauthClient()
+	var resp *http.Response
+	err = retry(isUnprocessed, func() error {
+		req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		// A JSON body isn't signed, so only the URL is.
+		if err := client.SetAuthorizationHeader(req.Header, token, req.Method, u, nil); err != nil {
+			return err
+		}
+		resp, err = t.api.HttpClient.Do(req)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("POST %s returned %s: %s", u, resp.Status, bytes.TrimSpace(data))
+	}
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return "", fmt.Errorf("bad response %q: %w", data, err)
+	}
+	if created.Data.ID == "" {
+		return "", fmt.Errorf("no tweet ID in response %q", data)
+	}
+	return created.Data.ID, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTwitterPosterPostsReplySettingsThroughV2(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		post *post
+		want map[string]interface{}
+	}{
+		{
+			name: "following",
+			post: &post{status: "hi", replySettings: repliesFollowing},
+			want: map[string]interface{}{"text": "hi", "reply_settings": "following"},
+		},
+		{
+			name: "mentioned, with a reply and media",
+			post: &post{status: "hi", replySettings: repliesMentioned, replyTo: "5", mediaIDs: []string{"8", "9"}},
+			want: map[string]interface{}{
+				"text":           "hi",
+				"reply_settings": "mentionedUsers",
+				"reply":          map[string]interface{}{"in_reply_to_tweet_id": "5"},
+				"media":          map[string]interface{}{"media_ids": []interface{}{"8", "9"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got map[string]interface{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodPost || req.URL.Path != "/2/tweets" {
+					http.NotFound(w, req)
+					return
+				}
+				if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"data": {"id": "123", "text": "hi"}}`))
+			}))
+			defer srv.Close()
+
+			tp := newTwitterPoster(&twitterConfig{apiBase: srv.URL + "/1.1/"})
+			id, err := tp.Post(context.Background(), tc.post)
+			if err != nil || id != "123" {
+				t.Fatalf("Post() = %q, %v, want %q", id, err, "123")
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("posted %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTwitterPosterV2Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "forbidden", status: http.StatusForbidden, body: `{"title": "Forbidden"}`},
+		{name: "no ID", status: http.StatusCreated, body: `{"data": {}}`},
+		{name: "not JSON", status: http.StatusCreated, body: `<html>`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			tp := newTwitterPoster(&twitterConfig{apiBase: srv.URL})
+			if _, err := tp.Post(context.Background(), &post{status: "hi", replySettings: repliesFollowing}); err == nil {
+				t.Error("Post() succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "net/http"
+
+// version is the version of hitlist, set at build time with
+// -ldflags "-X main.version=...".
+var version = "dev"
+
+// userAgentTransport sets the User-Agent header of every request it sends.
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper mustn't modify the request it's given.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// setUserAgent makes every request sent through http.DefaultTransport, which
+// the Sheets, Twitter and Bluesky clients all use, carry userAgent.
+func setUserAgent(userAgent string) {
+	http.DefaultTransport = &userAgentTransport{userAgent: userAgent, base: http.DefaultTransport}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUserAgentTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &userAgentTransport{userAgent: "hitlist/test", base: http.DefaultTransport}}
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "original")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() = %v", err)
+	}
+	resp.Body.Close()
+
+	if got != "hitlist/test" {
+		t.Errorf("User-Agent = %q, want %q", got, "hitlist/test")
+	}
+	if ua := req.Header.Get("User-Agent"); ua != "original" {
+		t.Errorf("the request given was modified: User-Agent = %q", ua)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// utmParams returns the UTM query parameters set by --utm_source,
+// --utm_medium and --utm_campaign, leaving out those that are empty.
+func utmParams(source, medium, campaign string) url.Values {
+	v := url.Values{}
+	for k, p := range map[string]string{"utm_source": source, "utm_medium": medium, "utm_campaign": campaign} {
+		if p != "" {
+			v.Set(k, p)
+		}
+	}
+	return v
+}
+
+// tagURL adds params to the query of the URL raw, keeping its other
+// parameters. It's an error if raw isn't an absolute URL.
+func tagURL(raw string, params url.Values) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL", raw)
+	}
+	if len(params) == 0 {
+		return raw, nil
+	}
+
+	q := u.Query()
+	for k, vs := range params {
+		q[k] = vs
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestTagURL(t *testing.T) {
+	utm := utmParams("twitter", "social", "launch")
+	for _, tc := range []struct {
+		raw     string
+		params  url.Values
+		want    string
+		wantErr bool
+	}{
+		{
+			raw:    "https://example.com/post",
+			params: utm,
+			want:   "https://example.com/post?utm_campaign=launch&utm_medium=social&utm_source=twitter",
+		},
+		{
+			raw:    "https://example.com/post?id=7#top",
+			params: utm,
+			want:   "https://example.com/post?id=7&utm_campaign=launch&utm_medium=social&utm_source=twitter#top",
+		},
+		{
+			raw:    "https://example.com/post?utm_source=old",
+			params: utmParams("new", "", ""),
+			want:   "https://example.com/post?utm_source=new",
+		},
+		{raw: "https://example.com/a?b=1", params: url.Values{}, want: "https://example.com/a?b=1"},
+		{raw: "example.com/post", params: utm, wantErr: true},
+		{raw: "/post", params: utm, wantErr: true},
+		{raw: "http://[::1", params: utm, wantErr: true},
+	} {
+		got, err := tagURL(tc.raw, tc.params)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("tagURL(%q) = %v, want error: %t", tc.raw, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("tagURL(%q) = %q, want %q", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestUTMParams(t *testing.T) {
+	if v := utmParams("", "", ""); len(v) != 0 {
+		t.Errorf("utmParams() = %v, want none", v)
+	}
+	if v := utmParams("", "email", ""); v.Encode() != "utm_medium=email" {
+		t.Errorf("utmParams() = %q, want %q", v.Encode(), "utm_medium=email")
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// videoUploader is implemented by Posters that upload videos differently
+// from images.
+type videoUploader interface {
+	// UploadVideo uploads a video of the given MIME type and returns its
+	// media ID once it's ready to be posted.
+	UploadVideo(ctx context.Context, data []byte, mimeType string) (string, error)
+}
+
+// videoMIMEType returns the MIME type of the video at u, going by its
+// extension as mediaKind does.
+func videoMIMEType(u string) string {
+	if strings.HasSuffix(strings.ToLower(strings.SplitN(u, "?", 2)[0]), ".mov") {
+		return "video/quicktime"
+	}
+	return "video/mp4"
+}
+
+const (
+	twitterUploadBase = "https://upload.twitter.com/1.1"
+	// videoChunkSize is the size of each part of a chunked upload, well
+	// under Twitter's limit of 5MB.
+	videoChunkSize = 1 << 20
+	// The longest and shortest waits between checks of a video's
+	// processing.
+	minVideoPoll = time.Second
+	maxVideoPoll = 30 * time.Second
+)
+
+// The states of a video's processing, as reported by Twitter.
+const (
+	videoPending    = "pending"
+	videoInProgress = "in_progress"
+	videoSucceeded  = "succeeded"
+	videoFailed     = "failed"
+)
+
+// videoUploadResponse is the JSON of a chunked upload's INIT, FINALIZE and
+// STATUS commands.
+type videoUploadResponse struct {
+	MediaIDString  string `json:"media_id_string"`
+	ProcessingInfo *struct {
+		State          string `json:"state"`
+		CheckAfterSecs int    `json:"check_after_secs"`
+		Error          *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"processing_info"`
+}
+
+// UploadVideo uploads a video in chunks, then waits, checking with growing
+// intervals, until Twitter has processed it, or until --video_timeout.
+func (t *twitterPoster) UploadVideo(ctx context.Context, data []byte, mimeType string) (string, error) {
+	base := twitterUploadBase
+	if t.tc.apiBase != "" {
+		base = strings.TrimSuffix(t.tc.apiBase, "/")
+	}
+	endpoint := base + "/media/upload.json"
+
+	init, err := t.uploadCommand(ctx, http.MethodPost, endpoint, url.Values{
+		"command":        {"INIT"},
+		"total_bytes":    {strconv.Itoa(len(data))},
+		"media_type":     {mimeType},
+		"media_category": {"tweet_video"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start video upload: %w", err)
+	}
+	id := init.MediaIDString
+
+	for i := 0; i*videoChunkSize < len(data); i++ {
+		end := (i + 1) * videoChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err := t.uploadCommand(ctx, http.MethodPost, endpoint, url.Values{
+			"command":       {"APPEND"},
+			"media_id":      {id},
+			"segment_index": {strconv.Itoa(i)},
+			"media_data":    {base64.StdEncoding.EncodeToString(data[i*videoChunkSize : end])},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to upload part %d of video: %w", i, err)
+		}
+	}
+
+	resp, err := t.uploadCommand(ctx, http.MethodPost, endpoint, url.Values{
+		"command":  {"FINALIZE"},
+		"media_id": {id},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to finish video upload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.tc.videoTimeout)
+	defer cancel()
+	wait := minVideoPoll
+	for resp.ProcessingInfo != nil {
+		info := resp.ProcessingInfo
+		switch info.State {
+		case videoSucceeded:
+			return id, nil
+		case videoFailed:
+			msg := "no reason given"
+			if info.Error != nil {
+				msg = info.Error.Message
+			}
+			return "", fmt.Errorf("Twitter failed to process video %s: %s", id, msg)
+		case videoPending, videoInProgress:
+		default:
+			return "", fmt.Errorf("video %s is in unknown processing state %q", id, info.State)
+		}
+
+		// Wait at least as long as Twitter asks, backing off from there.
+		if after := time.Duration(info.CheckAfterSecs) * time.Second; after > wait {
+			wait = after
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("gave up waiting for video %s to be processed: %w", id, ctx.Err())
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxVideoPoll {
+			wait = maxVideoPoll
+		}
+
+		if resp, err = t.uploadCommand(ctx, http.MethodGet, endpoint, url.Values{
+			"command":  {"STATUS"},
+			"media_id": {id},
+		}); err != nil {
+			return "", fmt.Errorf("failed to check processing of video %s: %w", id, err)
+		}
+	}
+	// Without processing info, the video is ready as soon as it's uploaded.
+	return id, nil
+}
+
+// uploadCommand sends a command of a chunked upload, signed with the
+// poster's credentials, and decodes the response, if there is one.
+func (t *twitterPoster) uploadCommand(ctx context.Context, method, endpoint string, form url.Values) (*videoUploadResponse, error) {
+	client, token := t.oauthClient()
+
+	// A POST, such as the INIT that creates the upload, is retried only if
+	// it wasn't acted on.
+	shouldRetry := isTransient
+	if method != http.MethodGet {
+		shouldRetry = isUnprocessed
+	}
+	var resp *http.Response
+	err := retry(shouldRetry, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		if method == http.MethodGet {
+			resp, err = client.Get(http.DefaultClient, token, endpoint, form)
+		} else {
+			resp, err = client.Post(http.DefaultClient, token, endpoint, form)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	r := &videoUploadResponse{}
+	if len(body) == 0 {
+		return r, nil
+	}
+	if err := json.Unmarshal(body, r); err != nil {
+		return nil, fmt.Errorf("bad response %q: %w", body, err)
+	}
+	if r.MediaIDString == "" && form.Get("command") == "INIT" {
+		return nil, errors.New("no media ID in response")
+	}
+	return r, nil
+}
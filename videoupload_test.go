@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestVideoMIMEType(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "https://example.com/a.mp4", want: "video/mp4"},
+		{in: "https://example.com/a.MOV", want: "video/quicktime"},
+		{in: "https://example.com/a.mov?dl=1", want: "video/quicktime"},
+		{in: "https://example.com/a.m4v", want: "video/mp4"},
+	} {
+		if got := videoMIMEType(tc.in); got != tc.want {
+			t.Errorf("videoMIMEType(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
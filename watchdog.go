@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+)
+
+// rowContext returns the context for posting a single row, which with
+// --per_tweet_timeout is cancelled once that has passed.
+func (r *runner) rowContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.rc.perTweetTimeout > 0 {
+		return context.WithTimeout(ctx, r.rc.perTweetTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// within calls fn, but returns ctx's error as soon as ctx is done, even if
+// fn hasn't returned, as some clients, such as Twitter's, ignore contexts.
+// fn is then left to finish in the background.
+func within(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithin(t *testing.T) {
+	errFn := errors.New("fn failed")
+	for _, tc := range []struct {
+		name    string
+		timeout time.Duration
+		fn      func() error
+		wantErr error
+	}{
+		{name: "returns", timeout: time.Second, fn: func() error { return nil }},
+		{name: "fails", timeout: time.Second, fn: func() error { return errFn }, wantErr: errFn},
+		{
+			name:    "ignores the context",
+			timeout: 20 * time.Millisecond,
+			fn:      func() error { time.Sleep(time.Second); return nil },
+			wantErr: context.DeadlineExceeded,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+			start := time.Now()
+			if err := within(ctx, tc.fn); !errors.Is(err, tc.wantErr) {
+				t.Errorf("within() = %v, want %v", err, tc.wantErr)
+			}
+			if d := time.Since(start); d > tc.timeout+500*time.Millisecond {
+				t.Errorf("within() took %v, more than the timeout of %v", d, tc.timeout)
+			}
+		})
+	}
+}
+
+func TestRowContext(t *testing.T) {
+	for _, tc := range []struct {
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{timeout: 0},
+		{timeout: time.Minute, wantDeadline: true},
+	} {
+		rc := testRunConfig()
+		rc.perTweetTimeout = tc.timeout
+		r := newTestRunner(&fakePoster{}, rc)
+
+		ctx, cancel := r.rowContext(context.Background())
+		if _, ok := ctx.Deadline(); ok != tc.wantDeadline {
+			t.Errorf("rowContext() with --per_tweet_timeout=%v has a deadline: %t, want %t", tc.timeout, ok, tc.wantDeadline)
+		}
+		cancel()
+		if ctx.Err() == nil {
+			t.Errorf("rowContext() with --per_tweet_timeout=%v isn't cancelled by its cancel func", tc.timeout)
+		}
+	}
+}
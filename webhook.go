@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds each call to the webhook.
+const webhookTimeout = 10 * time.Second
+
+// notifyWebhook posts text to the webhook at url, as the "text" field of a
+// JSON object, which is what Slack and compatible chat webhooks expect.
+func notifyWebhook(ctx context.Context, url, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// defaultEmptyMessage is logged when there's nothing to tweet and no
+// --empty_message is set.
+const defaultEmptyMessage = "nothing to tweet"
+
+// renderEmptyMessage renders the --empty_message template, replacing
+// "{sheet}" with the sheet's name and "{time}" with now.
+func renderEmptyMessage(tmpl, sheet string, now time.Time) string {
+	return strings.NewReplacer(
+		"{sheet}", sheet,
+		"{time}", now.Format(time.RFC3339),
+	).Replace(tmpl)
+}
+
+// reportEmpty reports that there are no pending rows: with --empty_message
+// it's printed, and sent to --webhook_url if set, and otherwise it's just
+// logged. A failure to notify the webhook is only a warning.
+func (r *runner) reportEmpty(ctx context.Context) {
+	if r.rc.emptyMessage == "" {
+		log.Print(defaultEmptyMessage)
+		return
+	}
+
+	msg := renderEmptyMessage(r.rc.emptyMessage, r.sc.name, r.now())
+	fmt.Println(msg)
+	if r.rc.webhookURL != "" {
+		if err := notifyWebhook(ctx, r.rc.webhookURL, msg); err != nil {
+			log.Printf("warning: failed to notify the webhook: %v", err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		code    int
+		wantErr bool
+	}{
+		{name: "ok", code: http.StatusOK},
+		{name: "no content", code: http.StatusNoContent},
+		{name: "not found", code: http.StatusNotFound, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tc.code)
+			}))
+			defer srv.Close()
+
+			err := notifyWebhook(context.Background(), srv.URL, "nothing to do")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("notifyWebhook() = %v, want error: %t", err, tc.wantErr)
+			}
+			if got["text"] != "nothing to do" {
+				t.Errorf("the webhook got %v, want the text", got)
+			}
+		})
+	}
+}
+
+func TestRenderEmptyMessage(t *testing.T) {
+	now := time.Date(2024, 6, 3, 14, 5, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{in: "all done", want: "all done"},
+		{in: "{sheet} is empty", want: "Queue is empty"},
+		{in: "checked at {time}", want: "checked at 2024-06-03T14:05:00Z"},
+	} {
+		if got := renderEmptyMessage(tc.in, "Queue", now); got != tc.want {
+			t.Errorf("renderEmptyMessage(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}